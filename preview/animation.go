@@ -0,0 +1,72 @@
+package preview
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"strconv"
+)
+
+// 动图每帧尺寸必须一致，缩放后的截图居中放入固定大小的画布。
+func fitToCanvas(img image.Image, width, height int, background color.Color) image.Image {
+	scaled := scaleToFit(img, width, height)
+	canvas := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(canvas, canvas.Bounds(), &image.Uniform{C: background}, image.Point{}, draw.Src)
+
+	bounds := scaled.Bounds()
+	offset := image.Pt((width-bounds.Dx())/2, (height-bounds.Dy())/2)
+	draw.Draw(canvas, bounds.Sub(bounds.Min).Add(offset), scaled, bounds.Min, draw.Over)
+	return canvas
+}
+
+func encodeAnimatedWebP(r Runner, frames []image.Image, path string, fps float64, quality int, deterministic bool) error {
+	if err := ensureOutputDir(path); err != nil {
+		return err
+	}
+
+	args := []string{
+		"-loglevel", "error",
+		"-y",
+		"-f", "image2pipe",
+		"-framerate", strconv.FormatFloat(fps, 'f', -1, 64),
+		"-c:v", "png",
+		"-i", "-",
+		"-c:v", "libwebp_anim",
+		"-loop", "0",
+		"-quality", strconv.Itoa(quality),
+	}
+	args = append(args, bitexactArgs(deterministic)...)
+	cmd := newCommand(context.Background(), r, ffmpegPath, append(args, toolPath(path))...)
+	var stderr stderrTail
+	cmd.Stderr = &stderr
+
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		observeToolFailure("animation")
+		return fmt.Errorf("启动 ffmpeg 失败: %w", err)
+	}
+
+	encoder := png.Encoder{CompressionLevel: png.BestSpeed}
+	var writeErr error
+	for _, frame := range frames {
+		if writeErr = encoder.Encode(stdin, frame); writeErr != nil {
+			break
+		}
+	}
+	stdin.Close()
+
+	if err := cmd.Wait(); err != nil {
+		observeToolFailure("animation")
+		return fmt.Errorf("生成动态 WebP 失败: %w", commandError(err, stderr.Bytes()))
+	}
+	if writeErr != nil {
+		return fmt.Errorf("写入动态 WebP 帧失败: %w", writeErr)
+	}
+	return nil
+}
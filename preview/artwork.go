@@ -0,0 +1,112 @@
+package preview
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// artworkImage 为媒体中心识别的一种本地图片：suffix 追加在视频文件名 (去掉扩展名) 之后，
+// position 为截取位置占视频时长的比例。
+type artworkImage struct {
+	suffix   string
+	width    int
+	height   int
+	position float64
+}
+
+// artworkPresets 按各媒体中心的本地图片命名规则与推荐尺寸生成海报 (2:3)、背景图 (16:9) 与剧集缩略图。
+// Plex 把与视频同名的图片作为海报，没有单独的缩略图文件。
+var artworkPresets = map[string][]artworkImage{
+	"kodi": {
+		{"-poster.jpg", 1000, 1500, 0.5},
+		{"-fanart.jpg", 1920, 1080, 0.35},
+		{"-thumb.jpg", 1280, 720, 0.2},
+	},
+	"jellyfin": {
+		{"-poster.jpg", 1000, 1500, 0.5},
+		{"-backdrop.jpg", 1920, 1080, 0.35},
+		{"-thumb.jpg", 1280, 720, 0.2},
+	},
+	"plex": {
+		{".jpg", 1000, 1500, 0.5},
+		{"-fanart.jpg", 1920, 1080, 0.35},
+	},
+}
+
+// artworkUnsupported 为 --artwork 不支持的参数：除 --at 的限制外，图片尺寸与文件名由预设决定。
+var artworkUnsupported = append([]string{"at", "variants", "max-bytes", "embed-cover", "publish"}, thumbnailUnsupported...)
+
+func artworkNames() string {
+	names := make([]string, 0, len(artworkPresets))
+	for name := range artworkPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return strings.Join(names, "、")
+}
+
+// artworkPaths 返回 --artwork 生成的图片路径，与输入视频位于同一目录。
+func artworkPaths(cfg *gridConfig) []string {
+	base := strings.TrimSuffix(cfg.input, filepath.Ext(cfg.input))
+	images := artworkPresets[cfg.artwork]
+	paths := make([]string, len(images))
+	for i, art := range images {
+		paths[i] = base + art.suffix
+	}
+	return paths
+}
+
+// renderArtwork 按 --artwork 预设在各自的位置截图，裁剪缩放到媒体中心要求的尺寸后保存为 JPEG。
+func renderArtwork(cfg *gridConfig, meta *videoMetadata, result *previewResult) error {
+	images := artworkPresets[cfg.artwork]
+	timestamps := make([]float64, len(images))
+	for i, art := range images {
+		position := thumbnailPosition{value: art.position * 100, percent: true}
+		ts, err := position.seconds(meta)
+		if err != nil {
+			return err
+		}
+		timestamps[i] = ts
+	}
+
+	paths := artworkPaths(cfg)
+	opts := cfg.encodeOptions()
+	opts.format = "jpeg"
+	for captured, err := range captureFrames(cfg, meta, timestamps, captureFilters(cfg, meta)) {
+		if err != nil {
+			return err
+		}
+		i, frame := captured.index, captured.image
+		if cfg.frameHook != nil {
+			if frame, err = cfg.frameHook(i, captured.timestamp, frame); err != nil {
+				return err
+			}
+		}
+		art := fillCrop(frame, images[i].width, images[i].height)
+		if cfg.autoLevels {
+			art = autoLevels(art)
+		}
+		if err := saveImage(art, paths[i], opts); err != nil {
+			return fmt.Errorf("保存 %s 失败: %w", filepath.Base(paths[i]), err)
+		}
+	}
+	result.timestamps = append(result.timestamps, timestamps...)
+	return nil
+}
+
+// fillCrop 把图片等比缩放到完全覆盖 width x height，再居中裁掉超出的部分。
+func fillCrop(img image.Image, width, height int) image.Image {
+	bounds := img.Bounds()
+	scale := max(float64(width)/float64(bounds.Dx()), float64(height)/float64(bounds.Dy()))
+	w, h := int(float64(width)/scale), int(float64(height)/scale)
+	src := image.Rect(0, 0, w, h).Add(bounds.Min).Add(image.Pt((bounds.Dx()-w)/2, (bounds.Dy()-h)/2))
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), img, src, draw.Src, nil)
+	return dst
+}
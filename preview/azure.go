@@ -0,0 +1,157 @@
+package preview
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/Azure/azure-sdk-for-go/sdk/azidentity"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/blob"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/sas"
+	"github.com/Azure/azure-sdk-for-go/sdk/storage/azblob/service"
+)
+
+// azureBackend 处理 az://account/container/blob 形式的地址，每个存储账号对应一个客户端。
+type azureBackend struct {
+	clients map[string]*azblob.Client
+	keys    map[string]*azblob.SharedKeyCredential
+}
+
+func newAzureBackend(ctx context.Context, opts storageOptions) (storageBackend, error) {
+	return &azureBackend{
+		clients: make(map[string]*azblob.Client),
+		keys:    make(map[string]*azblob.SharedKeyCredential),
+	}, nil
+}
+
+func parseAzureURI(uri string) (account, container, blobName string, err error) {
+	account, rest, err := splitStorageURI(uri)
+	if err != nil {
+		return "", "", "", err
+	}
+	container, blobName, _ = strings.Cut(rest, "/")
+	if container == "" || blobName == "" {
+		return "", "", "", fmt.Errorf("无效的 Azure 地址: %s (应为 az://account/container/blob)", uri)
+	}
+	return account, container, blobName, nil
+}
+
+// client 优先使用 AZURE_STORAGE_KEY 共享密钥，否则使用 DefaultAzureCredential (环境变量、托管身份、az login 等)。
+func (b *azureBackend) client(account string) (*azblob.Client, error) {
+	if client, ok := b.clients[account]; ok {
+		return client, nil
+	}
+	serviceURL := fmt.Sprintf("https://%s.blob.core.windows.net/", account)
+
+	var client *azblob.Client
+	if key := os.Getenv("AZURE_STORAGE_KEY"); key != "" {
+		credential, err := azblob.NewSharedKeyCredential(account, key)
+		if err != nil {
+			return nil, fmt.Errorf("Azure 存储密钥无效: %w", err)
+		}
+		if client, err = azblob.NewClientWithSharedKeyCredential(serviceURL, credential, nil); err != nil {
+			return nil, err
+		}
+		b.keys[account] = credential
+	} else {
+		credential, err := azidentity.NewDefaultAzureCredential(nil)
+		if err != nil {
+			return nil, fmt.Errorf("加载 Azure 凭证失败: %w", err)
+		}
+		if client, err = azblob.NewClient(serviceURL, credential, nil); err != nil {
+			return nil, err
+		}
+	}
+	b.clients[account] = client
+	return client, nil
+}
+
+// presign 生成只读 SAS 地址: 共享密钥直接签名，Entra ID 身份则先申请 user delegation key。
+func (b *azureBackend) presign(ctx context.Context, uri string, expiry time.Duration) (string, error) {
+	account, container, blobName, err := parseAzureURI(uri)
+	if err != nil {
+		return "", err
+	}
+	client, err := b.client(account)
+	if err != nil {
+		return "", err
+	}
+	blobClient := client.ServiceClient().NewContainerClient(container).NewBlobClient(blobName)
+
+	if _, ok := b.keys[account]; ok {
+		return blobClient.GetSASURL(sas.BlobPermissions{Read: true}, time.Now().Add(expiry), nil)
+	}
+
+	start := time.Now().UTC().Add(-5 * time.Minute)
+	end := time.Now().UTC().Add(expiry)
+	formatted := func(t time.Time) *string {
+		value := t.Format(sas.TimeFormat)
+		return &value
+	}
+	delegation, err := client.ServiceClient().GetUserDelegationCredential(ctx, service.KeyInfo{
+		Start:  formatted(start),
+		Expiry: formatted(end),
+	}, nil)
+	if err != nil {
+		return "", err
+	}
+	values := sas.BlobSignatureValues{
+		Protocol:      sas.ProtocolHTTPS,
+		StartTime:     start,
+		ExpiryTime:    end,
+		Permissions:   (&sas.BlobPermissions{Read: true}).String(),
+		ContainerName: container,
+		BlobName:      blobName,
+	}
+	query, err := values.SignWithUserDelegation(delegation)
+	if err != nil {
+		return "", err
+	}
+	return blobClient.URL() + "?" + query.Encode(), nil
+}
+
+func (b *azureBackend) download(ctx context.Context, uri, path string) error {
+	account, container, blobName, err := parseAzureURI(uri)
+	if err != nil {
+		return err
+	}
+	client, err := b.client(account)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = client.DownloadFile(ctx, container, blobName, file, nil)
+	return err
+}
+
+func (b *azureBackend) upload(ctx context.Context, path, uri string) error {
+	account, container, blobName, err := parseAzureURI(uri)
+	if err != nil {
+		return err
+	}
+	client, err := b.client(account)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var options azblob.UploadFileOptions
+	if contentType := mime.TypeByExtension(filepath.Ext(blobName)); contentType != "" {
+		options.HTTPHeaders = &blob.HTTPHeaders{BlobContentType: &contentType}
+	}
+	_, err = client.UploadFile(ctx, container, blobName, file, &options)
+	return err
+}
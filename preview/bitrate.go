@@ -0,0 +1,115 @@
+package preview
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var bitrateBarColor = color.NRGBA{60, 130, 220, 255}
+
+// videoBitrates 读取序号为 stream 的视频流每个数据包的时间与大小，返回每秒的码率 (bit/s)。
+func videoBitrates(ctx context.Context, r Runner, path string, stream int, duration float64) ([]float64, error) {
+	cmd := newCommand(ctx, r, ffprobePath,
+		"-v", "error",
+		"-select_streams", strconv.Itoa(stream),
+		"-show_entries", "packet=pts_time,dts_time,size",
+		"-of", "csv=p=0",
+		toolPath(path),
+	)
+	var stderr stderrTail
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		observeToolFailure("probe")
+		return nil, err
+	}
+
+	seconds := make([]float64, max(int(math.Ceil(duration)), 1))
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		// 字段顺序固定为 pts_time,dts_time,size，B 帧较多的流中 pts 可能为 N/A，此时退回 dts。
+		fields := strings.Split(scanner.Text(), ",")
+		if len(fields) < 3 {
+			continue
+		}
+		t := parseFloat(fields[0])
+		if fields[0] == "N/A" {
+			t = parseFloat(fields[1])
+		}
+		second := min(max(int(t), 0), len(seconds)-1)
+		seconds[second] += float64(parseInt(fields[2]) * 8)
+	}
+	if err := cmd.Wait(); err != nil {
+		observeToolFailure("probe")
+		return nil, fmt.Errorf("读取数据包信息失败: %w", commandError(err, stderr.Bytes()))
+	}
+	return seconds, nil
+}
+
+func bitrateGraphHeight(width int) int {
+	return max(60, width/10)
+}
+
+// addBitrateGraph 在拼图下方绘制视频码率随时间变化的柱状图，标注峰值与平均码率，并标出各截图的采样时间点。
+func addBitrateGraph(sheet image.Image, cfg *gridConfig, meta *videoMetadata, timestamps []float64) (image.Image, error) {
+	height := bitrateGraphHeight(sheet.Bounds().Dx())
+	canvas, area := appendPanel(sheet, cfg, height)
+	if area.Empty() {
+		return sheet, nil
+	}
+
+	seconds, err := videoBitrates(cfg.context(), cfg.runner, cfg.input, cfg.streamIndex, meta.duration)
+	if err != nil {
+		return nil, err
+	}
+
+	// 每个像素列取其覆盖的各秒码率的平均值；视频短于绘图宽度时每秒占据多列。
+	columns := make([]float64, area.Dx())
+	var peak, sum float64
+	for _, bits := range seconds {
+		peak = math.Max(peak, bits)
+		sum += bits
+	}
+	for x := range columns {
+		from := x * len(seconds) / len(columns)
+		to := max((x+1)*len(seconds)/len(columns), from+1)
+		var total float64
+		for _, bits := range seconds[from:to] {
+			total += bits
+		}
+		columns[x] = total / float64(to-from)
+	}
+
+	ink := contrastColor(cfg.background)
+	draw.Draw(canvas, image.Rect(area.Min.X, area.Max.Y-1, area.Max.X, area.Max.Y), &image.Uniform{C: withAlpha(ink, 120)}, image.Point{}, draw.Over)
+	if peak > 0 {
+		bar := &image.Uniform{C: bitrateBarColor}
+		for x, bits := range columns {
+			top := area.Max.Y - int(math.Round(bits/peak*float64(height)))
+			draw.Draw(canvas, image.Rect(area.Min.X+x, top, area.Min.X+x+1, area.Max.Y), bar, image.Point{}, draw.Over)
+		}
+		average := sum / float64(len(seconds))
+		y := area.Max.Y - int(math.Round(average/peak*float64(height)))
+		draw.Draw(canvas, image.Rect(area.Min.X, y, area.Max.X, y+1), &image.Uniform{C: withAlpha(ink, 160)}, image.Point{}, draw.Over)
+	}
+	drawSampleMarkers(canvas, area, timestamps, meta.duration)
+
+	size := math.Max(10, float64(sheet.Bounds().Dx())/96)
+	face, err := fontFace(size)
+	if err != nil {
+		return nil, err
+	}
+	label := fmt.Sprintf("Video bitrate    peak %d kb/s    avg %d kb/s", int(peak/1000), int(sum/float64(len(seconds))/1000))
+	drawTextLeft(canvas, image.Rect(area.Min.X+int(size/2), area.Min.Y, area.Max.X, area.Min.Y+int(size*1.6)), label, face, ink)
+	return canvas, nil
+}
@@ -0,0 +1,219 @@
+package preview
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var frameCacheHits = prometheus.NewCounter(prometheus.CounterOpts{
+	Name: "vpi_frame_cache_hits_total",
+	Help: "从截图缓存读取、无需重新解码的帧数。",
+})
+
+func init() {
+	metricsRegistry.MustRegister(frameCacheHits)
+}
+
+// frameCache 按视频内容哈希、采样时间点与截图方式 (滤镜链、是否只取关键帧、选帧方式、管道格式与后端) 缓存原始分辨率的截图，
+// 调整布局、单格尺寸或样式后重新生成时无需再次解码视频。
+type frameCache struct {
+	dir     string
+	video   string
+	variant string
+}
+
+func defaultCacheDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, toolName, "frames")
+}
+
+// openFrameCache 在缓存被禁用或输入不是本地文件 (例如预签名地址) 时返回 nil。
+func openFrameCache(cfg *gridConfig, filters []string) *frameCache {
+	if cfg.noCache {
+		return nil
+	}
+	dir := cfg.cacheDir
+	if dir == "" {
+		if dir = defaultCacheDir(); dir == "" {
+			return nil
+		}
+	}
+	if cfg.cacheMaxMB > 0 {
+		pruneFrameCache(dir, cfg.cacheMaxMB<<20)
+	}
+	hash, err := contentHash(dir, cfg.input)
+	if err != nil {
+		return nil
+	}
+	variant := strings.Join(filters, ",")
+	if cfg.keyframesOnly {
+		variant += "|keyframes"
+	}
+	if cfg.selector != "uniform" {
+		variant += "|" + cfg.selector
+	}
+	if cfg.stream != "" {
+		variant += fmt.Sprintf("|stream=%d", cfg.streamIndex)
+	}
+	// mjpeg 管道有损，各后端的解码路径也不同，截图像素与默认的 png 管道、go 后端不完全一致，需要分别缓存。
+	if cfg.pipeCodec != "png" {
+		variant += "|pipe=" + cfg.pipeCodec
+	}
+	if cfg.backend != "go" {
+		variant += "|backend=" + cfg.backend
+	}
+	return &frameCache{dir: dir, video: hash, variant: variant}
+}
+
+// contentHash 返回 path 完整内容的 SHA-256。完整读取大文件较慢，结果按绝对路径、大小与修改时间记录在缓存目录的
+// hashes 子目录中，文件未变化时直接复用；文件被修改后修改时间改变，重新计算。
+func contentHash(dir, path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return "", err
+	}
+	if !info.Mode().IsRegular() {
+		return "", fmt.Errorf("%s 不是普通文件", path)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	key := sha256.Sum256(fmt.Appendf(nil, "%s|%d|%d", abs, info.Size(), info.ModTime().UnixNano()))
+	memo := filepath.Join(dir, "hashes", hex.EncodeToString(key[:])+".sha256")
+	if data, err := os.ReadFile(memo); err == nil && len(data) == sha256.Size*2 {
+		return string(data), nil
+	}
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return "", err
+	}
+	sum := hex.EncodeToString(hash.Sum(nil))
+	// 写入失败只影响下次是否需要重新计算。
+	if err := os.MkdirAll(filepath.Dir(memo), 0o755); err == nil {
+		_ = os.WriteFile(memo, []byte(sum), 0o644)
+	}
+	return sum, nil
+}
+
+func (c *frameCache) path(timestamp float64) string {
+	sum := sha256.Sum256(fmt.Appendf(nil, "%s|%.3f|%s", c.video, timestamp, c.variant))
+	key := hex.EncodeToString(sum[:])
+	return filepath.Join(c.dir, key[:2], key+".png")
+}
+
+// load 返回采样点 sample 的缓存截图及其实际时间点；按 --selector 选出的帧与采样点不同，实际时间点记录在同名 .ts 文件中。
+// 未命中或缓存文件损坏时返回 nil。
+func (c *frameCache) load(sample float64) (image.Image, float64) {
+	path := c.path(sample)
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, sample
+	}
+	defer file.Close()
+	img, err := png.Decode(file)
+	if err != nil {
+		return nil, sample
+	}
+	actual := sample
+	if data, err := os.ReadFile(strings.TrimSuffix(path, ".png") + ".ts"); err == nil {
+		if actual, err = strconv.ParseFloat(strings.TrimSpace(string(data)), 64); err != nil {
+			return nil, sample
+		}
+	}
+	frameCacheHits.Inc()
+	// 命中时更新修改时间，清理缓存时按修改时间淘汰最久未使用的截图。
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+	return img, actual
+}
+
+func (c *frameCache) store(sample, actual float64, frame image.Image) error {
+	path := c.path(sample)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return err
+	}
+	// 时间点文件先于截图写入，读取到截图时时间点总是可用的。
+	if actual != sample {
+		if err := os.WriteFile(strings.TrimSuffix(path, ".png")+".ts", []byte(strconv.FormatFloat(actual, 'f', -1, 64)), 0o644); err != nil {
+			return err
+		}
+	}
+	// 先写临时文件再重命名，并发任务读取到的缓存文件总是完整的。
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".frame-*.png")
+	if err != nil {
+		return err
+	}
+	encoder := png.Encoder{CompressionLevel: png.BestSpeed}
+	err = encoder.Encode(tmp, frame)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// pruneFrameCache 在缓存目录中的截图与内容哈希记录总大小超过 limit 字节时，按修改时间从旧到新删除 (截图连同 .ts 时间点文件)，
+// 直到总大小不超过 limit 的 90%，避免之后每次生成都要清理。删除失败 (例如并发任务已删除) 时忽略。
+func pruneFrameCache(dir string, limit int64) {
+	type cachedFrame struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+	var frames []cachedFrame
+	var total int64
+	_ = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		ext := filepath.Ext(path)
+		if err != nil || d.IsDir() || ext != ".png" && ext != ".sha256" || strings.HasPrefix(d.Name(), ".") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		frames = append(frames, cachedFrame{path, info.Size(), info.ModTime()})
+		total += info.Size()
+		return nil
+	})
+	if total <= limit {
+		return
+	}
+	slices.SortFunc(frames, func(a, b cachedFrame) int { return a.modTime.Compare(b.modTime) })
+	for _, f := range frames {
+		if total <= limit/10*9 {
+			break
+		}
+		os.Remove(f.path)
+		os.Remove(strings.TrimSuffix(f.path, ".png") + ".ts")
+		total -= f.size
+	}
+}
@@ -0,0 +1,59 @@
+package preview
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestContentHash(t *testing.T) {
+	cacheDir := t.TempDir()
+	path := filepath.Join(t.TempDir(), "movie.mp4")
+	// 大于头、中、尾抽样范围的文件，只修改中间的字节且大小不变。
+	data := make([]byte, 20<<20)
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	want := sha256.Sum256(data)
+	first, err := contentHash(cacheDir, path)
+	if err != nil {
+		t.Fatalf("contentHash: %v", err)
+	}
+	if first != hex.EncodeToString(want[:]) {
+		t.Errorf("哈希为 %s，期望完整内容的 SHA-256", first)
+	}
+
+	data[7<<20] = 1
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// 确保修改时间不同，文件系统的时间精度可能较粗。
+	later := time.Now().Add(time.Minute)
+	if err := os.Chtimes(path, later, later); err != nil {
+		t.Fatal(err)
+	}
+	second, err := contentHash(cacheDir, path)
+	if err != nil {
+		t.Fatalf("contentHash: %v", err)
+	}
+	if second == first {
+		t.Error("修改文件中间的内容后哈希应改变")
+	}
+
+	// 文件未变化时复用记录的哈希，不再读取内容。
+	memos, _ := filepath.Glob(filepath.Join(cacheDir, "hashes", "*.sha256"))
+	if len(memos) != 2 {
+		t.Fatalf("缓存目录中有 %d 条哈希记录，期望 2 条", len(memos))
+	}
+	for _, memo := range memos {
+		if err := os.WriteFile(memo, []byte(hex.EncodeToString(make([]byte, 32))), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if third, err := contentHash(cacheDir, path); err != nil || third != hex.EncodeToString(make([]byte, 32)) {
+		t.Errorf("文件未变化时应使用记录的哈希，实际为 %s (%v)", third, err)
+	}
+}
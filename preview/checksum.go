@@ -0,0 +1,55 @@
+package preview
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/zeebo/blake3"
+)
+
+type fileChecksum struct {
+	algorithm string
+	value     string
+}
+
+func validateChecksum(algorithm string) error {
+	switch algorithm {
+	case "", "sha256", "crc32", "blake3":
+		return nil
+	default:
+		return fmt.Errorf("不支持的校验算法: %s (可选: sha256、crc32、blake3)", algorithm)
+	}
+}
+
+// computeChecksum 读取整个源文件计算校验值，结果为小写十六进制字符串。
+func computeChecksum(path, algorithm string) (*fileChecksum, error) {
+	var h hash.Hash
+	switch algorithm {
+	case "sha256":
+		h = sha256.New()
+	case "crc32":
+		h = crc32.NewIEEE()
+	case "blake3":
+		h = blake3.New()
+	}
+
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	if _, err := io.Copy(h, file); err != nil {
+		return nil, fmt.Errorf("计算校验值失败: %w", err)
+	}
+	return &fileChecksum{algorithm: algorithm, value: hex.EncodeToString(h.Sum(nil))}, nil
+}
+
+func (c *fileChecksum) headerLine() string {
+	return strings.ToUpper(c.algorithm) + ": " + c.value
+}
@@ -0,0 +1,122 @@
+package preview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// clipboardCommand 为一种写入剪贴板的方式，stdin 不为空时作为命令的标准输入。
+type clipboardCommand struct {
+	name  string
+	args  []string
+	stdin func() (io.Reader, error)
+}
+
+// clipboardTextCommands 按平台返回写入文本的候选命令，依次使用第一个在 PATH 中找到的命令。
+func clipboardTextCommands(text string) []clipboardCommand {
+	stdin := func() (io.Reader, error) { return strings.NewReader(text), nil }
+	switch runtime.GOOS {
+	case "darwin":
+		return []clipboardCommand{{name: "pbcopy", stdin: stdin}}
+	case "windows":
+		// clip.exe 按控制台代码页解读输入，中文路径会乱码，改用 PowerShell 从标准输入读取 UTF-8 文本。
+		return []clipboardCommand{{name: "powershell", args: []string{"-NoProfile", "-Command",
+			"[Console]::InputEncoding = [Text.Encoding]::UTF8; Set-Clipboard -Value ([Console]::In.ReadToEnd())"}, stdin: stdin}}
+	default:
+		return []clipboardCommand{
+			{name: "wl-copy", stdin: stdin},
+			{name: "xclip", args: []string{"-selection", "clipboard"}, stdin: stdin},
+			{name: "xsel", args: []string{"--clipboard", "--input"}, stdin: stdin},
+		}
+	}
+}
+
+// clipboardImageCommands 返回把图片文件写入剪贴板的候选命令；macOS 与 Windows 只支持 PNG 与 JPEG。
+func clipboardImageCommands(path string) ([]clipboardCommand, error) {
+	format, err := outputFormat(path, "")
+	if err != nil {
+		return nil, err
+	}
+	mime := map[string]string{"png": "image/png", "jpeg": "image/jpeg", "webp": "image/webp", "bmp": "image/bmp", "tiff": "image/tiff"}[format]
+	stdin := func() (io.Reader, error) { return os.Open(path) }
+	switch runtime.GOOS {
+	case "darwin":
+		class := map[string]string{"png": "«class PNGf»", "jpeg": "JPEG picture"}[format]
+		if class == "" {
+			return nil, fmt.Errorf("macOS 剪贴板不支持 %s 图片", format)
+		}
+		script := fmt.Sprintf("set the clipboard to (read (POSIX file %q) as %s)", path, class)
+		return []clipboardCommand{{name: "osascript", args: []string{"-e", script}}}, nil
+	case "windows":
+		if format != "png" && format != "jpeg" && format != "bmp" {
+			return nil, fmt.Errorf("Windows 剪贴板不支持 %s 图片", format)
+		}
+		script := "Add-Type -AssemblyName System.Windows.Forms, System.Drawing; " +
+			"[Windows.Forms.Clipboard]::SetImage([Drawing.Image]::FromFile('" + strings.ReplaceAll(path, "'", "''") + "'))"
+		return []clipboardCommand{{name: "powershell", args: []string{"-NoProfile", "-STA", "-Command", script}}}, nil
+	default:
+		return []clipboardCommand{
+			{name: "wl-copy", args: []string{"--type", mime}, stdin: stdin},
+			{name: "xclip", args: []string{"-selection", "clipboard", "-t", mime}, stdin: stdin},
+		}, nil
+	}
+}
+
+func runClipboard(commands []clipboardCommand) error {
+	for _, c := range commands {
+		if _, err := exec.LookPath(c.name); err != nil {
+			continue
+		}
+		cmd := newCommand(context.Background(), nil, c.name, c.args...)
+		if c.stdin != nil {
+			r, err := c.stdin()
+			if err != nil {
+				return err
+			}
+			if closer, ok := r.(io.Closer); ok {
+				defer closer.Close()
+			}
+			cmd.Stdin = r
+		}
+		var stderr stderrTail
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return fmt.Errorf("%s 写入剪贴板失败: %w", c.name, commandError(err, stderr.Bytes()))
+		}
+		return nil
+	}
+	names := make([]string, len(commands))
+	for i, c := range commands {
+		names[i] = c.name
+	}
+	return fmt.Errorf("未找到剪贴板工具 (需要 %s 之一)", strings.Join(names, "、"))
+}
+
+// copyResult 按 --copy-path/--copy-image 把结果的绝对路径 (对象存储输出为其 URI) 或拼图图片写入剪贴板。
+func copyResult(cfg *gridConfig) error {
+	if cfg.copyImage {
+		if cfg.splitSheets() || cfg.framesOnly || isMontageOutput(cfg.output) {
+			return errors.New("copy-image 只支持单张拼图")
+		}
+		commands, err := clipboardImageCommands(cfg.output)
+		if err != nil {
+			return err
+		}
+		return runClipboard(commands)
+	}
+	if isRemoteURI(cfg.outputName()) {
+		return runClipboard(clipboardTextCommands(cfg.outputName()))
+	}
+	path, err := filepath.Abs(resultLocation(cfg))
+	if err != nil {
+		return err
+	}
+	return runClipboard(clipboardTextCommands(path))
+}
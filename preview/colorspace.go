@@ -0,0 +1,184 @@
+package preview
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+)
+
+// colorFilters 根据探测到的色彩信息构造截图时的 ffmpeg 滤镜链，保证输出的 RGB 像素处于 sRGB 空间。
+// ffmpeg 默认按 BT.601 将 YUV 转为 RGB，高清 (BT.709) 与 BT.2020 片源会因此出现轻微偏色。
+func colorFilters(meta *videoMetadata) []string {
+	matrix := swscaleMatrix(meta)
+	inRange := "tv"
+	if meta.colorRange == "pc" || strings.HasPrefix(meta.pixelFormat, "yuvj") {
+		inRange = "pc"
+	}
+
+	var filters []string
+	switch {
+	case meta.colorTransfer == "smpte2084" || meta.colorTransfer == "arib-std-b67":
+		// HDR 片源需要先转为线性光再色调映射到 BT.709，依赖 ffmpeg 编译时启用 libzimg。
+		filters = append(filters,
+			fmt.Sprintf("zscale=tin=%s:min=bt2020nc:pin=bt2020:rin=%s:t=linear:npl=100", meta.colorTransfer, zscaleRange(inRange)),
+			"format=gbrpf32le",
+			"zscale=p=bt709",
+			"tonemap=hable:desat=0",
+			"zscale=t=bt709:m=bt709:r=tv",
+			"format=yuv420p",
+		)
+		matrix, inRange = "bt709", "tv"
+	case meta.colorPrimaries == "bt2020":
+		filters = append(filters, fmt.Sprintf("colorspace=all=bt709:iall=bt2020:irange=%s:range=tv:format=yuv444p", inRange))
+		matrix, inRange = "bt709", "tv"
+	}
+
+	return append(filters, fmt.Sprintf("scale=in_color_matrix=%s:in_range=%s", matrix, inRange), "format=rgb24")
+}
+
+func swscaleMatrix(meta *videoMetadata) string {
+	switch meta.colorSpace {
+	case "bt709":
+		return "bt709"
+	case "smpte170m", "bt470bg":
+		return "bt601"
+	case "bt2020nc", "bt2020c":
+		return "bt2020"
+	case "smpte240m":
+		return "smpte240m"
+	case "fcc":
+		return "fcc"
+	}
+	// 未标注色彩矩阵时沿用播放器的惯例: 高清按 BT.709，标清按 BT.601。
+	if meta.height >= 720 {
+		return "bt709"
+	}
+	return "bt601"
+}
+
+func zscaleRange(swsRange string) string {
+	if swsRange == "pc" {
+		return "full"
+	}
+	return "limited"
+}
+
+func srgbPNGChunks() []pngChunk {
+	gamma := make([]byte, 4)
+	binary.BigEndian.PutUint32(gamma, 45455)
+	// sRGB 块的渲染意图 0 表示感知 (perceptual)。
+	return []pngChunk{
+		{typ: "sRGB", data: []byte{0}},
+		{typ: "gAMA", data: gamma},
+	}
+}
+
+func srgbJPEGSegment() []byte {
+	payload := append([]byte("ICC_PROFILE\x00"), 1, 1)
+	return jpegSegment(0xE2, append(payload, srgbICCProfile()...))
+}
+
+var srgbProfileOnce = sync.OnceValue(buildSRGBProfile)
+
+func srgbICCProfile() []byte {
+	return srgbProfileOnce()
+}
+
+// buildSRGBProfile 生成一个精简的 ICC v2 sRGB 显示器配置文件 (D50 适配后的原色与 1024 点 sRGB 传递曲线)。
+func buildSRGBProfile() []byte {
+	s15 := func(v float64) uint32 {
+		return uint32(int32(math.Round(v * 65536)))
+	}
+	xyz := func(x, y, z float64) []byte {
+		var b bytes.Buffer
+		b.WriteString("XYZ \x00\x00\x00\x00")
+		binary.Write(&b, binary.BigEndian, []uint32{s15(x), s15(y), s15(z)})
+		return b.Bytes()
+	}
+
+	var desc bytes.Buffer
+	name := "sRGB IEC61966-2.1"
+	desc.WriteString("desc\x00\x00\x00\x00")
+	binary.Write(&desc, binary.BigEndian, uint32(len(name)+1))
+	desc.WriteString(name)
+	desc.WriteByte(0)
+	desc.Write(make([]byte, 4+4+2+1+67))
+
+	cprt := []byte("text\x00\x00\x00\x00No copyright, use freely\x00")
+
+	var curve bytes.Buffer
+	curve.WriteString("curv\x00\x00\x00\x00")
+	const points = 1024
+	binary.Write(&curve, binary.BigEndian, uint32(points))
+	for i := 0; i < points; i++ {
+		v := float64(i) / (points - 1)
+		if v <= 0.04045 {
+			v /= 12.92
+		} else {
+			v = math.Pow((v+0.055)/1.055, 2.4)
+		}
+		binary.Write(&curve, binary.BigEndian, uint16(math.Round(v*65535)))
+	}
+
+	type tag struct {
+		sig  string
+		data []byte
+	}
+	tags := []tag{
+		{"desc", desc.Bytes()},
+		{"cprt", cprt},
+		{"wtpt", xyz(0.9642, 1.0, 0.8249)},
+		{"rXYZ", xyz(0.4361, 0.2225, 0.0139)},
+		{"gXYZ", xyz(0.3851, 0.7169, 0.0971)},
+		{"bXYZ", xyz(0.1431, 0.0606, 0.7141)},
+		{"rTRC", curve.Bytes()},
+		{"gTRC", nil},
+		{"bTRC", nil},
+	}
+
+	tableSize := 4 + 12*len(tags)
+	offset := 128 + tableSize
+	var table, data bytes.Buffer
+	binary.Write(&table, binary.BigEndian, uint32(len(tags)))
+	var curveOffset, curveSize int
+	for _, t := range tags {
+		// 三条传递曲线完全相同，共用同一份数据。
+		if t.data == nil {
+			table.WriteString(t.sig)
+			binary.Write(&table, binary.BigEndian, []uint32{uint32(curveOffset), uint32(curveSize)})
+			continue
+		}
+		for (offset+data.Len())%4 != 0 {
+			data.WriteByte(0)
+		}
+		start := offset + data.Len()
+		if t.sig == "rTRC" {
+			curveOffset, curveSize = start, len(t.data)
+		}
+		table.WriteString(t.sig)
+		binary.Write(&table, binary.BigEndian, []uint32{uint32(start), uint32(len(t.data))})
+		data.Write(t.data)
+	}
+	for data.Len()%4 != 0 {
+		data.WriteByte(0)
+	}
+
+	total := 128 + table.Len() + data.Len()
+	var header bytes.Buffer
+	binary.Write(&header, binary.BigEndian, uint32(total))
+	header.Write(make([]byte, 4))
+	binary.Write(&header, binary.BigEndian, uint32(0x02100000))
+	header.WriteString("mntrRGB XYZ ")
+	binary.Write(&header, binary.BigEndian, []uint16{2000, 1, 1, 0, 0, 0})
+	header.WriteString("acsp")
+	header.Write(make([]byte, 4+4+4+4+8))
+	binary.Write(&header, binary.BigEndian, uint32(0))
+	binary.Write(&header, binary.BigEndian, []uint32{s15(0.9642), s15(1.0), s15(0.8249)})
+	header.Write(make([]byte, 128-header.Len()))
+
+	profile := append(header.Bytes(), table.Bytes()...)
+	return append(profile, data.Bytes()...)
+}
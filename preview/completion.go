@@ -0,0 +1,296 @@
+package preview
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+type completionCommand struct {
+	name  string
+	usage string
+	flags *flag.FlagSet
+}
+
+func completionCommands() []completionCommand {
+	mainFlags, _ := newMainFlagSet()
+	watchFlags, _ := newWatchFlagSet()
+	probeFlags, _ := newProbeFlagSet()
+	serveFlags, _ := newServeFlagSet()
+	grpcFlags, _ := newGRPCFlagSet()
+	workerFlags, _ := newWorkerFlagSet()
+	composeFlags, _ := newComposeFlagSet()
+	installFlags, _ := newInstallFlagSet()
+	return []completionCommand{
+		{"", "生成视频预览拼图", mainFlags},
+		{"watch", "监听目录并自动生成预览图", watchFlags},
+		{"probe", "以 JSON 输出视频信息", probeFlags},
+		{"serve", "启动 HTTP 预览服务", serveFlags},
+		{"grpc", "启动 gRPC 预览服务", grpcFlags},
+		{"worker", "从 NATS JetStream 队列消费预览任务", workerFlags},
+		{"compose", "把已有图片拼接成拼图", composeFlags},
+		{"install-ffmpeg", "下载预编译的 ffmpeg/ffprobe 到工具目录", installFlags},
+		{"completion", "输出 bash/zsh/fish 补全脚本", flag.NewFlagSet("completion", flag.ContinueOnError)},
+		{"version", "输出版本与构建信息", flag.NewFlagSet("version", flag.ContinueOnError)},
+	}
+}
+
+var completionShells = []string{"bash", "zsh", "fish"}
+
+// 需要补全文件或目录路径的参数。
+var (
+	completionFileFlags = []string{"input", "output", "manifest", "state-file", "report", "layout-file", "anim-output", "mediainfo", "lut", "cpuprofile", "memprofile", "ffmpeg", "ffprobe"}
+	completionDirFlags  = []string{"dir", "output-dir", "save-frames", "cache-dir"}
+)
+
+// completionChoices 返回只接受固定取值的参数及其候选值。
+func completionChoices() map[string][]string {
+	formats := []string{"png", "jpeg", "webp", "tiff", "bmp"}
+	presets := slices.Sorted(maps.Keys(builtinPresets))
+	if all, err := loadPresets(); err == nil {
+		presets = slices.Sorted(maps.Keys(all))
+	}
+	return map[string][]string{
+		"format":           formats,
+		"frame-format":     formats,
+		"layout":           {"grid", "mosaic"},
+		"style":            {"plain", "polaroid"},
+		"selector":         {"uniform", "thumbnail", "scene"},
+		"sample":           {"uniform", "random"},
+		"backend":          {"go", "libav", "persistent", "ffmpeg-tile"},
+		"timestamp-format": {"clock", "seconds", "frames", "smpte"},
+		"deinterlace":      {"off", "yadif", "bwdif"},
+		"rotate":           {"90", "180", "270"},
+		"flip":             {"h", "v"},
+		"checksum":         {"sha256", "crc32", "blake3"},
+		"publish":          {"imgbb", "catbox", "custom"},
+		"publish-format":   {"bbcode", "markdown"},
+		"tiff-compression": {"none", "lzw", "deflate"},
+		"jpeg-subsampling": {"420", "444"},
+		"png-compression":  {"none", "fast", "default", "best"},
+		"preset":           presets,
+	}
+}
+
+func runCompletion(args []string) error {
+	if len(args) != 1 {
+		return errors.New("用法: completion bash|zsh|fish")
+	}
+	switch args[0] {
+	case "bash":
+		return writeBashCompletion(os.Stdout)
+	case "zsh":
+		return writeZshCompletion(os.Stdout)
+	case "fish":
+		return writeFishCompletion(os.Stdout)
+	default:
+		return fmt.Errorf("不支持的 shell: %s (可选: %s)", args[0], strings.Join(completionShells, ", "))
+	}
+}
+
+func isBoolFlag(f *flag.Flag) bool {
+	b, ok := f.Value.(interface{ IsBoolFlag() bool })
+	return ok && b.IsBoolFlag()
+}
+
+func flagNames(fs *flag.FlagSet) []string {
+	var names []string
+	fs.VisitAll(func(f *flag.Flag) {
+		names = append(names, "--"+f.Name)
+	})
+	return names
+}
+
+func subcommandNames(commands []completionCommand) []string {
+	var names []string
+	for _, cmd := range commands {
+		if cmd.name != "" {
+			names = append(names, cmd.name)
+		}
+	}
+	return names
+}
+
+func shellFuncName() string {
+	return "_" + strings.ReplaceAll(toolName, "-", "_")
+}
+
+func writeBashCompletion(w io.Writer) error {
+	commands := completionCommands()
+	choices := completionChoices()
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# bash completion for %s\n", toolName)
+	fmt.Fprintf(&b, "%s() {\n", shellFuncName())
+	b.WriteString("    local cur=\"${COMP_WORDS[COMP_CWORD]}\" prev=\"${COMP_WORDS[COMP_CWORD-1]}\" cmd=\"\"\n")
+	fmt.Fprintf(&b, "    case \"${COMP_WORDS[1]}\" in\n        %s) [[ ${COMP_CWORD} -gt 1 ]] && cmd=\"${COMP_WORDS[1]}\" ;;\n    esac\n\n", strings.Join(subcommandNames(commands), "|"))
+
+	valueFlags := make(map[string]bool)
+	for _, cmd := range commands {
+		cmd.flags.VisitAll(func(f *flag.Flag) {
+			if !isBoolFlag(f) {
+				valueFlags[f.Name] = true
+			}
+		})
+	}
+	b.WriteString("    local name=\"${prev#-}\"\n    name=\"${name#-}\"\n")
+	b.WriteString("    [[ \"$prev\" == -* ]] && case \"$name\" in\n")
+	for _, name := range slices.Sorted(maps.Keys(choices)) {
+		fmt.Fprintf(&b, "        %s) COMPREPLY=($(compgen -W \"%s\" -- \"$cur\")); return ;;\n", name, strings.Join(choices[name], " "))
+	}
+	fmt.Fprintf(&b, "        %s) COMPREPLY=($(compgen -f -- \"$cur\")); return ;;\n", bashPattern(completionFileFlags))
+	fmt.Fprintf(&b, "        %s) COMPREPLY=($(compgen -d -- \"$cur\")); return ;;\n", bashPattern(completionDirFlags))
+	var others []string
+	for _, name := range slices.Sorted(maps.Keys(valueFlags)) {
+		if choices[name] == nil && !slices.Contains(completionFileFlags, name) && !slices.Contains(completionDirFlags, name) {
+			others = append(others, name)
+		}
+	}
+	fmt.Fprintf(&b, "        %s) COMPREPLY=(); return ;;\n", bashPattern(others))
+	b.WriteString("    esac\n\n")
+
+	b.WriteString("    local words=\"\"\n    case \"$cmd\" in\n")
+	for _, cmd := range commands {
+		label := cmd.name
+		if label == "" {
+			label = "\"\""
+		}
+		words := flagNames(cmd.flags)
+		switch cmd.name {
+		case "":
+			words = append(subcommandNames(commands), words...)
+		case "completion":
+			words = completionShells
+		}
+		fmt.Fprintf(&b, "        %s) words=\"%s\" ;;\n", label, strings.Join(words, " "))
+	}
+	b.WriteString("    esac\n")
+	b.WriteString("    if [[ \"$cmd\" == \"probe\" && \"$cur\" != -* ]]; then\n")
+	b.WriteString("        COMPREPLY=($(compgen -f -- \"$cur\"))\n")
+	b.WriteString("        return\n    fi\n")
+	b.WriteString("    COMPREPLY=($(compgen -W \"$words\" -- \"$cur\"))\n")
+	b.WriteString("}\n")
+	fmt.Fprintf(&b, "complete -o filenames -F %s %s\n", shellFuncName(), toolName)
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func bashPattern(names []string) string {
+	return strings.Join(names, "|")
+}
+
+func zshEscape(text string) string {
+	replacer := strings.NewReplacer("'", "'\\''", "[", "\\[", "]", "\\]", ":", "\\:")
+	return replacer.Replace(text)
+}
+
+func zshFlagSpecs(fs *flag.FlagSet, choices map[string][]string) []string {
+	var specs []string
+	fs.VisitAll(func(f *flag.Flag) {
+		spec := fmt.Sprintf("'--%s[%s]", f.Name, zshEscape(f.Usage))
+		switch {
+		case isBoolFlag(f):
+		case choices[f.Name] != nil:
+			spec += fmt.Sprintf(":%s:(%s)", f.Name, strings.Join(choices[f.Name], " "))
+		case slices.Contains(completionFileFlags, f.Name):
+			spec += ":file:_files"
+		case slices.Contains(completionDirFlags, f.Name):
+			spec += ":directory:_files -/"
+		default:
+			spec += ":value: "
+		}
+		specs = append(specs, spec+"'")
+	})
+	return specs
+}
+
+func writeZshCompletion(w io.Writer) error {
+	commands := completionCommands()
+	choices := completionChoices()
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "#compdef %s\n\n", toolName)
+	fmt.Fprintf(&b, "%s() {\n", shellFuncName())
+	b.WriteString("    case $words[2] in\n")
+	for _, cmd := range commands {
+		if cmd.name == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "        %s)\n            shift words\n            (( CURRENT-- ))\n", cmd.name)
+		if cmd.name == "completion" {
+			fmt.Fprintf(&b, "            _arguments '1:shell:(%s)'\n            ;;\n", strings.Join(completionShells, " "))
+			continue
+		}
+		fmt.Fprintf(&b, "            _arguments -s \\\n                %s\n            ;;\n", strings.Join(zshFlagSpecs(cmd.flags, choices), " \\\n                "))
+	}
+	b.WriteString("        *)\n")
+	b.WriteString("            if (( CURRENT == 2 )) && [[ $words[CURRENT] != -* ]]; then\n")
+	b.WriteString("                local -a commands=(\n")
+	for _, cmd := range commands {
+		if cmd.name != "" {
+			fmt.Fprintf(&b, "                    '%s:%s'\n", cmd.name, zshEscape(cmd.usage))
+		}
+	}
+	b.WriteString("                )\n                _describe command commands\n                return\n            fi\n")
+	fmt.Fprintf(&b, "            _arguments -s \\\n                %s\n            ;;\n", strings.Join(zshFlagSpecs(commands[0].flags, choices), " \\\n                "))
+	b.WriteString("    esac\n}\n\n")
+	fmt.Fprintf(&b, "%s \"$@\"\n", shellFuncName())
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func fishQuote(text string) string {
+	return "'" + strings.NewReplacer("\\", "\\\\", "'", "\\'").Replace(text) + "'"
+}
+
+func writeFishCompletion(w io.Writer) error {
+	commands := completionCommands()
+	choices := completionChoices()
+	subcommands := strings.Join(subcommandNames(commands), " ")
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "# fish completion for %s\n", toolName)
+	fmt.Fprintf(&b, "complete -c %s -f\n", toolName)
+	for _, cmd := range commands {
+		if cmd.name == "" {
+			continue
+		}
+		fmt.Fprintf(&b, "complete -c %s -n '__fish_use_subcommand' -a %s -d %s\n", toolName, cmd.name, fishQuote(cmd.usage))
+	}
+	fmt.Fprintf(&b, "complete -c %s -n '__fish_seen_subcommand_from completion' -a '%s'\n", toolName, strings.Join(completionShells, " "))
+
+	for _, cmd := range commands {
+		if cmd.name == "completion" {
+			continue
+		}
+		condition := fmt.Sprintf("'__fish_seen_subcommand_from %s'", cmd.name)
+		if cmd.name == "" {
+			condition = fmt.Sprintf("'not __fish_seen_subcommand_from %s'", subcommands)
+		}
+		cmd.flags.VisitAll(func(f *flag.Flag) {
+			fmt.Fprintf(&b, "complete -c %s -n %s -l %s", toolName, condition, f.Name)
+			switch {
+			case isBoolFlag(f):
+			case choices[f.Name] != nil:
+				fmt.Fprintf(&b, " -x -a '%s'", strings.Join(choices[f.Name], " "))
+			case slices.Contains(completionFileFlags, f.Name):
+				b.WriteString(" -r -F")
+			case slices.Contains(completionDirFlags, f.Name):
+				b.WriteString(" -x -a '(__fish_complete_directories)'")
+			default:
+				b.WriteString(" -x")
+			}
+			fmt.Fprintf(&b, " -d %s\n", fishQuote(f.Usage))
+		})
+	}
+
+	_, err := io.WriteString(w, b.String())
+	return err
+}
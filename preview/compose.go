@@ -0,0 +1,233 @@
+package preview
+
+import (
+	"cmp"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	_ "golang.org/x/image/tiff"
+	_ "golang.org/x/image/webp"
+)
+
+// composeUnsupported 为需要读取视频或采样时间的参数，compose 只拼接已有图片，不支持这些参数。
+var composeUnsupported = []string{
+	"timestamps", "header", "header-template", "waveform", "bitrate-graph", "loudness", "sidecar", "mediainfo",
+	"anim-output", "save-frames", "frames-only", "frame-numbers", "percentages", "interval", "segment", "sheet-per-chapter",
+	"at", "artwork", "trickplay", "embed-cover",
+}
+
+type composeFlags struct {
+	output    string
+	images    string
+	imagesDir string
+	grid      *gridFlags
+}
+
+func newComposeFlagSet() (*flag.FlagSet, *composeFlags) {
+	fs := flag.NewFlagSet("compose", flag.ExitOnError)
+	cf := &composeFlags{}
+	fs.StringVar(&cf.output, "output", "preview.png", "输出图片路径，格式根据扩展名自动决定；为 - 时写到标准输出")
+	bindImageFlags(fs, &cf.images, &cf.imagesDir)
+	bindFontFlags(fs)
+	cf.grid = bindGridFlags(fs)
+	return fs, cf
+}
+
+func bindImageFlags(fs *flag.FlagSet, images, imagesDir *string) {
+	fs.StringVar(images, "images", "", "用逗号分隔的图片列表拼接拼图，不读取视频也不调用 ffmpeg")
+	fs.StringVar(imagesDir, "images-dir", "", "按文件名顺序 (frame_2 在 frame_10 之前) 拼接该目录中的全部图片，不读取视频也不调用 ffmpeg")
+}
+
+// runCompose 把命令行给出的图片按布局与样式拼接成一张拼图，不读取视频也不调用 ffmpeg。
+func runCompose(args []string) error {
+	fs, cf := newComposeFlagSet()
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	paths, err := imagePaths(cf.images, cf.imagesDir)
+	if err != nil {
+		return err
+	}
+	paths = append(paths, fs.Args()...)
+	if len(paths) == 0 {
+		return errors.New("至少需要指定一张图片，例如 compose --output sheet.png a.png b.jpg")
+	}
+	if err := checkComposeFlags(fs); err != nil {
+		return err
+	}
+	cfg, err := cf.grid.config()
+	if err != nil {
+		return err
+	}
+	cfg.output = cf.output
+	if err := composeImages(cfg, paths); err != nil {
+		return err
+	}
+	if cfg.output != "-" {
+		fmt.Println("已生成拼图: " + cfg.output)
+	}
+	return nil
+}
+
+func checkComposeFlags(fs *flag.FlagSet) error {
+	for _, name := range composeUnsupported {
+		if flagWasSet(fs, name) {
+			return fmt.Errorf("拼接已有图片时不支持 --%s", name)
+		}
+	}
+	return nil
+}
+
+// imagePaths 返回 --images 列出的图片与 --images-dir 目录中的图片；目录中只取扩展名为支持的图片格式的文件。
+func imagePaths(list, dir string) ([]string, error) {
+	var paths []string
+	for _, path := range strings.Split(list, ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			paths = append(paths, path)
+		}
+	}
+	if dir == "" {
+		return paths, nil
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	var found []string
+	for _, entry := range entries {
+		if _, err := normalizeFormat(filepath.Ext(entry.Name())); err == nil && !entry.IsDir() {
+			found = append(found, entry.Name())
+		}
+	}
+	if len(found) == 0 {
+		return nil, fmt.Errorf("目录中没有图片: %s", dir)
+	}
+	slices.SortFunc(found, naturalCompare)
+	for _, name := range found {
+		paths = append(paths, filepath.Join(dir, name))
+	}
+	return paths, nil
+}
+
+// naturalCompare 按自然顺序比较文件名，连续数字按数值比较，其他工具导出的 frame_2.png 排在 frame_10.png 之前。
+func naturalCompare(a, b string) int {
+	for a != "" && b != "" {
+		da, db := leadingDigits(a), leadingDigits(b)
+		if da != "" && db != "" {
+			na, nb := strings.TrimLeft(da, "0"), strings.TrimLeft(db, "0")
+			if c := cmp.Compare(len(na), len(nb)); c != 0 {
+				return c
+			}
+			if c := strings.Compare(na, nb); c != 0 {
+				return c
+			}
+			a, b = a[len(da):], b[len(db):]
+			continue
+		}
+		if a[0] != b[0] {
+			return cmp.Compare(a[0], b[0])
+		}
+		a, b = a[1:], b[1:]
+	}
+	return cmp.Compare(len(a), len(b))
+}
+
+func leadingDigits(s string) string {
+	i := 0
+	for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+		i++
+	}
+	return s[:i]
+}
+
+// composeImages 把已有图片按布局与样式拼接成一张拼图，供 compose 子命令与 --images/--images-dir 使用。
+func composeImages(cfg *gridConfig, paths []string) error {
+	cfg.input = paths[0]
+	cfg.embedMetadata = false
+	if isMontageOutput(cfg.output) || isRemoteURI(cfg.output) {
+		return errors.New("拼接已有图片时只能输出到本地图片文件或标准输出")
+	}
+
+	images := make([]image.Image, len(paths))
+	var err error
+	for i, path := range paths {
+		if images[i], err = decodeImageFile(path); err != nil {
+			return err
+		}
+	}
+	collage, err := composeFrames(cfg, images, nil)
+	if err != nil {
+		return err
+	}
+
+	opts := cfg.encodeOptions()
+	if cfg.output != "-" {
+		if err := saveVariants(collage, cfg, opts); err != nil {
+			return err
+		}
+	}
+	opts.maxBytes = cfg.maxBytes
+	if err := saveImage(collage, cfg.output, opts); err != nil {
+		return err
+	}
+	return saveExtraOutputs(collage, cfg, opts)
+}
+
+// composeFrames 按布局缩放 images 并拼接、绘制页脚；timestamps 可以为 nil 或与 images 等长。
+func composeFrames(cfg *gridConfig, images []image.Image, timestamps []float64) (image.Image, error) {
+	if cfg.backend != "go" {
+		return nil, errors.New("拼接已有图片时只使用默认的 go 后端")
+	}
+	if cfg.autoRows {
+		cfg.rows = (len(images) + cfg.cols - 1) / cfg.cols
+	}
+	if cfg.cellHeight == 0 {
+		bounds := images[0].Bounds()
+		cfg.cellHeight = inferCellHeight(cfg.cellWidth, bounds.Dx(), bounds.Dy())
+	}
+	layout, err := cfg.sheetLayout()
+	if err != nil {
+		return nil, err
+	}
+	if len(images) > layout.frameCount() {
+		return nil, fmt.Errorf("指定了 %d 张图片，但布局只有 %d 个截图位置", len(images), layout.frameCount())
+	}
+
+	frameSizes := layout.frameSizes(cfg.geometry())
+	frames := make([]image.Image, layout.frameCount())
+	for i, img := range images {
+		frames[i] = scaleToFit(img, frameSizes[i].X, frameSizes[i].Y)
+		if cfg.autoLevels {
+			frames[i] = autoLevels(frames[i])
+		}
+	}
+	collage, err := composeSheet(frames, timestamps, layout, cfg)
+	if err != nil {
+		return nil, err
+	}
+	if len(cfg.footer) > 0 {
+		if collage, err = addFooter(collage, cfg.footer, cfg); err != nil {
+			return nil, fmt.Errorf("绘制页脚失败: %w", err)
+		}
+	}
+	return collage, nil
+}
+
+func decodeImageFile(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	img, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("解码图片 %s 失败: %w", path, err)
+	}
+	return img, nil
+}
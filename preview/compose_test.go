@@ -0,0 +1,88 @@
+package preview
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+	"testing"
+	"time"
+)
+
+func solidImage(width, height int, c color.Color) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: c}, image.Point{}, draw.Src)
+	return img
+}
+
+func TestComposeGrid(t *testing.T) {
+	colors := []color.RGBA{{255, 0, 0, 255}, {0, 255, 0, 255}, {0, 0, 255, 255}, {255, 255, 0, 255}}
+	var cells []Cell
+	for i, c := range colors {
+		// 截图大于单格，应按比例缩小到 40x30。
+		cells = append(cells, Cell{Image: solidImage(80, 60, c), Timestamp: time.Duration(i) * time.Second})
+	}
+	sheet, err := ComposeGrid(cells, LayoutOptions{
+		"rows": "2", "cols": "2", "cell-width": "40", "cell-height": "30",
+		"gap-x": "10", "gap-y": "10", "padding": "5", "background": "#000000",
+	})
+	if err != nil {
+		t.Fatalf("ComposeGrid: %v", err)
+	}
+	if size := sheet.Bounds().Size(); size != image.Pt(5+40+10+40+5, 5+30+10+30+5) {
+		t.Fatalf("拼图尺寸为 %v", size)
+	}
+	for i, want := range colors {
+		col, row := i%2, i/2
+		x, y := 5+col*50, 5+row*40
+		for _, p := range []image.Point{{x, y}, {x + 39, y + 29}, {x + 20, y + 15}} {
+			if got := color.RGBAModel.Convert(sheet.At(p.X, p.Y)); got != want {
+				t.Errorf("第 %d 格 %v 处颜色为 %v，期望 %v", i+1, p, got, want)
+			}
+		}
+		if got := color.RGBAModel.Convert(sheet.At(x+40, y+15)); col == 0 && got != (color.RGBA{0, 0, 0, 255}) {
+			t.Errorf("第 %d 格右侧间距颜色为 %v，期望背景色", i+1, got)
+		}
+	}
+	if got := color.RGBAModel.Convert(sheet.At(2, 2)); got != (color.RGBA{0, 0, 0, 255}) {
+		t.Errorf("外边距颜色为 %v", got)
+	}
+}
+
+func TestComposeGridAutoRows(t *testing.T) {
+	var cells []Cell
+	for range 5 {
+		cells = append(cells, Cell{Image: solidImage(32, 18, color.White)})
+	}
+	sheet, err := ComposeGrid(cells, LayoutOptions{"cols": "2", "cell-width": "32", "gap-x": "0", "gap-y": "0", "padding": "0"})
+	if err != nil {
+		t.Fatalf("ComposeGrid: %v", err)
+	}
+	// 5 张截图排成 3 行，单格高度按第一张截图的比例推算为 18。
+	if size := sheet.Bounds().Size(); size != image.Pt(64, 54) {
+		t.Errorf("拼图尺寸为 %v，期望 64x54", size)
+	}
+}
+
+func TestComposeGridErrors(t *testing.T) {
+	frame := Cell{Image: solidImage(16, 9, color.White)}
+	for _, tc := range []struct {
+		name  string
+		cells []Cell
+		opts  LayoutOptions
+		want  string
+	}{
+		{"empty", nil, nil, "至少需要一张截图"},
+		{"nil image", []Cell{frame, {}}, nil, "第 2 张截图为空"},
+		{"video option", []Cell{frame}, LayoutOptions{"waveform": "true"}, "不支持参数 waveform"},
+		{"unknown option", []Cell{frame}, LayoutOptions{"no-such": "1"}, "未知参数"},
+		{"too many", []Cell{frame, frame, frame}, LayoutOptions{"rows": "1", "cols": "2"}, "布局只有 2 个截图位置"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := ComposeGrid(tc.cells, tc.opts)
+			if err == nil || !strings.Contains(err.Error(), tc.want) {
+				t.Errorf("错误为 %v，期望包含 %q", err, tc.want)
+			}
+		})
+	}
+}
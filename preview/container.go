@@ -0,0 +1,556 @@
+package preview
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+)
+
+// 单个需要完整读入内存解析的 box/元素的大小上限，防止损坏的文件导致分配过大的内存。
+const maxContainerElement = 64 << 20
+
+var errUnsupportedContainer = errors.New("内置解析器只支持 MP4/MOV 与 Matroska/WebM")
+
+// probeContainer 在没有 ffprobe 时直接解析 MP4/MOV 与 Matroska/WebM 的头信息，
+// 只能得到时长、分辨率、旋转、帧率与编码等基础信息，色彩信息、码率细节与章节均为空。
+func probeContainer(path string) (*videoMetadata, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	var magic [8]byte
+	if _, err := io.ReadFull(file, magic[:]); err != nil {
+		return nil, errUnsupportedContainer
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+
+	var meta *videoMetadata
+	switch {
+	case bytes.Equal(magic[:4], []byte{0x1A, 0x45, 0xDF, 0xA3}):
+		meta, err = probeMatroska(file)
+	case isMP4BoxType(string(magic[4:8])):
+		meta, err = probeMP4(file, info.Size())
+	default:
+		return nil, errUnsupportedContainer
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	meta.size = info.Size()
+	if meta.duration > 0 {
+		meta.bitRate = int64(float64(meta.size*8) / meta.duration)
+	}
+	for i, stream := range meta.streams {
+		stream.index = i
+		meta.streams[i] = stream
+		if stream.codecType == "video" && meta.videoCodec == "" {
+			meta.useVideoStream(stream)
+		}
+		if stream.codecType == "audio" && meta.audioCodec == "" {
+			meta.audioCodec = stream.codecName
+			meta.audioChannels = stream.channels
+			meta.sampleRate = stream.sampleRate
+		}
+	}
+	return meta, nil
+}
+
+func isMP4BoxType(name string) bool {
+	switch name {
+	case "ftyp", "moov", "mdat", "free", "skip", "wide":
+		return true
+	}
+	return false
+}
+
+// mp4Codecs 将样本描述中的 fourcc 映射为 ffprobe 使用的编码名称。
+var mp4Codecs = map[string]string{
+	"avc1": "h264", "avc3": "h264", "hvc1": "hevc", "hev1": "hevc", "av01": "av1",
+	"vp08": "vp8", "vp09": "vp9", "mp4v": "mpeg4", "mp4a": "aac", "Opus": "opus",
+	"ac-3": "ac3", "ec-3": "eac3", "fLaC": "flac", "alac": "alac", "apch": "prores",
+	"apcn": "prores", "apcs": "prores", "apco": "prores", "ap4h": "prores",
+}
+
+type mp4Track struct {
+	stream    streamInfo
+	handler   string
+	timescale uint32
+	duration  uint64
+	samples   uint64
+}
+
+func probeMP4(r io.ReadSeeker, size int64) (*videoMetadata, error) {
+	meta := &videoMetadata{formatName: "mov,mp4,m4a,3gp,3g2,mj2", formatLongName: "QuickTime / MOV"}
+	found := false
+	err := walkMP4Boxes(r, size, func(name string, body io.Reader, length int64) error {
+		if name != "moov" {
+			return nil
+		}
+		data, err := readContainerElement(body, length)
+		if err != nil {
+			return err
+		}
+		found = true
+		return parseMP4Movie(data, meta)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("解析 MP4 失败: %w", err)
+	}
+	if !found {
+		return nil, errors.New("解析 MP4 失败: 未找到 moov")
+	}
+	return meta, nil
+}
+
+// walkMP4Boxes 依次遍历 r 中长度为 size 的顶层 box，跳过 mdat 等大块数据。
+func walkMP4Boxes(r io.ReadSeeker, size int64, visit func(name string, body io.Reader, length int64) error) error {
+	var offset int64
+	for offset+8 <= size {
+		if _, err := r.Seek(offset, io.SeekStart); err != nil {
+			return err
+		}
+		var header [16]byte
+		if _, err := io.ReadFull(r, header[:8]); err != nil {
+			return err
+		}
+		boxSize := int64(binary.BigEndian.Uint32(header[:4]))
+		name := string(header[4:8])
+		headerSize := int64(8)
+		switch boxSize {
+		case 0:
+			boxSize = size - offset
+		case 1:
+			if _, err := io.ReadFull(r, header[8:16]); err != nil {
+				return err
+			}
+			boxSize = int64(binary.BigEndian.Uint64(header[8:16]))
+			headerSize = 16
+		}
+		if boxSize < headerSize || offset+boxSize > size {
+			return fmt.Errorf("box %q 长度无效", name)
+		}
+		if err := visit(name, io.LimitReader(r, boxSize-headerSize), boxSize-headerSize); err != nil {
+			return err
+		}
+		offset += boxSize
+	}
+	return nil
+}
+
+// mp4Children 遍历已读入内存的容器 box 的子 box。
+func mp4Children(data []byte, visit func(name string, body []byte) error) error {
+	r := bytes.NewReader(data)
+	return walkMP4Boxes(r, int64(len(data)), func(name string, body io.Reader, length int64) error {
+		start := len(data) - r.Len()
+		return visit(name, data[start:start+int(length)])
+	})
+}
+
+func parseMP4Movie(moov []byte, meta *videoMetadata) error {
+	return mp4Children(moov, func(name string, body []byte) error {
+		switch name {
+		case "mvhd":
+			timescale, duration := mp4HeaderTimes(body)
+			if timescale > 0 {
+				meta.duration = float64(duration) / float64(timescale)
+			}
+		case "trak":
+			track := &mp4Track{}
+			if err := parseMP4Track(body, track); err != nil {
+				return err
+			}
+			switch track.handler {
+			case "vide":
+				track.stream.codecType = "video"
+			case "soun":
+				track.stream.codecType = "audio"
+			default:
+				return nil
+			}
+			if track.timescale > 0 {
+				track.stream.duration = float64(track.duration) / float64(track.timescale)
+				if track.stream.codecType == "video" && track.duration > 0 {
+					track.stream.fps = float64(track.samples) * float64(track.timescale) / float64(track.duration)
+				}
+			}
+			meta.streams = append(meta.streams, track.stream)
+		}
+		return nil
+	})
+}
+
+func parseMP4Track(data []byte, track *mp4Track) error {
+	return mp4Children(data, func(name string, body []byte) error {
+		switch name {
+		case "tkhd":
+			parseMP4TrackHeader(body, &track.stream)
+		case "mdia", "minf", "stbl":
+			return parseMP4Track(body, track)
+		case "mdhd":
+			timescale, duration := mp4HeaderTimes(body)
+			track.timescale, track.duration = timescale, duration
+			if lang := mp4Language(body); lang != "" && lang != "und" {
+				track.stream.language = lang
+			}
+		case "hdlr":
+			if len(body) >= 12 {
+				track.handler = string(body[8:12])
+			}
+		case "stsd":
+			parseMP4SampleDescription(body, track)
+		case "stts":
+			if len(body) < 8 {
+				return nil
+			}
+			entries := int(binary.BigEndian.Uint32(body[4:8]))
+			for i := 0; i < entries && 8+i*8+8 <= len(body); i++ {
+				track.samples += uint64(binary.BigEndian.Uint32(body[8+i*8:]))
+			}
+		}
+		return nil
+	})
+}
+
+// mp4HeaderTimes 读取 mvhd/mdhd 中的时间刻度与时长，两者在版本 0 与 1 中的布局相同。
+func mp4HeaderTimes(body []byte) (uint32, uint64) {
+	if len(body) < 4 {
+		return 0, 0
+	}
+	if body[0] == 1 {
+		if len(body) < 32 {
+			return 0, 0
+		}
+		return binary.BigEndian.Uint32(body[20:24]), binary.BigEndian.Uint64(body[24:32])
+	}
+	if len(body) < 20 {
+		return 0, 0
+	}
+	return binary.BigEndian.Uint32(body[12:16]), uint64(binary.BigEndian.Uint32(body[16:20]))
+}
+
+// mp4Language 解码 mdhd 中以 3 个 5 位字符打包的 ISO 639-2 语言代码。
+func mp4Language(body []byte) string {
+	offset := 20
+	if len(body) > 0 && body[0] == 1 {
+		offset = 32
+	}
+	if len(body) < offset+2 {
+		return ""
+	}
+	packed := binary.BigEndian.Uint16(body[offset:])
+	return string([]byte{byte(packed>>10&0x1F) + 0x60, byte(packed>>5&0x1F) + 0x60, byte(packed&0x1F) + 0x60})
+}
+
+// parseMP4TrackHeader 读取 tkhd 中的显示矩阵与尺寸，旋转角度按顺时针计算，与 ffprobe 的 rotate 标签一致。
+func parseMP4TrackHeader(body []byte, stream *streamInfo) {
+	matrixOffset := 40
+	if len(body) > 0 && body[0] == 1 {
+		matrixOffset = 52
+	}
+	if len(body) < matrixOffset+44 {
+		return
+	}
+	a := float64(int32(binary.BigEndian.Uint32(body[matrixOffset:])))
+	b := float64(int32(binary.BigEndian.Uint32(body[matrixOffset+4:])))
+	rotation := int(math.Round(math.Atan2(b, a)*180/math.Pi/90)) * 90 % 360
+	if rotation < 0 {
+		rotation += 360
+	}
+	stream.rotation = rotation
+	stream.width = int(binary.BigEndian.Uint32(body[matrixOffset+36:]) >> 16)
+	stream.height = int(binary.BigEndian.Uint32(body[matrixOffset+40:]) >> 16)
+}
+
+// parseMP4SampleDescription 读取第一个样本描述的编码，视频以编码尺寸覆盖 tkhd 中可能经过缩放的显示尺寸。
+func parseMP4SampleDescription(body []byte, track *mp4Track) {
+	if len(body) < 16 {
+		return
+	}
+	entry := body[8:]
+	format := string(entry[4:8])
+	stream := &track.stream
+	stream.codecName = mp4Codecs[format]
+	if stream.codecName == "" {
+		stream.codecName = format
+	}
+	if len(entry) < 36 {
+		return
+	}
+	switch track.handler {
+	case "vide":
+		stream.width = int(binary.BigEndian.Uint16(entry[32:34]))
+		stream.height = int(binary.BigEndian.Uint16(entry[34:36]))
+	case "soun":
+		stream.channels = int(binary.BigEndian.Uint16(entry[24:26]))
+		stream.sampleRate = int(binary.BigEndian.Uint32(entry[32:36]) >> 16)
+	}
+}
+
+// Matroska/WebM 中用到的 EBML 元素 ID。
+const (
+	ebmlHeaderID       = 0x1A45DFA3
+	ebmlDocTypeID      = 0x4282
+	mkvSegmentID       = 0x18538067
+	mkvInfoID          = 0x1549A966
+	mkvTimecodeScaleID = 0x2AD7B1
+	mkvDurationID      = 0x4489
+	mkvTracksID        = 0x1654AE6B
+	mkvTrackEntryID    = 0xAE
+	mkvTrackTypeID     = 0x83
+	mkvCodecID         = 0x86
+	mkvDefaultDurID    = 0x23E383
+	mkvLanguageID      = 0x22B59C
+	mkvNameID          = 0x536E
+	mkvVideoID         = 0xE0
+	mkvPixelWidthID    = 0xB0
+	mkvPixelHeightID   = 0xBA
+	mkvAudioID         = 0xE1
+	mkvSamplingFreqID  = 0xB5
+	mkvChannelsID      = 0x9F
+	mkvClusterID       = 0x1F43B675
+)
+
+var matroskaCodecs = map[string]string{
+	"V_MPEG4/ISO/AVC": "h264", "V_MPEGH/ISO/HEVC": "hevc", "V_AV1": "av1", "V_VP8": "vp8",
+	"V_VP9": "vp9", "V_MPEG4/ISO/ASP": "mpeg4", "V_MPEG2": "mpeg2video", "V_THEORA": "theora",
+	"A_AAC": "aac", "A_OPUS": "opus", "A_VORBIS": "vorbis", "A_AC3": "ac3", "A_EAC3": "eac3",
+	"A_FLAC": "flac", "A_MPEG/L3": "mp3", "A_DTS": "dts", "A_TRUEHD": "truehd",
+}
+
+func probeMatroska(r io.Reader) (*videoMetadata, error) {
+	meta := &videoMetadata{formatName: "matroska,webm", formatLongName: "Matroska / WebM"}
+
+	id, size, err := readEBMLHeader(r)
+	if err != nil || id != ebmlHeaderID {
+		return nil, errors.New("解析 Matroska 失败: EBML 头无效")
+	}
+	header, err := readContainerElement(r, size)
+	if err != nil {
+		return nil, err
+	}
+	err = ebmlChildren(header, func(id uint64, body []byte) {
+		if id == ebmlDocTypeID && string(bytes.TrimRight(body, "\x00")) == "webm" {
+			meta.formatLongName = "WebM"
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if id, _, err = readEBMLHeader(r); err != nil || id != mkvSegmentID {
+		return nil, errors.New("解析 Matroska 失败: 未找到 Segment")
+	}
+
+	// Info 与 Tracks 通常位于第一个 Cluster 之前，读到 Cluster 即停止，不读取媒体数据。
+	var haveInfo, haveTracks bool
+	for !haveInfo || !haveTracks {
+		id, size, err := readEBMLHeader(r)
+		if err != nil || id == mkvClusterID || size < 0 {
+			break
+		}
+		if id != mkvInfoID && id != mkvTracksID {
+			if _, err := io.CopyN(io.Discard, r, size); err != nil {
+				break
+			}
+			continue
+		}
+		body, err := readContainerElement(r, size)
+		if err != nil {
+			return nil, err
+		}
+		if id == mkvInfoID {
+			haveInfo = true
+			err = parseMatroskaInfo(body, meta)
+		} else {
+			haveTracks = true
+			err = ebmlChildren(body, func(id uint64, entry []byte) {
+				if id == mkvTrackEntryID {
+					if stream, ok := parseMatroskaTrack(entry); ok {
+						meta.streams = append(meta.streams, stream)
+					}
+				}
+			})
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解析 Matroska 失败: %w", err)
+		}
+	}
+	if !haveTracks {
+		return nil, errors.New("解析 Matroska 失败: 未找到 Tracks")
+	}
+	return meta, nil
+}
+
+func parseMatroskaInfo(body []byte, meta *videoMetadata) error {
+	scale := uint64(1000000)
+	var duration float64
+	err := ebmlChildren(body, func(id uint64, value []byte) {
+		switch id {
+		case mkvTimecodeScaleID:
+			scale = ebmlUint(value)
+		case mkvDurationID:
+			duration = ebmlFloat(value)
+		}
+	})
+	meta.duration = duration * float64(scale) / 1e9
+	return err
+}
+
+func parseMatroskaTrack(entry []byte) (streamInfo, bool) {
+	var stream streamInfo
+	var codec string
+	_ = ebmlChildren(entry, func(id uint64, value []byte) {
+		switch id {
+		case mkvTrackTypeID:
+			switch ebmlUint(value) {
+			case 1:
+				stream.codecType = "video"
+			case 2:
+				stream.codecType = "audio"
+			}
+		case mkvCodecID:
+			codec = string(bytes.TrimRight(value, "\x00"))
+		case mkvDefaultDurID:
+			if ns := ebmlUint(value); ns > 0 {
+				stream.fps = 1e9 / float64(ns)
+			}
+		case mkvLanguageID:
+			stream.language = string(bytes.TrimRight(value, "\x00"))
+		case mkvNameID:
+			stream.title = string(bytes.TrimRight(value, "\x00"))
+		case mkvVideoID:
+			_ = ebmlChildren(value, func(id uint64, v []byte) {
+				switch id {
+				case mkvPixelWidthID:
+					stream.width = int(ebmlUint(v))
+				case mkvPixelHeightID:
+					stream.height = int(ebmlUint(v))
+				}
+			})
+		case mkvAudioID:
+			_ = ebmlChildren(value, func(id uint64, v []byte) {
+				switch id {
+				case mkvSamplingFreqID:
+					stream.sampleRate = int(ebmlFloat(v))
+				case mkvChannelsID:
+					stream.channels = int(ebmlUint(v))
+				}
+			})
+		}
+	})
+	if stream.codecType == "" {
+		return stream, false
+	}
+	// A_AAC/MPEG4/LC 等带有配置后缀的编码 ID 按前缀匹配。
+	stream.codecName = codec
+	for prefix, name := range matroskaCodecs {
+		if codec == prefix || (len(codec) > len(prefix) && codec[:len(prefix)+1] == prefix+"/") {
+			stream.codecName = name
+			break
+		}
+	}
+	return stream, true
+}
+
+// readEBMLHeader 读取元素 ID (保留长度标记位) 与数据长度，长度未知时返回 -1。
+func readEBMLHeader(r io.Reader) (uint64, int64, error) {
+	id, _, err := readEBMLVint(r, false)
+	if err != nil {
+		return 0, 0, err
+	}
+	size, unknown, err := readEBMLVint(r, true)
+	if err != nil {
+		return 0, 0, err
+	}
+	if unknown {
+		return id, -1, nil
+	}
+	return id, int64(size), nil
+}
+
+func readEBMLVint(r io.Reader, stripMarker bool) (uint64, bool, error) {
+	var first [1]byte
+	if _, err := io.ReadFull(r, first[:]); err != nil {
+		return 0, false, err
+	}
+	length := 1
+	for length <= 8 && first[0]&(0x80>>(length-1)) == 0 {
+		length++
+	}
+	if length > 8 {
+		return 0, false, errors.New("EBML 变长整数无效")
+	}
+	rest := make([]byte, length-1)
+	if _, err := io.ReadFull(r, rest); err != nil {
+		return 0, false, err
+	}
+	value := uint64(first[0])
+	if stripMarker {
+		value &= uint64(0xFF >> length)
+	}
+	allOnes := value == uint64(0xFF>>length)
+	for _, b := range rest {
+		value = value<<8 | uint64(b)
+		allOnes = allOnes && b == 0xFF
+	}
+	return value, stripMarker && allOnes, nil
+}
+
+func ebmlChildren(data []byte, visit func(id uint64, body []byte)) error {
+	r := bytes.NewReader(data)
+	for r.Len() > 0 {
+		id, size, err := readEBMLHeader(r)
+		if err != nil {
+			return err
+		}
+		if size < 0 || size > int64(r.Len()) {
+			return fmt.Errorf("EBML 元素 0x%X 长度无效", id)
+		}
+		start := len(data) - r.Len()
+		visit(id, data[start:start+int(size)])
+		r.Seek(size, io.SeekCurrent)
+	}
+	return nil
+}
+
+func ebmlUint(data []byte) uint64 {
+	var value uint64
+	for _, b := range data {
+		value = value<<8 | uint64(b)
+	}
+	return value
+}
+
+func ebmlFloat(data []byte) float64 {
+	switch len(data) {
+	case 4:
+		return float64(math.Float32frombits(binary.BigEndian.Uint32(data)))
+	case 8:
+		return math.Float64frombits(binary.BigEndian.Uint64(data))
+	}
+	return 0
+}
+
+func readContainerElement(r io.Reader, size int64) ([]byte, error) {
+	if size < 0 || size > maxContainerElement {
+		return nil, fmt.Errorf("元素长度 %d 超出解析范围", size)
+	}
+	data := make([]byte, size)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,104 @@
+package preview
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// coverContainers 为 --embed-cover 支持写回封面的容器，按输入文件的扩展名判断。
+var coverContainers = map[string]string{
+	".mp4": "mp4",
+	".m4v": "mp4",
+	".mov": "mp4",
+	".mkv": "matroska",
+}
+
+// checkCoverTarget 检查 --embed-cover 能否把输出写回输入视频：输入需要是本地 MP4/MOV/MKV 文件，输出需要是单张 JPEG/PNG。
+func checkCoverTarget(cfg *gridConfig) error {
+	switch {
+	case cfg.input == "-" || isRemoteURI(cfg.input) || cfg.source != "":
+		return errors.New("--embed-cover 需要本地输入文件")
+	case coverContainers[strings.ToLower(filepath.Ext(cfg.input))] == "":
+		return fmt.Errorf("--embed-cover 只支持 MP4/MOV 与 MKV 输入: %s", cfg.input)
+	case cfg.output == "-" || isRemoteURI(cfg.output) || cfg.splitSheets() || cfg.framesOnly:
+		return errors.New("--embed-cover 需要输出单张本地图片")
+	}
+	if format, err := outputFormat(cfg.output, cfg.format); isMontageOutput(cfg.output) || err == nil && format != "jpeg" && format != "png" {
+		return errors.New("--embed-cover 需要 JPEG 或 PNG 输出")
+	}
+	return nil
+}
+
+// coverTempPath 返回写回封面时的临时文件，与输入位于同一目录，完成后重命名覆盖输入。
+func coverTempPath(input string) string {
+	return filepath.Join(filepath.Dir(input), "."+filepath.Base(input)+".cover")
+}
+
+// coverArgs 返回把 --output 写入输入视频作为封面的 ffmpeg 参数，所有流直接复制，不重新编码。
+// MP4 中封面是带 attached_pic 标记的视频流，已有的封面流会被替换；MKV 中封面是名为 cover 的附件。
+func coverArgs(cfg *gridConfig, meta *videoMetadata, output string) []string {
+	args := []string{"-loglevel", "error", "-y", "-i", toolPath(cfg.input)}
+	container := coverContainers[strings.ToLower(filepath.Ext(cfg.input))]
+	format, _ := outputFormat(cfg.output, cfg.format)
+	if container == "matroska" {
+		attachments := 0
+		for _, s := range meta.streams {
+			if s.codecType == "attachment" {
+				attachments++
+			}
+		}
+		mime, name := "image/jpeg", "cover.jpg"
+		if format == "png" {
+			mime, name = "image/png", "cover.png"
+		}
+		tag := "-metadata:s:t:" + strconv.Itoa(attachments)
+		args = append(args, "-map", "0", "-c", "copy", "-attach", toolPath(cfg.output),
+			tag, "mimetype="+mime, tag, "filename="+name)
+	} else {
+		videos := 0
+		for _, s := range meta.streams {
+			if s.codecType == "video" && !s.attachedPic {
+				videos++
+			}
+		}
+		// 0:V 只选择不是封面的视频流。
+		args = append(args, "-i", toolPath(cfg.output),
+			"-map", "0:V", "-map", "0:a?", "-map", "0:s?", "-map", "1:v",
+			"-map_metadata", "0", "-map_chapters", "0", "-c", "copy",
+			"-disposition:v:"+strconv.Itoa(videos), "attached_pic")
+	}
+	return append(args, "-f", container, toolPath(output))
+}
+
+// embedCover 生成拼图或截图后把它写回输入视频作为封面：先写入同目录的临时文件，成功后保留原文件权限并重命名覆盖。
+func embedCover(cfg *gridConfig, meta *videoMetadata) error {
+	if err := checkCoverTarget(cfg); err != nil {
+		return err
+	}
+	info, err := os.Stat(cfg.input)
+	if err != nil {
+		return err
+	}
+	temp := coverTempPath(cfg.input)
+	cmd := newCommand(cfg.context(), cfg.runner, ffmpegPath, coverArgs(cfg, meta, temp)...)
+	var stderr stderrTail
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		os.Remove(temp)
+		observeToolFailure("cover")
+		return fmt.Errorf("写入封面失败: %w", commandError(err, stderr.Bytes()))
+	}
+	if err := os.Chmod(temp, info.Mode().Perm()); err != nil {
+		os.Remove(temp)
+		return err
+	}
+	if err := os.Rename(temp, cfg.input); err != nil {
+		os.Remove(temp)
+		return fmt.Errorf("替换输入视频失败: %w", err)
+	}
+	return nil
+}
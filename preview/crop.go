@@ -0,0 +1,54 @@
+package preview
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+)
+
+// cropRegion 为 --crop 指定的截取区域，各分量为像素或相对画面宽高的百分比。
+type cropRegion struct {
+	x, y, w, h spacing
+}
+
+func parseCrop(value string) (*cropRegion, error) {
+	fields := strings.Split(value, ",")
+	if len(fields) != 4 {
+		return nil, fmt.Errorf("crop 应为 x,y,w,h (像素或百分比，例如 0,0,25%%,15%%): %s", value)
+	}
+	var parts [4]spacing
+	for i, name := range []string{"crop x", "crop y", "crop w", "crop h"} {
+		part, err := parseSpacing(name, fields[i])
+		if err != nil {
+			return nil, err
+		}
+		parts[i] = part
+	}
+	region := &cropRegion{x: parts[0], y: parts[1], w: parts[2], h: parts[3]}
+	if region.w.value == 0 || region.h.value == 0 {
+		return nil, errors.New("crop 的宽度与高度必须大于 0")
+	}
+	return region, nil
+}
+
+// rect 把区域换算为 width x height 画面中的像素坐标，超出画面时返回错误。
+func (r *cropRegion) rect(width, height int) (x, y, w, h int, err error) {
+	x, y = r.x.pixels(width), r.y.pixels(height)
+	w, h = r.w.pixels(width), r.h.pixels(height)
+	if w <= 0 || h <= 0 || x+w > width || y+h > height {
+		return 0, 0, 0, 0, fmt.Errorf("crop 区域 (%d,%d %dx%d) 超出画面范围 (%dx%d)", x, y, w, h, width, height)
+	}
+	return x, y, w, h, nil
+}
+
+// filter 返回 crop 滤镜，百分比分量使用 iw/ih 表达式。
+func (r *cropRegion) filter() string {
+	expr := func(s spacing, dimension string) string {
+		if s.percent {
+			return fmt.Sprintf("%s*%g/100", dimension, s.value)
+		}
+		return fmt.Sprint(int(math.Round(s.value)))
+	}
+	return fmt.Sprintf("crop=%s:%s:%s:%s", expr(r.w, "iw"), expr(r.h, "ih"), expr(r.x, "iw"), expr(r.y, "ih"))
+}
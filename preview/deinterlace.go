@@ -0,0 +1,31 @@
+package preview
+
+import "fmt"
+
+func validateDeinterlace(mode string) error {
+	switch mode {
+	case "off", "yadif", "bwdif":
+		return nil
+	default:
+		return fmt.Errorf("不支持的反交错方式: %s (可选: off、yadif、bwdif)", mode)
+	}
+}
+
+// interlaced 根据 ffprobe 报告的场序判断视频是否为隔行扫描；progressive 与未知场序均按逐行处理。
+func (m *videoMetadata) interlaced() bool {
+	switch m.fieldOrder {
+	case "tt", "bb", "tb", "bt":
+		return true
+	}
+	return false
+}
+
+// deinterlaceFilter 返回反交错滤镜，每帧输出一帧 (send_frame)，并按探测到的场序指定奇偶场，
+// 避免部分采集卡文件未在帧上标注隔行信息时滤镜猜错或跳过处理。
+func deinterlaceFilter(mode string, meta *videoMetadata) string {
+	parity := "tff"
+	if meta.fieldOrder == "bb" || meta.fieldOrder == "bt" {
+		parity = "bff"
+	}
+	return fmt.Sprintf("%s=mode=send_frame:parity=%s", mode, parity)
+}
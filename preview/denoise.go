@@ -0,0 +1,8 @@
+package preview
+
+const (
+	// denoiseFilter 只使用 hqdn3d 的空间降噪：每次截图只解码一帧，时间域降噪没有前后帧可参考。
+	denoiseFilter = "hqdn3d=luma_spatial=4:chroma_spatial=3:luma_tmp=0:chroma_tmp=0"
+	// debandFilter 在 YUV 上消除低码率片源平滑渐变区域 (天空、暗部) 的色带，需在色彩转换之前执行。
+	debandFilter = "deband=1thr=0.02:2thr=0.02:3thr=0.02:range=16:blur=1"
+)
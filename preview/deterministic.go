@@ -0,0 +1,10 @@
+package preview
+
+// bitexactArgs 返回让 ffmpeg 不写入编码器版本等可变信息、也不复制输入元数据的输出参数，
+// --deterministic 时用于所有由 ffmpeg 编码的输出，使相同输入多次运行得到逐字节相同的文件。
+func bitexactArgs(deterministic bool) []string {
+	if !deterministic {
+		return nil
+	}
+	return []string{"-fflags", "+bitexact", "-flags:v", "+bitexact", "-map_metadata", "-1"}
+}
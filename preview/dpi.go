@@ -0,0 +1,33 @@
+package preview
+
+import (
+	"encoding/binary"
+	"math"
+)
+
+// pngPhysChunk 返回以每米像素数记录物理分辨率的 pHYs 块。
+func pngPhysChunk(dpi int) pngChunk {
+	data := make([]byte, 9)
+	ppm := uint32(math.Round(float64(dpi) / 0.0254))
+	binary.BigEndian.PutUint32(data[0:4], ppm)
+	binary.BigEndian.PutUint32(data[4:8], ppm)
+	data[8] = 1 // 单位为米
+	return pngChunk{typ: "pHYs", data: data}
+}
+
+// setJPEGDensity 把 JFIF APP0 段中的像素密度改为 dpi (单位为英寸)；编码器未写出 JFIF 段时在 SOI 之后插入一个。
+func setJPEGDensity(data []byte, dpi int) []byte {
+	density := uint16(min(dpi, math.MaxUint16))
+	if len(data) >= 18 && data[2] == 0xFF && data[3] == 0xE0 && string(data[6:11]) == "JFIF\x00" {
+		data[13] = 1
+		binary.BigEndian.PutUint16(data[14:16], density)
+		binary.BigEndian.PutUint16(data[16:18], density)
+		return data
+	}
+	payload := []byte{'J', 'F', 'I', 'F', 0, 1, 1, 1, 0, 0, 0, 0, 0, 0}
+	binary.BigEndian.PutUint16(payload[8:10], density)
+	binary.BigEndian.PutUint16(payload[10:12], density)
+	out := append([]byte{}, data[:2]...)
+	out = append(out, jpegSegment(0xE0, payload)...)
+	return append(out, data[2:]...)
+}
@@ -0,0 +1,186 @@
+package preview
+
+import (
+	"fmt"
+	"io"
+	"os/exec"
+	"regexp"
+	"strings"
+)
+
+// printDryRun 输出 --dry-run 的执行计划：采样时间点、最终拼图尺寸以及截图时将要执行的 ffmpeg 命令。
+// 只读取视频信息 (以及 --avoid-freeze、--snap-to-keyframe 所需的分析)，不截图也不写入任何文件。
+func printDryRun(w io.Writer, cfg *gridConfig, meta *videoMetadata, layout *sheetLayout, timestamps []float64, filters []string, animHeight int) error {
+	width, height := meta.displaySize()
+	fmt.Fprintf(w, "输入: %s (%s，%dx%d，%s)\n", cfg.sourceName(), formatTimestamp(meta.duration), width, height, meta.videoCodec)
+	if cfg.span != nil {
+		fmt.Fprintf(w, "范围: %s (%s - %s)\n", cfg.span.label, formatTimestamp(cfg.span.start), formatTimestamp(cfg.span.end))
+	}
+
+	g := cfg.geometry()
+	fmt.Fprintf(w, "布局: %d 张截图，每格 %dx%d\n", layout.frameCount(), g.cellWidth, g.cellHeight)
+	if !cfg.framesOnly {
+		width, height, parts, err := plannedSheetSize(cfg, meta, layout)
+		if err != nil {
+			return err
+		}
+		line := fmt.Sprintf("输出: %s (%dx%d", cfg.outputName(), width, height)
+		if len(parts) > 0 {
+			line += "，含" + strings.Join(parts, "、")
+		}
+		fmt.Fprintln(w, line+")")
+	}
+	for _, output := range plannedExtraOutputs(cfg) {
+		fmt.Fprintln(w, "输出: "+output)
+	}
+
+	fmt.Fprintf(w, "采样时间点 (%d):\n", len(timestamps))
+	for i, ts := range timestamps {
+		fmt.Fprintf(w, "  %3d  %s  %.3f\n", i+1, formatTimestamp(ts), ts)
+	}
+
+	commands, err := plannedCommands(cfg, meta, layout, timestamps, filters, animHeight)
+	if err != nil {
+		return err
+	}
+	if cfg.backend == "libav" {
+		fmt.Fprintln(w, "截图: 进程内解码 (--backend libav)，不启动 ffmpeg")
+	}
+	if len(commands) == 0 {
+		return nil
+	}
+	fmt.Fprintln(w, "ffmpeg 命令:")
+	for _, args := range commands {
+		fmt.Fprintln(w, "  "+shellCommand(ffmpegPath, args))
+	}
+	return nil
+}
+
+// plannedSheetSize 按 renderPreview 的顺序计算最终拼图尺寸，并列出会追加的信息栏与面板。
+func plannedSheetSize(cfg *gridConfig, meta *videoMetadata, layout *sheetLayout) (int, int, []string, error) {
+	if isMontageOutput(cfg.output) {
+		return evenDimension(cfg.cellWidth), evenDimension(cfg.cellHeight), nil, nil
+	}
+	width, height := layout.canvasSize(cfg.geometry())
+	if cfg.backend == "ffmpeg-tile" {
+		return width, height, nil, nil
+	}
+
+	var parts []string
+	if cfg.waveform && meta.audioCodec != "" {
+		height += panelExtent(cfg, width, waveformHeight(width))
+		parts = append(parts, "音频波形")
+	}
+	if cfg.bitrateGraph {
+		if _, err := exec.LookPath(ffprobePath); err == nil {
+			height += panelExtent(cfg, width, bitrateGraphHeight(width))
+			parts = append(parts, "码率图")
+		}
+	}
+	if len(cfg.footer) > 0 {
+		strip, err := textStripHeight(width, len(cfg.footer))
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		height += strip
+		parts = append(parts, "页脚")
+	}
+	if cfg.header {
+		lines := headerLines(cfg, meta)
+		if cfg.headerTemplate != nil {
+			var err error
+			if lines, err = templateHeaderLines(cfg, meta); err != nil {
+				return 0, 0, nil, err
+			}
+		}
+		count := len(lines)
+		if cfg.loudness && meta.audioCodec != "" {
+			count++
+		}
+		strip, err := textStripHeight(width, count)
+		if err != nil {
+			return 0, 0, nil, err
+		}
+		height += strip
+		parts = append(parts, fmt.Sprintf("信息栏 (%d 行)", count))
+	}
+	return width, height, parts, nil
+}
+
+func plannedExtraOutputs(cfg *gridConfig) []string {
+	var outputs []string
+	outputs = append(outputs, cfg.extraOutputs...)
+	if !cfg.framesOnly {
+		for _, width := range cfg.variants {
+			outputs = append(outputs, variantOutput(cfg.output, width))
+		}
+	}
+	if cfg.saveFramesDir != "" {
+		outputs = append(outputs, cfg.saveFramesDir+" (单帧截图)")
+	}
+	if cfg.animOutput != "" {
+		outputs = append(outputs, cfg.animOutput+" (动态预览)")
+	}
+	if cfg.sidecar && cfg.output != "-" {
+		outputs = append(outputs, sidecarPath(cfg.output))
+	}
+	if cfg.mediaInfo != "" {
+		outputs = append(outputs, cfg.mediaInfo)
+	}
+	return outputs
+}
+
+// plannedCommands 返回截图阶段将要执行的 ffmpeg 参数，与实际截图使用同一组参数构造函数；
+// 命中截图缓存的时间点在实际运行时不会执行对应的命令。
+func plannedCommands(cfg *gridConfig, meta *videoMetadata, layout *sheetLayout, timestamps []float64, filters []string, animHeight int) ([][]string, error) {
+	if cfg.backend == "ffmpeg-tile" {
+		args, err := tileSheetArgs(cfg, layout, timestamps, filters)
+		if err != nil {
+			return nil, err
+		}
+		return [][]string{args}, nil
+	}
+
+	var commands [][]string
+	montage := isMontageOutput(cfg.output)
+	if cfg.prescale {
+		filters = append(filters, prescaleFilter(layout.frameSizes(cfg.geometry()), cfg.animWidth, animHeight))
+	}
+	if !montage || cfg.saveFramesDir != "" || cfg.animOutput != "" {
+		switch {
+		case cfg.backend == "libav":
+		case cfg.backend == "persistent":
+			commands = append(commands, persistentArgs(cfg, timestamps, filters))
+		case cfg.selector == "uniform" || cfg.selector == "":
+			for _, ts := range timestamps {
+				commands = append(commands, captureFrameArgs(cfg.input, cfg.streamIndex, ts, filters, cfg.keyframesOnly, cfg.pipeCodec))
+			}
+		default:
+			for i := range timestamps {
+				start, window, selectFilter := selectorWindow(cfg, meta, i, len(timestamps))
+				commands = append(commands, selectedFrameArgs(cfg, start, window, append([]string{selectFilter, "showinfo"}, filters...)))
+			}
+		}
+	}
+	if montage && !cfg.framesOnly {
+		commands = append(commands, montageArgs(cfg, meta, timestamps))
+	}
+	if cfg.embedCover {
+		commands = append(commands, coverArgs(cfg, meta, coverTempPath(cfg.input)))
+	}
+	return commands, nil
+}
+
+var shellSafePattern = regexp.MustCompile(`^[A-Za-z0-9_./:=,+@%-]+$`)
+
+// shellCommand 把命令与参数拼接为可直接粘贴到 POSIX shell 中执行的一行。
+func shellCommand(name string, args []string) string {
+	quoted := make([]string, 0, len(args)+1)
+	for _, arg := range append([]string{name}, args...) {
+		if !shellSafePattern.MatchString(arg) {
+			arg = "'" + strings.ReplaceAll(arg, "'", `'\''`) + "'"
+		}
+		quoted = append(quoted, arg)
+	}
+	return strings.Join(quoted, " ")
+}
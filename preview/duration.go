@@ -0,0 +1,60 @@
+package preview
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// estimateDuration 在容器未记录时长时 (部分 MKV 与直播录制的 TS)，以 -c copy 读取视频流的全部数据包而不解码，
+// 取 ffmpeg 进度输出中最后一个数据包的时间作为时长。耗时与读取整个文件相当，但远快于完整解码。
+func estimateDuration(ctx context.Context, r Runner, path string, stream int) (float64, error) {
+	cmd := newCommand(ctx, r, ffmpegPath,
+		"-nostdin", "-nostats", "-loglevel", "error",
+		"-i", toolPath(path),
+		"-map", fmt.Sprintf("0:%d", stream),
+		"-c", "copy",
+		"-f", "null",
+		"-progress", "pipe:1",
+		"-",
+	)
+	var stderr stderrTail
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return 0, err
+	}
+	if err := cmd.Start(); err != nil {
+		observeToolFailure("probe")
+		return 0, err
+	}
+
+	// -progress 周期性输出 key=value 行，最后一组 out_time_us 对应最后一个数据包；边读边解析，只保留最新的时间。
+	var duration float64
+	scanner := bufio.NewScanner(stdout)
+	for scanner.Scan() {
+		value, ok := strings.CutPrefix(scanner.Text(), "out_time_us=")
+		if !ok {
+			continue
+		}
+		if us, err := strconv.ParseInt(value, 10, 64); err == nil && us > 0 {
+			duration = float64(us) / 1e6
+		}
+	}
+	// 读取出错时丢弃其余输出，避免 ffmpeg 阻塞在写入上；Wait 的错误 (含非零退出码) 优先报告。
+	scanErr := scanner.Err()
+	if scanErr != nil {
+		_, _ = io.Copy(io.Discard, stdout)
+	}
+	if err := cmd.Wait(); err != nil {
+		observeToolFailure("probe")
+		return 0, commandError(err, stderr.Bytes())
+	}
+	if scanErr != nil {
+		return 0, scanErr
+	}
+	return duration, nil
+}
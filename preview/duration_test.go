@@ -0,0 +1,53 @@
+package preview
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"video-preview-image/previewtest"
+)
+
+func TestEstimateDuration(t *testing.T) {
+	var progress strings.Builder
+	for _, us := range []int{500000, 1500000, 62250000} {
+		fmt.Fprintf(&progress, "frame=0\nout_time_us=%d\nprogress=continue\n", us)
+	}
+	progress.WriteString("out_time_us=N/A\nprogress=end\n")
+	fake := previewtest.NewRunner(t, func(previewtest.Call) previewtest.Result {
+		return previewtest.Result{Stdout: []byte(progress.String())}
+	})
+
+	duration, err := estimateDuration(context.Background(), fake, "live.ts", 1)
+	if err != nil {
+		t.Fatalf("estimateDuration: %v", err)
+	}
+	if duration != 62.25 {
+		t.Errorf("时长为 %v，期望最后一个有效的 out_time_us 62.25", duration)
+	}
+	args := strings.Join(fake.Calls()[0].Args, " ")
+	for _, want := range []string{"-i live.ts", "-map 0:1", "-c copy", "-progress pipe:1"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("参数 %q 中缺少 %q", args, want)
+		}
+	}
+}
+
+func TestEstimateDurationError(t *testing.T) {
+	// 大量 stderr 输出只保留末尾，错误中附加最后几行。
+	stderr := strings.Repeat("[mpegts] noise\n", 4096) + "live.ts: Invalid data found when processing input\n"
+	fake := previewtest.NewRunner(t, func(previewtest.Call) previewtest.Result {
+		return previewtest.Result{Stderr: stderr, ExitCode: 1}
+	})
+
+	_, err := estimateDuration(context.Background(), fake, "live.ts", 0)
+	if err == nil {
+		t.Fatal("ffmpeg 失败时 estimateDuration 应返回错误")
+	}
+	for _, want := range []string{"exit status 1", "Invalid data found when processing input"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("错误 %q 中缺少 %q", err, want)
+		}
+	}
+}
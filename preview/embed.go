@@ -0,0 +1,190 @@
+package preview
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"hash/crc32"
+	"strings"
+	"time"
+)
+
+type sheetMetadata struct {
+	source     string
+	duration   float64
+	timestamps []float64
+	created    time.Time
+}
+
+type pngChunk struct {
+	typ  string
+	data []byte
+}
+
+// injectPNGChunks 将附加块插入到 IHDR 之后，PNG 规范要求 IHDR 必须是第一个块。
+func injectPNGChunks(data []byte, chunks []pngChunk) ([]byte, error) {
+	const signatureLen = 8
+	if len(data) < signatureLen+12 || string(data[12:16]) != "IHDR" {
+		return nil, fmt.Errorf("无效的 PNG 数据")
+	}
+	ihdrEnd := signatureLen + 12 + int(binary.BigEndian.Uint32(data[8:12]))
+
+	var out bytes.Buffer
+	out.Write(data[:ihdrEnd])
+	for _, chunk := range chunks {
+		var header [8]byte
+		binary.BigEndian.PutUint32(header[:4], uint32(len(chunk.data)))
+		copy(header[4:], chunk.typ)
+		out.Write(header[:])
+		out.Write(chunk.data)
+
+		crc := crc32.NewIEEE()
+		crc.Write(header[4:])
+		crc.Write(chunk.data)
+		binary.Write(&out, binary.BigEndian, crc.Sum32())
+	}
+	out.Write(data[ihdrEnd:])
+	return out.Bytes(), nil
+}
+
+// injectJPEGSegments 将附加段插入到 SOI 及紧随其后的 JFIF APP0 段之后。
+func injectJPEGSegments(data []byte, segments [][]byte) ([]byte, error) {
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return nil, fmt.Errorf("无效的 JPEG 数据")
+	}
+	insertAt := 2
+	if data[2] == 0xFF && data[3] == 0xE0 && len(data) >= 6 {
+		insertAt = 4 + int(binary.BigEndian.Uint16(data[4:6]))
+	}
+
+	var out bytes.Buffer
+	out.Write(data[:insertAt])
+	for _, segment := range segments {
+		out.Write(segment)
+	}
+	out.Write(data[insertAt:])
+	return out.Bytes(), nil
+}
+
+func jpegSegment(marker byte, payload []byte) []byte {
+	segment := make([]byte, 4, 4+len(payload))
+	segment[0] = 0xFF
+	segment[1] = marker
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+func pngTextChunk(keyword, text string) pngChunk {
+	var data bytes.Buffer
+	data.WriteString(keyword)
+	// 依次为: 关键字结束符、未压缩、压缩方法、空语言标签、空翻译关键字。
+	data.Write([]byte{0, 0, 0, 0, 0})
+	data.WriteString(text)
+	return pngChunk{typ: "iTXt", data: data.Bytes()}
+}
+
+func (m *sheetMetadata) pngChunks() []pngChunk {
+	return []pngChunk{
+		pngTextChunk("Software", toolName+" "+version),
+		pngTextChunk("Source", m.source),
+		pngTextChunk("Description", m.description()),
+		pngTextChunk("XML:com.adobe.xmp", string(m.xmp())),
+	}
+}
+
+func (m *sheetMetadata) jpegSegments() [][]byte {
+	return [][]byte{
+		jpegSegment(0xE1, append([]byte("Exif\x00\x00"), m.exif()...)),
+		jpegSegment(0xE1, append([]byte("http://ns.adobe.com/xap/1.0/\x00"), m.xmp()...)),
+	}
+}
+
+func (m *sheetMetadata) formattedTimestamps() []string {
+	values := make([]string, len(m.timestamps))
+	for i, ts := range m.timestamps {
+		values[i] = fmt.Sprintf("%.3f", ts)
+	}
+	return values
+}
+
+func (m *sheetMetadata) description() string {
+	return fmt.Sprintf("source=%s; duration=%.3f; timestamps=%s",
+		m.source, m.duration, strings.Join(m.formattedTimestamps(), ","))
+}
+
+func (m *sheetMetadata) xmp() []byte {
+	escape := func(value string) string {
+		var buf bytes.Buffer
+		xml.EscapeText(&buf, []byte(value))
+		return buf.String()
+	}
+
+	var b strings.Builder
+	b.WriteString("<?xpacket begin=\"\ufeff\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	b.WriteString("<x:xmpmeta xmlns:x=\"adobe:ns:meta/\">\n")
+	b.WriteString(" <rdf:RDF xmlns:rdf=\"http://www.w3.org/1999/02/22-rdf-syntax-ns#\">\n")
+	b.WriteString("  <rdf:Description rdf:about=\"\"\n")
+	b.WriteString("    xmlns:xmp=\"http://ns.adobe.com/xap/1.0/\"\n")
+	b.WriteString("    xmlns:dc=\"http://purl.org/dc/elements/1.1/\"\n")
+	b.WriteString("    xmlns:vpi=\"https://github.com/wsyzxjn/video-preview-image/ns/1.0/\"\n")
+	fmt.Fprintf(&b, "    xmp:CreatorTool=\"%s\"\n", escape(toolName+" "+version))
+	if !m.created.IsZero() {
+		fmt.Fprintf(&b, "    xmp:CreateDate=\"%s\"\n", m.created.Format(time.RFC3339))
+	}
+	fmt.Fprintf(&b, "    vpi:SourceFile=\"%s\"\n", escape(m.source))
+	fmt.Fprintf(&b, "    vpi:Duration=\"%.3f\">\n", m.duration)
+	fmt.Fprintf(&b, "   <dc:source>%s</dc:source>\n", escape(m.source))
+	b.WriteString("   <vpi:Timestamps>\n    <rdf:Seq>\n")
+	for _, ts := range m.formattedTimestamps() {
+		fmt.Fprintf(&b, "     <rdf:li>%s</rdf:li>\n", ts)
+	}
+	b.WriteString("    </rdf:Seq>\n   </vpi:Timestamps>\n")
+	b.WriteString("  </rdf:Description>\n </rdf:RDF>\n</x:xmpmeta>\n")
+	b.WriteString("<?xpacket end=\"w\"?>")
+	return []byte(b.String())
+}
+
+// exif 生成只包含 IFD0 的最小 EXIF 数据: ImageDescription、Software 与 DateTime。
+func (m *sheetMetadata) exif() []byte {
+	type entry struct {
+		tag   uint16
+		value string
+	}
+	entries := []entry{
+		{270, m.description()},
+		{305, toolName + " " + version},
+	}
+	if !m.created.IsZero() {
+		entries = append(entries, entry{306, m.created.Format("2006:01:02 15:04:05")})
+	}
+
+	var ifd, values bytes.Buffer
+	valueOffset := 8 + 2 + 12*len(entries) + 4
+	binary.Write(&ifd, binary.LittleEndian, uint16(len(entries)))
+	for _, e := range entries {
+		data := append([]byte(e.value), 0)
+		binary.Write(&ifd, binary.LittleEndian, e.tag)
+		binary.Write(&ifd, binary.LittleEndian, uint16(2))
+		binary.Write(&ifd, binary.LittleEndian, uint32(len(data)))
+		if len(data) <= 4 {
+			var inline [4]byte
+			copy(inline[:], data)
+			ifd.Write(inline[:])
+			continue
+		}
+		binary.Write(&ifd, binary.LittleEndian, uint32(valueOffset+values.Len()))
+		values.Write(data)
+		if values.Len()%2 != 0 {
+			values.WriteByte(0)
+		}
+	}
+	binary.Write(&ifd, binary.LittleEndian, uint32(0))
+
+	var out bytes.Buffer
+	out.WriteString("II*\x00")
+	binary.Write(&out, binary.LittleEndian, uint32(8))
+	out.Write(ifd.Bytes())
+	out.Write(values.Bytes())
+	return out.Bytes()
+}
@@ -0,0 +1,291 @@
+package preview
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/bmp"
+)
+
+type encodeOptions struct {
+	format          string
+	quality         int
+	tiffCompression string
+	jpegProgressive bool
+	jpegSubsampling string
+	pngCompression  string
+	pngColors       int
+	pngDither       bool
+	metadata        *sheetMetadata
+	srgb            bool
+	dpi             int
+	deterministic   bool
+	background      color.Color
+	runner          Runner
+	// maxBytes 大于 0 时降低质量或缩小图片使文件不超过该大小，只用于主拼图。
+	maxBytes int64
+}
+
+func (cfg *gridConfig) encodeOptions() encodeOptions {
+	return encodeOptions{
+		format:          cfg.format,
+		quality:         cfg.jpegQuality,
+		tiffCompression: cfg.tiffCompression,
+		jpegProgressive: cfg.jpegProgressive,
+		jpegSubsampling: cfg.jpegSubsampling,
+		pngCompression:  cfg.pngCompression,
+		pngColors:       cfg.pngColors,
+		pngDither:       cfg.pngDither,
+		srgb:            cfg.colorManagement,
+		dpi:             cfg.dpi,
+		deterministic:   cfg.deterministic,
+		background:      cfg.background,
+		runner:          cfg.runner,
+	}
+}
+
+func normalizeFormat(name string) (string, error) {
+	switch strings.ToLower(strings.TrimPrefix(name, ".")) {
+	case "png":
+		return "png", nil
+	case "jpg", "jpeg":
+		return "jpeg", nil
+	case "tif", "tiff":
+		return "tiff", nil
+	case "bmp":
+		return "bmp", nil
+	case "webp":
+		return "webp", nil
+	default:
+		return "", fmt.Errorf("不支持的输出格式: %s", name)
+	}
+}
+
+func formatExtension(format string) string {
+	switch format {
+	case "jpeg":
+		return ".jpg"
+	case "tiff":
+		return ".tif"
+	default:
+		return "." + format
+	}
+}
+
+// outputFormat 优先使用 --format 指定的格式，否则根据扩展名判断；无扩展名或输出到标准输出时默认为 PNG。
+func outputFormat(path, override string) (string, error) {
+	if override != "" {
+		return normalizeFormat(override)
+	}
+	ext := filepath.Ext(path)
+	if path == "-" || ext == "" {
+		return "png", nil
+	}
+	return normalizeFormat(ext)
+}
+
+func saveImage(img image.Image, path string, opts encodeOptions) error {
+	format, err := outputFormat(path, opts.format)
+	if err != nil {
+		return err
+	}
+
+	var data []byte
+	if opts.maxBytes > 0 {
+		if data, err = encodeWithinSize(img, format, opts); err != nil {
+			return err
+		}
+	}
+
+	var w io.Writer = os.Stdout
+	if path != "-" {
+		if err := ensureOutputDir(path); err != nil {
+			return err
+		}
+		file, err := os.Create(path)
+		if err != nil {
+			return fmt.Errorf("创建输出文件失败: %w", err)
+		}
+		defer file.Close()
+		w = file
+	}
+
+	if data != nil {
+		_, err = w.Write(data)
+		return err
+	}
+	return encodeImage(w, img, format, opts)
+}
+
+func encodeImage(w io.Writer, img image.Image, format string, opts encodeOptions) error {
+	chunks := pngExtraChunks(opts)
+	segments := jpegExtraSegments(opts)
+	if (format != "png" || len(chunks) == 0) && (format != "jpeg" || len(segments) == 0 && opts.dpi == 0) {
+		return encodeRaw(w, img, format, opts)
+	}
+
+	var buf bytes.Buffer
+	if err := encodeRaw(&buf, img, format, opts); err != nil {
+		return err
+	}
+	data := buf.Bytes()
+	var err error
+	if format == "png" {
+		data, err = injectPNGChunks(data, chunks)
+	} else {
+		if opts.dpi > 0 {
+			data = setJPEGDensity(data, opts.dpi)
+		}
+		data, err = injectJPEGSegments(data, segments)
+	}
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
+
+func pngExtraChunks(opts encodeOptions) []pngChunk {
+	var chunks []pngChunk
+	if opts.srgb {
+		chunks = append(chunks, srgbPNGChunks()...)
+	}
+	if opts.dpi > 0 {
+		chunks = append(chunks, pngPhysChunk(opts.dpi))
+	}
+	if opts.metadata != nil {
+		chunks = append(chunks, opts.metadata.pngChunks()...)
+	}
+	return chunks
+}
+
+func jpegExtraSegments(opts encodeOptions) [][]byte {
+	var segments [][]byte
+	if opts.srgb {
+		segments = append(segments, srgbJPEGSegment())
+	}
+	if opts.metadata != nil {
+		segments = append(segments, opts.metadata.jpegSegments()...)
+	}
+	return segments
+}
+
+func encodeRaw(w io.Writer, img image.Image, format string, opts encodeOptions) error {
+	if format == "jpeg" || format == "bmp" {
+		img = flattenAlpha(img, opts.background)
+	}
+	switch format {
+	case "jpeg":
+		if opts.jpegProgressive || opts.jpegSubsampling == "444" {
+			return encodeJPEG(w, img, jpegOptions{
+				quality:     opts.quality,
+				progressive: opts.jpegProgressive,
+				subsampling: opts.jpegSubsampling,
+			})
+		}
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: opts.quality})
+	case "png":
+		return encodePNG(w, img, opts)
+	case "tiff":
+		tiffOpts := tiffOptions{compression: opts.tiffCompression, dpi: opts.dpi}
+		if opts.srgb {
+			tiffOpts.iccProfile = srgbICCProfile()
+		}
+		return encodeTIFF(w, img, tiffOpts)
+	case "bmp":
+		return bmp.Encode(w, img)
+	case "webp":
+		return encodeWebP(opts.runner, w, img, opts.quality, opts.deterministic)
+	default:
+		return fmt.Errorf("不支持的输出格式: %s", format)
+	}
+}
+
+func encodePNG(w io.Writer, img image.Image, opts encodeOptions) error {
+	encoder := png.Encoder{CompressionLevel: pngCompressionLevel(opts.pngCompression)}
+	if opts.pngColors > 0 {
+		img = quantizeImage(img, opts.pngColors, opts.pngDither)
+	}
+	return encoder.Encode(w, img)
+}
+
+func pngCompressionLevel(name string) png.CompressionLevel {
+	switch name {
+	case "none":
+		return png.NoCompression
+	case "fast":
+		return png.BestSpeed
+	case "best":
+		return png.BestCompression
+	default:
+		return png.DefaultCompression
+	}
+}
+
+// flattenAlpha 将半透明像素合成到去掉透明度的背景色上，用于不支持 alpha 通道的 JPEG 与 BMP。
+func flattenAlpha(img image.Image, background color.Color) image.Image {
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		return img
+	}
+	matte := color.NRGBA{255, 255, 255, 255}
+	if background != nil {
+		matte = color.NRGBAModel.Convert(background).(color.NRGBA)
+		matte.A = 255
+	}
+	bounds := img.Bounds()
+	dst := image.NewRGBA(bounds)
+	draw.Draw(dst, bounds, &image.Uniform{C: matte}, image.Point{}, draw.Src)
+	draw.Draw(dst, bounds, img, bounds.Min, draw.Over)
+	return dst
+}
+
+// Go 没有 WebP 编码器，静态 WebP 同样交给 ffmpeg 的 libwebp 完成。
+func encodeWebP(r Runner, w io.Writer, img image.Image, quality int, deterministic bool) error {
+	var input bytes.Buffer
+	encoder := png.Encoder{CompressionLevel: png.BestSpeed}
+	if err := encoder.Encode(&input, img); err != nil {
+		return err
+	}
+
+	args := []string{
+		"-loglevel", "error",
+		"-f", "png_pipe",
+		"-i", "-",
+		"-c:v", "libwebp",
+		"-quality", strconv.Itoa(quality),
+	}
+	if opaque, ok := img.(interface{ Opaque() bool }); !ok || !opaque.Opaque() {
+		args = append(args, "-pix_fmt", "yuva420p")
+	}
+	args = append(args, bitexactArgs(deterministic)...)
+	args = append(args, "-f", "webp", "-")
+	cmd := newCommand(context.Background(), r, ffmpegPath, args...)
+	cmd.Stdin = &input
+	cmd.Stdout = w
+	var stderr stderrTail
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		observeToolFailure("webp")
+		return fmt.Errorf("编码 WebP 失败: %w", commandError(err, stderr.Bytes()))
+	}
+	return nil
+}
+
+func ensureOutputDir(path string) error {
+	dir := filepath.Dir(path)
+	if dir == "." || dir == "" {
+		return nil
+	}
+	return os.MkdirAll(dir, 0o755)
+}
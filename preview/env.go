@@ -0,0 +1,43 @@
+package preview
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const envPrefix = "VPI_"
+
+// envName 将参数名转换为对应的环境变量名，例如 cell-width 对应 VPI_CELL_WIDTH。
+func envName(flagName string) string {
+	return envPrefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// parseArgs 解析命令行参数，未在命令行中出现的参数再从 VPI_* 环境变量读取。
+// 优先级: 命令行 > 环境变量 > 预设 > 默认值。
+func parseArgs(fs *flag.FlagSet, args []string) error {
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil || explicit[f.Name] {
+			return
+		}
+		value, ok := os.LookupEnv(envName(f.Name))
+		if !ok {
+			return
+		}
+		if setErr := fs.Set(f.Name, value); setErr != nil {
+			err = fmt.Errorf("环境变量 %s 的值无效: %w", envName(f.Name), setErr)
+		}
+	})
+	return err
+}
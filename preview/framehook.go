@@ -0,0 +1,51 @@
+package preview
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// frameHookCommand 返回 --frame-hook 的截图回调：每张截图以 PNG 写入命令的标准输入，命令从标准输出返回处理后的图片
+// (PNG、JPEG 等)。命令与参数以空格分隔，不经过 shell；截图序号 (从 1 开始) 与时间 (秒) 通过
+// VPI_FRAME_INDEX、VPI_FRAME_TIME 环境变量传递。
+func frameHookCommand(cfg *gridConfig, command string) (func(int, float64, image.Image) (image.Image, error), error) {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil, errors.New("frame-hook 不能为空")
+	}
+	if _, err := exec.LookPath(args[0]); err != nil {
+		return nil, fmt.Errorf("未找到 frame-hook 命令: %s", args[0])
+	}
+	return func(index int, timestamp float64, frame image.Image) (image.Image, error) {
+		var input bytes.Buffer
+		if err := png.Encode(&input, frame); err != nil {
+			return nil, err
+		}
+		cmd := newCommand(cfg.context(), cfg.runner, args[0], args[1:]...)
+		cmd.Env = append(os.Environ(),
+			"VPI_FRAME_INDEX="+strconv.Itoa(index+1),
+			"VPI_FRAME_TIME="+strconv.FormatFloat(timestamp, 'f', 3, 64),
+		)
+		cmd.Stdin = &input
+		var stdout bytes.Buffer
+		var stderr stderrTail
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("frame-hook 处理第 %d 张截图失败: %w", index+1, commandError(err, stderr.Bytes()))
+		}
+		img, _, err := image.Decode(bufio.NewReader(&stdout))
+		if err != nil {
+			return nil, fmt.Errorf("解码 frame-hook 输出的第 %d 张截图失败: %w", index+1, err)
+		}
+		return img, nil
+	}, nil
+}
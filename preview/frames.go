@@ -0,0 +1,46 @@
+package preview
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseFrameNumbers 解析 --frame-numbers 的逗号分隔帧序号列表 (从 0 开始，按给出的顺序排列)。
+func parseFrameNumbers(value string) ([]int, error) {
+	var frames []int
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return nil, fmt.Errorf("无效的帧序号: %s", field)
+		}
+		frames = append(frames, n)
+	}
+	if len(frames) == 0 {
+		return nil, errors.New("frame-numbers 至少需要一个帧序号")
+	}
+	return frames, nil
+}
+
+// frameTimestamps 把帧序号换算为定位时间。按恒定帧率计算时第 n 帧的显示时间为 n/fps，
+// 定位点取其前四分之一帧处：精确定位 (-ss 在 -i 之前) 会解码到该点之后的第一帧，即第 n 帧，
+// 不会因时间舍入落到相邻帧；该时间换算回帧序号时也仍为 n。
+func frameTimestamps(frames []int, meta *videoMetadata) ([]float64, error) {
+	if meta.fps <= 0 {
+		return nil, errors.New("无法确定视频帧率，不能按帧序号截图")
+	}
+	last := int(meta.duration * meta.fps)
+	timestamps := make([]float64, len(frames))
+	for i, n := range frames {
+		if meta.duration > 0 && n >= last {
+			return nil, fmt.Errorf("帧序号 %d 超出视频范围 (共约 %d 帧)", n, last)
+		}
+		timestamps[i] = max((float64(n)-0.25)/meta.fps, 0)
+	}
+	return timestamps, nil
+}
@@ -0,0 +1,59 @@
+package preview
+
+import (
+	"fmt"
+	"image"
+	"iter"
+	"os"
+)
+
+// capturedFrame 为按采样顺序取得的一张截图，timestamp 为实际截取的时间 (选帧方式可能偏离采样点)。
+type capturedFrame struct {
+	index     int
+	timestamp float64
+	image     image.Image
+}
+
+// captureFrames 按采样顺序逐张取得截图并立即产出，调用方可以边截取边处理 (拼接、保存单帧或推送进度)。
+// 先读取截图缓存，persistent 后端一次截取全部时间点；yield 返回 false 时不再截取后续截图。
+func captureFrames(cfg *gridConfig, meta *videoMetadata, timestamps []float64, filters []string) iter.Seq2[capturedFrame, error] {
+	return func(yield func(capturedFrame, error) bool) {
+		cache := openFrameCache(cfg, filters)
+		var prefetched []image.Image
+		if cfg.backend == "persistent" {
+			var err error
+			if prefetched, err = prefetchFrames(cfg, cache, timestamps, filters); err != nil {
+				yield(capturedFrame{}, err)
+				return
+			}
+		}
+		for i, sample := range timestamps {
+			var frame image.Image
+			ts := sample
+			if prefetched != nil {
+				frame = prefetched[i]
+			} else if cache != nil {
+				frame, ts = cache.load(sample)
+			}
+			if frame == nil {
+				err := withRetry(cfg, fmt.Sprintf("提取第 %d 张截图", i+1), func() (err error) {
+					frame, ts, err = sampleFrame(cfg, meta, i, len(timestamps), sample, filters)
+					return err
+				})
+				if err != nil {
+					yield(capturedFrame{index: i}, fmt.Errorf("提取第 %d 张截图失败: %w", i+1, err))
+					return
+				}
+				if cache != nil {
+					if err := cache.store(sample, ts, frame); err != nil {
+						fmt.Fprintln(os.Stderr, "警告: 写入截图缓存失败，本次任务不再使用缓存:", err)
+						cache = nil
+					}
+				}
+			}
+			if !yield(capturedFrame{index: i, timestamp: ts, image: frame}, nil) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package preview
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+const (
+	// 相邻帧差异低于 freezeNoise 且持续至少 freezeMinDuration 秒时视为静止画面。
+	freezeNoise       = "-60dB"
+	freezeMinDuration = 2.0
+	// freezeAnalysisWidth 为检测前缩小到的宽度，静止检测不需要原始分辨率。
+	freezeAnalysisWidth = 320
+	// 静止段以外的画面不足采样范围的该比例时不再调整采样点，避免所有截图挤在几秒内。
+	minUnfrozenRatio = 0.05
+)
+
+// freezedetect 在静止段开始与结束时分别输出 freeze_start 与 freeze_end，时间相对于 -ss 定位后的起点。
+var freezeEventPattern = regexp.MustCompile(`lavfi\.freezedetect\.freeze_(start|end):\s*(-?[0-9.]+)`)
+
+type frozenRange struct {
+	start, end float64
+}
+
+// detectFreezes 用 freezedetect 滤镜完整解码采样范围内的画面，返回其中的静止段 (绝对时间)。
+func detectFreezes(ctx context.Context, cfg *gridConfig, start, length float64) ([]frozenRange, error) {
+	cmd := newCommand(ctx, cfg.runner, ffmpegPath,
+		"-hide_banner", "-nostats", "-loglevel", "info",
+		"-ss", fmt.Sprintf("%.3f", start),
+		"-t", fmt.Sprintf("%.3f", length),
+		"-i", toolPath(cfg.input),
+		"-map", fmt.Sprintf("0:%d", cfg.streamIndex), "-an", "-sn",
+		"-vf", fmt.Sprintf("scale=%d:-2,freezedetect=n=%s:d=%g", freezeAnalysisWidth, freezeNoise, freezeMinDuration),
+		"-f", "null", "-",
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		observeToolFailure("freezedetect")
+		return nil, fmt.Errorf("检测静止画面失败: %w", commandError(err, stderr.Bytes()))
+	}
+
+	var freezes []frozenRange
+	open := false
+	for _, match := range freezeEventPattern.FindAllSubmatch(stderr.Bytes(), -1) {
+		value, err := strconv.ParseFloat(string(match[2]), 64)
+		if err != nil {
+			continue
+		}
+		value = start + min(max(value, 0), length)
+		switch {
+		case string(match[1]) == "start":
+			freezes = append(freezes, frozenRange{start: value, end: start + length})
+			open = true
+		case open:
+			freezes[len(freezes)-1].end = value
+			open = false
+		}
+	}
+	return freezes, nil
+}
+
+// avoidFreezes 把采样点重新分布到静止段以外的画面中：去掉静止段后将剩余画面首尾相接，
+// 各采样点按其在原范围中的相对位置映射到剩余画面上，保持顺序与相对间隔。
+func avoidFreezes(timestamps []float64, start, length float64, freezes []frozenRange) []float64 {
+	if len(freezes) == 0 || length <= 0 {
+		return timestamps
+	}
+	var free []frozenRange
+	cursor, total := start, 0.0
+	for _, f := range freezes {
+		if f.start > cursor {
+			free = append(free, frozenRange{start: cursor, end: f.start})
+			total += f.start - cursor
+		}
+		cursor = max(cursor, f.end)
+	}
+	if end := start + length; end > cursor {
+		free = append(free, frozenRange{start: cursor, end: end})
+		total += end - cursor
+	}
+	if total < length*minUnfrozenRatio {
+		return timestamps
+	}
+
+	shifted := make([]float64, len(timestamps))
+	for i, t := range timestamps {
+		offset := (t - start) / length * total
+		for j, r := range free {
+			span := r.end - r.start
+			if offset < span || j == len(free)-1 {
+				shifted[i] = r.start + min(offset, span)
+				break
+			}
+			offset -= span
+		}
+	}
+	return shifted
+}
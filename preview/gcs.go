@@ -0,0 +1,80 @@
+package preview
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"cloud.google.com/go/storage"
+)
+
+type gcsBackend struct {
+	client *storage.Client
+}
+
+// newGCSBackend 使用 Application Default Credentials (GOOGLE_APPLICATION_CREDENTIALS、gcloud 登录或元数据服务)。
+func newGCSBackend(ctx context.Context, opts storageOptions) (storageBackend, error) {
+	client, err := storage.NewClient(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("创建 Google Cloud Storage 客户端失败: %w", err)
+	}
+	return &gcsBackend{client: client}, nil
+}
+
+// presign 需要服务账号凭证 (或具备 signBlob 权限的身份)，最终用户凭证无法签名，此时会回退为下载。
+func (b *gcsBackend) presign(ctx context.Context, uri string, expiry time.Duration) (string, error) {
+	bucket, key, err := splitStorageURI(uri)
+	if err != nil {
+		return "", err
+	}
+	return b.client.Bucket(bucket).SignedURL(key, &storage.SignedURLOptions{
+		Method:  http.MethodGet,
+		Expires: time.Now().Add(expiry),
+		Scheme:  storage.SigningSchemeV4,
+	})
+}
+
+func (b *gcsBackend) download(ctx context.Context, uri, path string) error {
+	bucket, key, err := splitStorageURI(uri)
+	if err != nil {
+		return err
+	}
+	reader, err := b.client.Bucket(bucket).Object(key).NewReader(ctx)
+	if err != nil {
+		return err
+	}
+	defer reader.Close()
+
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(file, reader)
+	return err
+}
+
+func (b *gcsBackend) upload(ctx context.Context, path, uri string) error {
+	bucket, key, err := splitStorageURI(uri)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	writer := b.client.Bucket(bucket).Object(key).NewWriter(ctx)
+	writer.ContentType = mime.TypeByExtension(filepath.Ext(key))
+	if _, err := io.Copy(writer, file); err != nil {
+		writer.Close()
+		return err
+	}
+	return writer.Close()
+}
@@ -0,0 +1,218 @@
+package preview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"io"
+	"iter"
+	"os"
+	"path/filepath"
+	"slices"
+	"time"
+)
+
+// Generator 供其他 Go 程序直接调用本工具的截图与拼图流程，参数与命令行同名。
+// 同一个 Generator 可以多次调用 Frames 与 Generate，也可以在多个 goroutine 中同时使用。
+type Generator struct {
+	// OnProbe 在读取视频信息后、开始截图前调用。
+	OnProbe func(info VideoInfo)
+	// OnFrameCaptured 在每张截图取得后、缩放与拼接前调用 (Frames 中在产出前调用)，返回的图像替代原截图，
+	// 可用于人脸打码等处理；返回错误或 nil 图像时终止生成。backend=ffmpeg-tile 与 .mp4 预览短片不逐张截图，
+	// 设置后 Generate 返回错误。
+	OnFrameCaptured func(index int, timestamp time.Duration, frame image.Image) (image.Image, error)
+	// OnComposeStart 在全部截图取得后、开始拼接前调用，frames 为截图数；Frames 不会调用。
+	OnComposeStart func(frames int)
+	// Runner 为 nil 时直接启动 ffmpeg 与 ffprobe；可替换为 previewtest.Runner 等实现，在测试中记录调用并返回预设结果。
+	Runner Runner
+
+	cfg *gridConfig
+	// spooled 为 NewReader 写入的临时文件，Close 时删除。
+	spooled string
+}
+
+// Frame 为 Frames 按采样顺序产出的一张原始分辨率截图 (已应用 --crop、--rotate 等截图滤镜，未缩放)。
+// Timestamp 为实际截取的时间，按 --selector 选帧时可能偏离采样点。
+type Frame struct {
+	Index     int
+	Timestamp time.Duration
+	Image     image.Image
+}
+
+// New 返回处理 input (本地路径或 http(s) 地址；Generate 还支持 s3://、gs://、az://) 的 Generator。
+// options 以不含前导 - 的参数名为键，取值与命令行相同，例如 {"rows": "4", "selector": "scene"}；
+// 未指定的参数依次取 VPI_* 环境变量、预设与默认值。
+func New(input string, options map[string]string) (*Generator, error) {
+	if input == "" {
+		return nil, errors.New("必须指定输入视频路径")
+	}
+	cfg, err := optionsConfig(options, nil)
+	if err != nil {
+		return nil, err
+	}
+	cfg.input = input
+	return &Generator{cfg: cfg}, nil
+}
+
+// NewReader 与 New 相同，但从 r 读取视频 (例如上传的 multipart 文件)。ffprobe 探测与精确定位都需要随机读取，
+// r 为普通文件的 *os.File 时直接使用该文件，否则先完整写入系统临时目录，使用完毕后需要调用 Close 删除。
+// name 为信息栏与元数据中显示的文件名，其扩展名同时用于临时文件；可以为空。
+func NewReader(r io.Reader, name string, options map[string]string) (*Generator, error) {
+	cfg, err := optionsConfig(options, nil)
+	if err != nil {
+		return nil, err
+	}
+	g := &Generator{cfg: cfg}
+	if file, ok := r.(*os.File); ok {
+		if info, err := file.Stat(); err == nil && info.Mode().IsRegular() {
+			cfg.input = file.Name()
+		}
+	}
+	if cfg.input == "" {
+		path, err := spoolInput(r, "", filepath.Ext(name))
+		if err != nil {
+			return nil, fmt.Errorf("读取视频失败: %w", err)
+		}
+		cfg.input = path
+		g.spooled = path
+	}
+	if name != "" {
+		cfg.source = filepath.Base(name)
+	}
+	return g, nil
+}
+
+// Close 删除 NewReader 写入的临时文件；New 创建的 Generator 不需要调用。
+func (g *Generator) Close() error {
+	if g.spooled == "" {
+		return nil
+	}
+	err := os.Remove(g.spooled)
+	g.spooled = ""
+	return err
+}
+
+// job 返回本次调用使用的参数副本，读取视频信息后确定的值 (视频流、单格高度等) 不会影响其他调用。
+func (g *Generator) job(ctx context.Context) *gridConfig {
+	cfg := *g.cfg
+	cfg.ctx = ctx
+	if g.OnProbe != nil {
+		cfg.onProbe = func(meta *videoMetadata) { g.OnProbe(newMetadataReport(meta)) }
+	}
+	if g.OnFrameCaptured != nil {
+		cfg.frameHook = func(index int, timestamp float64, frame image.Image) (image.Image, error) {
+			replaced, err := g.OnFrameCaptured(index, seconds(timestamp), frame)
+			if err == nil && replaced == nil {
+				return nil, fmt.Errorf("OnFrameCaptured 对第 %d 张截图返回了空图像", index+1)
+			}
+			return replaced, err
+		}
+	}
+	cfg.onComposeStart = g.OnComposeStart
+	cfg.runner = g.Runner
+	return &cfg
+}
+
+func seconds(s float64) time.Duration {
+	return time.Duration(s * float64(time.Second))
+}
+
+// Frames 读取视频信息并按与拼图相同的采样方式逐张截图，每张截取完成后立即产出，调用方可以自行排版
+// 或边截取边推送到界面；停止遍历或 ctx 结束后不再截取后续截图。出错时产出一次错误后结束。
+func (g *Generator) Frames(ctx context.Context) iter.Seq2[Frame, error] {
+	return func(yield func(Frame, error) bool) {
+		cfg := g.job(ctx)
+		if isRemoteURI(cfg.input) {
+			yield(Frame{}, errors.New("Frames 不支持对象存储地址，请改用预签名的 http(s) 地址或先下载到本地"))
+			return
+		}
+		meta, err := prepareVideo(cfg)
+		if err != nil {
+			yield(Frame{}, err)
+			return
+		}
+		if cfg.interval > 0 && cfg.autoRows {
+			_, length := cfg.sampleRange(meta)
+			cfg.rows = (intervalCount(length, cfg.interval.Seconds()) + cfg.cols - 1) / cfg.cols
+		}
+		layout, err := cfg.sheetLayout()
+		if err != nil {
+			yield(Frame{}, err)
+			return
+		}
+		timestamps, err := planTimestamps(cfg, meta, layout)
+		if err != nil {
+			yield(Frame{}, err)
+			return
+		}
+		for captured, err := range captureFrames(cfg, meta, timestamps, captureFilters(cfg, meta)) {
+			if err != nil {
+				yield(Frame{}, err)
+				return
+			}
+			frame := Frame{Index: captured.index, Timestamp: seconds(captured.timestamp), Image: captured.image}
+			if cfg.frameHook != nil {
+				if frame.Image, err = cfg.frameHook(captured.index, captured.timestamp, captured.image); err != nil {
+					yield(Frame{}, err)
+					return
+				}
+			}
+			if !yield(frame, nil) {
+				return
+			}
+		}
+	}
+}
+
+// Generate 生成拼图并写入 output (本地路径或对象存储地址)，与命令行的 --output 相同；
+// 格式由扩展名或 format 参数决定，其他输出 (--save-frames、--anim-output 等) 按参数一并生成。
+func (g *Generator) Generate(ctx context.Context, output string) error {
+	if output == "" || output == "-" {
+		return errors.New("必须指定输出路径")
+	}
+	cfg := g.job(ctx)
+	cfg.output = output
+	if cfg.frameHook != nil && (cfg.backend == "ffmpeg-tile" || isMontageOutput(output)) {
+		return errors.New("OnFrameCaptured 需要逐张截图，不支持 backend=ffmpeg-tile 与 .mp4 预览短片")
+	}
+	_, err := runPreview(cfg)
+	return err
+}
+
+// Cell 为 ComposeGrid 拼接的一张截图；Timestamp 用于 timestamps=true 时的时间戳与 polaroid 样式的说明文字。
+type Cell struct {
+	Image     image.Image
+	Timestamp time.Duration
+}
+
+// LayoutOptions 为 ComposeGrid 的布局与样式参数，键与取值同 New 的 options，例如 {"cols": "3", "style": "polaroid"}；
+// 需要读取视频的参数 (header、waveform、save-frames 等) 不可用。
+type LayoutOptions map[string]string
+
+// ComposeGrid 按与拼图相同的布局与样式拼接调用方提供的截图，不读取视频也不调用 ffmpeg；
+// 截图按顺序填入各格并缩放到单格大小，单格高度未指定时按第一张截图的宽高比推算。
+func ComposeGrid(frames []Cell, opts LayoutOptions) (image.Image, error) {
+	if len(frames) == 0 {
+		return nil, errors.New("至少需要一张截图")
+	}
+	for name := range opts {
+		if name != "timestamps" && slices.Contains(composeUnsupported, name) {
+			return nil, fmt.Errorf("拼接已有图片时不支持参数 %s", name)
+		}
+	}
+	cfg, err := optionsConfig(opts, nil)
+	if err != nil {
+		return nil, err
+	}
+	images := make([]image.Image, len(frames))
+	timestamps := make([]float64, len(frames))
+	for i, frame := range frames {
+		if frame.Image == nil {
+			return nil, fmt.Errorf("第 %d 张截图为空", i+1)
+		}
+		images[i] = frame.Image
+		timestamps[i] = frame.Timestamp.Seconds()
+	}
+	return composeFrames(cfg, images, timestamps)
+}
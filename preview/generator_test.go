@@ -0,0 +1,277 @@
+package preview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"video-preview-image/previewtest"
+)
+
+// fakeTools 返回代替 ffprobe 与 ffmpeg 的假执行器：ffprobe 返回 ffprobeJSON，ffmpeg 返回 64x36 的截图。
+func fakeTools(t *testing.T) *previewtest.Runner {
+	return previewtest.NewRunner(t, func(call previewtest.Call) previewtest.Result {
+		if call.Name == ffprobePath {
+			return previewtest.Result{Stdout: []byte(ffprobeJSON)}
+		}
+		return previewtest.Result{Stdout: previewtest.PNG(64, 36, color.White)}
+	})
+}
+
+func TestGeneratorFrames(t *testing.T) {
+	fake := fakeTools(t)
+	g, err := New("movie.mp4", map[string]string{"rows": "2", "cols": "2", "no-cache": "true"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	g.Runner = fake
+
+	var frames []Frame
+	for frame, err := range g.Frames(context.Background()) {
+		if err != nil {
+			t.Fatalf("Frames: %v", err)
+		}
+		frames = append(frames, frame)
+	}
+	if len(frames) != 4 {
+		t.Fatalf("产出了 %d 张截图，期望 4 张", len(frames))
+	}
+	for i, frame := range frames {
+		if frame.Index != i {
+			t.Errorf("第 %d 张截图的序号为 %d", i, frame.Index)
+		}
+		if i > 0 && frame.Timestamp <= frames[i-1].Timestamp {
+			t.Errorf("截图时间应递增: %v 之后为 %v", frames[i-1].Timestamp, frame.Timestamp)
+		}
+		if size := frame.Image.Bounds().Size(); size.X != 64 || size.Y != 36 {
+			t.Errorf("第 %d 张截图尺寸为 %v，期望原始分辨率 64x36", i, size)
+		}
+	}
+	if calls := len(fake.Calls()); calls != 5 {
+		t.Errorf("调用了 %d 次外部程序，期望 1 次 ffprobe 与 4 次 ffmpeg", calls)
+	}
+}
+
+func TestGeneratorFramesStop(t *testing.T) {
+	fake := fakeTools(t)
+	g, err := New("movie.mp4", map[string]string{"rows": "3", "cols": "3", "no-cache": "true"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	g.Runner = fake
+
+	for frame, err := range g.Frames(context.Background()) {
+		if err != nil {
+			t.Fatalf("Frames: %v", err)
+		}
+		if frame.Index == 1 {
+			break
+		}
+	}
+	if calls := len(fake.Calls()); calls != 3 {
+		t.Errorf("停止遍历后调用了 %d 次外部程序，期望 1 次 ffprobe 与 2 次 ffmpeg", calls)
+	}
+}
+
+func TestGeneratorGenerate(t *testing.T) {
+	fake := fakeTools(t)
+	g, err := New("movie.mp4", map[string]string{"rows": "2", "cols": "3", "cell-width": "160", "no-cache": "true"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	g.Runner = fake
+
+	output := filepath.Join(t.TempDir(), "sheet.png")
+	if err := g.Generate(context.Background(), output); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+	file, err := os.Open(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	sheet, err := png.DecodeConfig(file)
+	if err != nil {
+		t.Fatalf("解码拼图失败: %v", err)
+	}
+	if sheet.Width < 3*160 {
+		t.Errorf("拼图宽度为 %d，应至少容纳 3 列 160 像素的单格", sheet.Width)
+	}
+}
+
+func TestGeneratorRunnerConcurrent(t *testing.T) {
+	// 每个 Generator 只通过自己的 Runner 调用外部程序，可以同时使用不同的 Runner。
+	fakes := []*previewtest.Runner{fakeTools(t), fakeTools(t)}
+	var wg sync.WaitGroup
+	for _, fake := range fakes {
+		g, err := New("movie.mp4", map[string]string{"rows": "2", "cols": "2", "no-cache": "true"})
+		if err != nil {
+			t.Fatalf("New: %v", err)
+		}
+		g.Runner = fake
+		wg.Go(func() {
+			if err := g.Generate(context.Background(), filepath.Join(t.TempDir(), "sheet.png")); err != nil {
+				t.Errorf("Generate: %v", err)
+			}
+		})
+	}
+	wg.Wait()
+	for i, fake := range fakes {
+		if calls := len(fake.Calls()); calls != 5 {
+			t.Errorf("第 %d 个 Runner 收到 %d 次调用，期望 1 次 ffprobe 与 4 次 ffmpeg", i+1, calls)
+		}
+	}
+}
+
+func TestGeneratorHooks(t *testing.T) {
+	fake := fakeTools(t)
+	g, err := New("movie.mp4", map[string]string{"rows": "2", "cols": "2", "no-cache": "true"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	g.Runner = fake
+
+	var events []string
+	g.OnProbe = func(info VideoInfo) {
+		events = append(events, fmt.Sprintf("probe %dx%d", info.Width, info.Height))
+	}
+	g.OnFrameCaptured = func(index int, timestamp time.Duration, frame image.Image) (image.Image, error) {
+		events = append(events, fmt.Sprintf("frame %d", index))
+		// 替换后的截图应参与拼接。
+		return image.NewRGBA(image.Rect(0, 0, 32, 18)), nil
+	}
+	g.OnComposeStart = func(frames int) {
+		events = append(events, fmt.Sprintf("compose %d", frames))
+	}
+	if err := g.Generate(context.Background(), filepath.Join(t.TempDir(), "sheet.png")); err != nil {
+		t.Fatalf("Generate: %v", err)
+	}
+
+	want := []string{"probe 1920x1080", "frame 0", "frame 1", "frame 2", "frame 3", "compose 4"}
+	if !slices.Equal(events, want) {
+		t.Errorf("回调顺序为 %q，期望 %q", events, want)
+	}
+}
+
+func TestGeneratorHookError(t *testing.T) {
+	fake := fakeTools(t)
+	g, err := New("movie.mp4", map[string]string{"rows": "1", "cols": "2", "no-cache": "true"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	g.Runner = fake
+	g.OnFrameCaptured = func(int, time.Duration, image.Image) (image.Image, error) {
+		return nil, errors.New("blur failed")
+	}
+
+	var errs []error
+	for _, err := range g.Frames(context.Background()) {
+		errs = append(errs, err)
+	}
+	if len(errs) != 1 || errs[0] == nil || errs[0].Error() != "blur failed" {
+		t.Errorf("OnFrameCaptured 出错后应只产出该错误，实际为 %v", errs)
+	}
+}
+
+func TestGeneratorHookNilImage(t *testing.T) {
+	fake := fakeTools(t)
+	g, err := New("movie.mp4", map[string]string{"rows": "1", "cols": "2", "no-cache": "true"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	g.Runner = fake
+	g.OnFrameCaptured = func(int, time.Duration, image.Image) (image.Image, error) {
+		return nil, nil
+	}
+	err = g.Generate(context.Background(), filepath.Join(t.TempDir(), "sheet.png"))
+	if err == nil || !strings.Contains(err.Error(), "空图像") {
+		t.Errorf("OnFrameCaptured 返回 nil 图像时应返回错误，实际为 %v", err)
+	}
+}
+
+func TestGeneratorHookTileBackend(t *testing.T) {
+	fake := fakeTools(t)
+	g, err := New("movie.mp4", map[string]string{"backend": "ffmpeg-tile"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	g.Runner = fake
+	g.OnFrameCaptured = func(_ int, _ time.Duration, frame image.Image) (image.Image, error) {
+		return frame, nil
+	}
+	if err := g.Generate(context.Background(), filepath.Join(t.TempDir(), "sheet.png")); err == nil || !strings.Contains(err.Error(), "ffmpeg-tile") {
+		t.Errorf("ffmpeg-tile 后端设置 OnFrameCaptured 时应返回错误，实际为 %v", err)
+	}
+	if calls := len(fake.Calls()); calls != 0 {
+		t.Errorf("参数错误时不应调用外部程序，实际调用了 %d 次", calls)
+	}
+}
+
+func TestNewReader(t *testing.T) {
+	fake := fakeTools(t)
+	g, err := NewReader(strings.NewReader("not really a video"), "uploads/clip.mp4", map[string]string{"rows": "1", "cols": "2", "no-cache": "true"})
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	g.Runner = fake
+	for _, err := range g.Frames(context.Background()) {
+		if err != nil {
+			t.Fatalf("Frames: %v", err)
+		}
+	}
+
+	args := fake.Calls()[0].Args
+	input := args[len(args)-1]
+	if filepath.Ext(input) != ".mp4" {
+		t.Errorf("临时文件 %s 应保留 name 的扩展名", input)
+	}
+	if data, err := os.ReadFile(input); err != nil || string(data) != "not really a video" {
+		t.Errorf("临时文件内容为 %q (%v)，期望与 r 相同", data, err)
+	}
+	if g.cfg.source != "clip.mp4" {
+		t.Errorf("显示的文件名为 %q，期望 clip.mp4", g.cfg.source)
+	}
+	if err := g.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+	if _, err := os.Stat(input); !os.IsNotExist(err) {
+		t.Errorf("Close 后临时文件 %s 仍然存在", input)
+	}
+}
+
+func TestNewReaderFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "movie.mkv")
+	if err := os.WriteFile(path, []byte("video"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+
+	g, err := NewReader(file, "", nil)
+	if err != nil {
+		t.Fatalf("NewReader: %v", err)
+	}
+	defer g.Close()
+	if g.cfg.input != path || g.spooled != "" {
+		t.Errorf("普通文件应直接使用而不是写入临时文件: input=%s spooled=%s", g.cfg.input, g.spooled)
+	}
+}
+
+func TestNewUnknownOption(t *testing.T) {
+	if _, err := New("movie.mp4", map[string]string{"no-such-option": "1"}); err == nil {
+		t.Error("未知参数应返回错误")
+	}
+}
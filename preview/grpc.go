@@ -0,0 +1,195 @@
+package preview
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative previewpb/preview.proto
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+
+	"video-preview-image/previewpb"
+)
+
+type grpcFlags struct {
+	listen        string
+	metricsListen string
+}
+
+func newGRPCFlagSet() (*flag.FlagSet, *grpcFlags) {
+	fs := flag.NewFlagSet("grpc", flag.ExitOnError)
+	gf := &grpcFlags{}
+	fs.StringVar(&gf.listen, "listen", ":50051", "gRPC 服务监听地址")
+	fs.StringVar(&gf.metricsListen, "metrics-listen", ":9090", "Prometheus /metrics 监听地址，为空时不启动")
+	bindToolFlags(fs)
+	bindFontFlags(fs)
+	return fs, gf
+}
+
+func runGRPC(args []string) error {
+	fs, gf := newGRPCFlagSet()
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+
+	if err := ensureExecutables(); err != nil {
+		return err
+	}
+
+	if err := serveMetrics(gf.metricsListen); err != nil {
+		return err
+	}
+
+	listener, err := net.Listen("tcp", gf.listen)
+	if err != nil {
+		return fmt.Errorf("监听 %s 失败: %w", gf.listen, err)
+	}
+
+	server := grpc.NewServer()
+	previewpb.RegisterPreviewServiceServer(server, &previewServer{})
+	reflection.Register(server)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		server.GracefulStop()
+	}()
+
+	fmt.Printf("gRPC 服务已启动: %s (按 Ctrl+C 退出)\n", listener.Addr())
+	return server.Serve(listener)
+}
+
+type previewServer struct {
+	previewpb.UnimplementedPreviewServiceServer
+}
+
+func (s *previewServer) GeneratePreview(ctx context.Context, req *previewpb.GeneratePreviewRequest) (*previewpb.GeneratePreviewResponse, error) {
+	cfg, err := jobConfig(req.GetInput(), req.GetOutput(), req.GetOptions())
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+	cfg.ctx = ctx
+	result, err := runPreview(cfg)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return previewResponse(cfg, result), nil
+}
+
+func (s *previewServer) GeneratePreviewStream(req *previewpb.GeneratePreviewRequest, stream grpc.ServerStreamingServer[previewpb.GeneratePreviewEvent]) error {
+	cfg, err := jobConfig(req.GetInput(), req.GetOutput(), req.GetOptions())
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+	// 客户端断开或超时后 ffmpeg 会随 ctx 终止。
+	cfg.ctx = stream.Context()
+	cfg.progress = func(done, total int) {
+		_ = stream.Send(&previewpb.GeneratePreviewEvent{
+			Event: &previewpb.GeneratePreviewEvent_Progress{
+				Progress: &previewpb.Progress{FramesDone: int32(done), FramesTotal: int32(total)},
+			},
+		})
+	}
+	result, err := runPreview(cfg)
+	if err != nil {
+		return status.Error(codes.Internal, err.Error())
+	}
+	return stream.Send(&previewpb.GeneratePreviewEvent{
+		Event: &previewpb.GeneratePreviewEvent_Result{Result: previewResponse(cfg, result)},
+	})
+}
+
+func (s *previewServer) ProbeVideo(ctx context.Context, req *previewpb.ProbeVideoRequest) (*previewpb.VideoMetadata, error) {
+	if req.GetInput() == "" {
+		return nil, status.Error(codes.InvalidArgument, "必须指定输入视频路径 input")
+	}
+	meta, err := probeVideo(ctx, nil, req.GetInput())
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	return newVideoMetadataMessage(newMetadataReport(meta)), nil
+}
+
+func previewResponse(cfg *gridConfig, result *previewResult) *previewpb.GeneratePreviewResponse {
+	response := &previewpb.GeneratePreviewResponse{
+		Input:      cfg.sourceName(),
+		Animation:  cfg.animOutput,
+		FramesDir:  cfg.saveFramesDir,
+		Duration:   result.duration,
+		Timestamps: result.timestamps,
+	}
+	if !cfg.framesOnly {
+		response.Output = cfg.outputName()
+	}
+	return response
+}
+
+func newVideoMetadataMessage(report VideoInfo) *previewpb.VideoMetadata {
+	message := &previewpb.VideoMetadata{
+		Format:         report.Format,
+		FormatLongName: report.FormatLongName,
+		Duration:       report.Duration,
+		Size:           report.Size,
+		BitRate:        report.BitRate,
+		Width:          int32(report.Width),
+		Height:         int32(report.Height),
+		DisplayWidth:   int32(report.DisplayWidth),
+		DisplayHeight:  int32(report.DisplayHeight),
+		Rotation:       int32(report.Rotation),
+		VideoCodec:     report.VideoCodec,
+		PixelFormat:    report.PixelFormat,
+		ColorSpace:     report.ColorSpace,
+		ColorTransfer:  report.ColorTransfer,
+		ColorPrimaries: report.ColorPrimaries,
+		ColorRange:     report.ColorRange,
+		Fps:            report.FPS,
+		VideoBitRate:   report.VideoBitRate,
+		AudioCodec:     report.AudioCodec,
+		AudioChannels:  int32(report.AudioChannels),
+		SampleRate:     int32(report.SampleRate),
+		Tags:           report.Tags,
+	}
+
+	for _, s := range report.Streams {
+		message.Streams = append(message.Streams, &previewpb.Stream{
+			Index:         int32(s.Index),
+			Type:          s.Type,
+			Codec:         s.Codec,
+			CodecLongName: s.CodecLongName,
+			Profile:       s.Profile,
+			Width:         int32(s.Width),
+			Height:        int32(s.Height),
+			PixelFormat:   s.PixelFormat,
+			Fps:           s.FPS,
+			BitRate:       s.BitRate,
+			Duration:      s.Duration,
+			Channels:      int32(s.Channels),
+			ChannelLayout: s.ChannelLayout,
+			SampleRate:    int32(s.SampleRate),
+			Rotation:      int32(s.Rotation),
+			Language:      s.Language,
+			Title:         s.Title,
+			Tags:          s.Tags,
+		})
+	}
+
+	for _, c := range report.Chapters {
+		message.Chapters = append(message.Chapters, &previewpb.Chapter{
+			Id:    c.ID,
+			Start: c.Start,
+			End:   c.End,
+			Title: c.Title,
+		})
+	}
+
+	return message
+}
@@ -0,0 +1,163 @@
+package preview
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/font"
+)
+
+// headerLines 生成拼图顶部的信息栏文字。内置字体只包含拉丁字符，因此这里使用英文字段名。
+func headerLines(cfg *gridConfig, meta *videoMetadata) []string {
+	lines := []string{"File: " + filepath.Base(cfg.sourceName())}
+	if cfg.span != nil {
+		lines = append(lines, cfg.span.headerLine())
+	}
+	if meta.checksum != nil {
+		lines = append(lines, meta.checksum.headerLine())
+	}
+
+	var info []string
+	if meta.size > 0 {
+		info = append(info, "Size: "+formatBytes(meta.size))
+	}
+	info = append(info, "Duration: "+formatTimestamp(meta.duration))
+	if meta.bitRate > 0 {
+		info = append(info, fmt.Sprintf("Bitrate: %d kb/s", meta.bitRate/1000))
+	}
+	lines = append(lines, strings.Join(info, "    "))
+
+	width, height := meta.displaySize()
+	video := fmt.Sprintf("Video: %s %dx%d", meta.videoCodec, width, height)
+	if meta.fps > 0 {
+		video += " " + strconv.FormatFloat(math.Round(meta.fps*1000)/1000, 'f', -1, 64) + " fps"
+	}
+	lines = append(lines, video)
+
+	// 每条音轨与字幕轨单独占一行，便于一张图记录容器内的全部轨道。
+	var audio, subtitle int
+	for _, stream := range meta.streams {
+		switch stream.codecType {
+		case "audio":
+			audio++
+			lines = append(lines, fmt.Sprintf("Audio #%d: %s", audio, audioTrackInfo(stream)))
+		case "subtitle":
+			subtitle++
+			lines = append(lines, fmt.Sprintf("Subtitle #%d: %s", subtitle, trackInfo(stream, stream.codecName)))
+		}
+	}
+	if audio == 0 && meta.audioCodec != "" {
+		lines = append(lines, fmt.Sprintf("Audio: %s %d ch %d Hz", meta.audioCodec, meta.audioChannels, meta.sampleRate))
+	}
+	return lines
+}
+
+func audioTrackInfo(stream streamInfo) string {
+	info := fmt.Sprintf("%s %d ch", stream.codecName, stream.channels)
+	if stream.channelLayout != "" {
+		info += " (" + stream.channelLayout + ")"
+	}
+	if stream.sampleRate > 0 {
+		info += fmt.Sprintf(" %d Hz", stream.sampleRate)
+	}
+	return trackInfo(stream, info)
+}
+
+// trackInfo 在轨道描述后附加语言与标题，语言未标注 (und) 时省略。
+func trackInfo(stream streamInfo, info string) string {
+	if stream.language != "" && stream.language != "und" {
+		info += "    [" + stream.language + "]"
+	}
+	if stream.title != "" {
+		info += "    \"" + stream.title + "\""
+	}
+	return info
+}
+
+func formatBytes(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	value := float64(size)
+	suffixes := []string{"KiB", "MiB", "GiB", "TiB"}
+	i := -1
+	for value >= unit && i < len(suffixes)-1 {
+		value /= unit
+		i++
+	}
+	return fmt.Sprintf("%.2f %s", value, suffixes[i])
+}
+
+// addHeader 在拼图上方拼接信息栏，文字颜色根据背景亮度自动选择黑色或白色。
+func addHeader(sheet image.Image, lines []string, cfg *gridConfig) (image.Image, error) {
+	return addTextStrip(sheet, lines, cfg, true)
+}
+
+// addFooter 在拼图下方拼接 --footer 文字栏，字体与排版与信息栏相同。
+func addFooter(sheet image.Image, lines []string, cfg *gridConfig) (image.Image, error) {
+	return addTextStrip(sheet, lines, cfg, false)
+}
+
+// textStripFont 返回宽度为 width 的拼图上文字栏所用的字体、字号与行高。
+func textStripFont(width int) (font.Face, float64, int, error) {
+	size := math.Max(12, float64(width)/64)
+	face, err := fontFace(size)
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	metrics := face.Metrics()
+	return face, size, int(math.Ceil(float64((metrics.Ascent + metrics.Descent).Ceil()) * 1.3)), nil
+}
+
+// textStripHeight 返回在宽度为 width 的拼图上追加 lines 行文字栏所增加的高度。
+func textStripHeight(width, lines int) (int, error) {
+	_, size, lineHeight, err := textStripFont(width)
+	if err != nil {
+		return 0, err
+	}
+	return int(size/2)*2 + lineHeight*lines, nil
+}
+
+func addTextStrip(sheet image.Image, lines []string, cfg *gridConfig, top bool) (image.Image, error) {
+	bounds := sheet.Bounds()
+	face, size, lineHeight, err := textStripFont(bounds.Dx())
+	if err != nil {
+		return nil, err
+	}
+
+	padX := max(cfg.padding.pixels(cfg.cellWidth), int(size))
+	padY := int(size / 2)
+	stripHeight := padY*2 + lineHeight*len(lines)
+
+	canvas := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()+stripHeight))
+	draw.Draw(canvas, canvas.Bounds(), &image.Uniform{C: cfg.background}, image.Point{}, draw.Src)
+	sheetTop, stripTop := stripHeight, 0
+	if !top {
+		sheetTop, stripTop = 0, bounds.Dy()
+	}
+	draw.Draw(canvas, image.Rect(0, sheetTop, bounds.Dx(), sheetTop+bounds.Dy()), sheet, bounds.Min, draw.Over)
+
+	textColor := contrastColor(cfg.background)
+	for i, line := range lines {
+		y := stripTop + padY + i*lineHeight
+		drawTextLeft(canvas, image.Rect(padX, y, bounds.Dx()-padX, y+lineHeight), line, face, textColor)
+	}
+	return canvas, nil
+}
+
+func contrastColor(background color.Color) color.Color {
+	c := color.NRGBAModel.Convert(background).(color.NRGBA)
+	luminance := 0.2126*float64(c.R) + 0.7152*float64(c.G) + 0.0722*float64(c.B)
+	// 透明背景无法判断最终底色，按浅色网页背景处理。
+	if c.A >= 128 && luminance < 128 {
+		return color.White
+	}
+	return color.NRGBA{20, 20, 20, 255}
+}
@@ -0,0 +1,85 @@
+package preview
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// headerTemplateData 为 --header-template 的模板数据：常用字段已格式化为信息栏中的写法，
+// Probe 为与 probe 子命令输出相同的完整视频信息，Audio/Subtitles 为各轨道按默认信息栏格式生成的描述。
+type headerTemplateData struct {
+	Filename        string
+	Path            string
+	Size            string
+	SizeBytes       int64
+	Duration        string
+	DurationSeconds float64
+	Bitrate         string
+	Codec           string
+	Width           int
+	Height          int
+	FPS             float64
+	Format          string
+	Checksum        string
+	Span            string
+	Audio           []string
+	Subtitles       []string
+	Probe           VideoInfo
+}
+
+func parseHeaderTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("header").Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("解析信息栏模板失败: %w", err)
+	}
+	return tmpl, nil
+}
+
+func newHeaderTemplateData(cfg *gridConfig, meta *videoMetadata) headerTemplateData {
+	width, height := meta.displaySize()
+	data := headerTemplateData{
+		Filename:        filepath.Base(cfg.sourceName()),
+		Path:            cfg.sourceName(),
+		SizeBytes:       meta.size,
+		Duration:        formatTimestamp(meta.duration),
+		DurationSeconds: meta.duration,
+		Codec:           meta.videoCodec,
+		Width:           width,
+		Height:          height,
+		FPS:             meta.fps,
+		Format:          meta.formatName,
+		Probe:           newMetadataReport(meta),
+	}
+	if meta.size > 0 {
+		data.Size = formatBytes(meta.size)
+	}
+	if meta.bitRate > 0 {
+		data.Bitrate = fmt.Sprintf("%d kb/s", meta.bitRate/1000)
+	}
+	if meta.checksum != nil {
+		data.Checksum = meta.checksum.value
+	}
+	if cfg.span != nil {
+		data.Span = cfg.span.headerLine()
+	}
+	for _, stream := range meta.streams {
+		switch stream.codecType {
+		case "audio":
+			data.Audio = append(data.Audio, audioTrackInfo(stream))
+		case "subtitle":
+			data.Subtitles = append(data.Subtitles, trackInfo(stream, stream.codecName))
+		}
+	}
+	return data
+}
+
+// templateHeaderLines 按 --header-template 生成信息栏，模板输出中的每个换行对应信息栏的一行。
+func templateHeaderLines(cfg *gridConfig, meta *videoMetadata) ([]string, error) {
+	var b strings.Builder
+	if err := cfg.headerTemplate.Execute(&b, newHeaderTemplateData(cfg, meta)); err != nil {
+		return nil, fmt.Errorf("渲染信息栏模板失败: %w", err)
+	}
+	return strings.Split(strings.TrimRight(b.String(), "\n"), "\n"), nil
+}
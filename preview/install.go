@@ -0,0 +1,331 @@
+package preview
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/signal"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+
+	"github.com/ulikunitz/xz"
+)
+
+// managedFFmpegVersion 是 install-ffmpeg 下载的 ffmpeg 版本分支，升级时同时修改 ffmpegDownloads 中的地址。
+const managedFFmpegVersion = "7.1"
+
+type ffmpegArchive struct {
+	url string
+	// sha256 为写在源码中的压缩包摘要；为空时只能通过 --sha256 校验或以 --skip-verify 跳过。
+	sha256 string
+}
+
+// btbnRelease 为 BtbN/FFmpeg-Builds 的发布地址。latest 发布会被整体替换，同一发布中的 checksums.sha256 也随之替换，
+// 不能作为校验依据；固定版本时改为带日期的 autobuild-* 标签，并在 ffmpegDownloads 中写入各压缩包下载后计算的 SHA-256。
+const btbnRelease = "https://github.com/BtbN/FFmpeg-Builds/releases/download/latest/"
+
+// ffmpegDownloads 按平台列出包含 ffmpeg 与 ffprobe 的静态构建压缩包，没有 sha256 的压缩包默认拒绝安装。
+// macOS 的 evermeet.cx 构建为 x86_64，在 Apple Silicon 上通过 Rosetta 运行。
+var ffmpegDownloads = map[string][]ffmpegArchive{
+	"linux/amd64":   {{url: btbnRelease + "ffmpeg-n7.1-latest-linux64-gpl-7.1.tar.xz"}},
+	"linux/arm64":   {{url: btbnRelease + "ffmpeg-n7.1-latest-linuxarm64-gpl-7.1.tar.xz"}},
+	"windows/amd64": {{url: btbnRelease + "ffmpeg-n7.1-latest-win64-gpl-7.1.zip"}},
+	"darwin/amd64": {
+		{url: "https://evermeet.cx/ffmpeg/ffmpeg-7.1.zip"},
+		{url: "https://evermeet.cx/ffmpeg/ffprobe-7.1.zip"},
+	},
+	"darwin/arm64": {
+		{url: "https://evermeet.cx/ffmpeg/ffmpeg-7.1.zip"},
+		{url: "https://evermeet.cx/ffmpeg/ffprobe-7.1.zip"},
+	},
+}
+
+type installFlags struct {
+	dir        string
+	url        string
+	sha256     string
+	skipVerify bool
+	force      bool
+}
+
+func newInstallFlagSet() (*flag.FlagSet, *installFlags) {
+	fs := flag.NewFlagSet("install-ffmpeg", flag.ExitOnError)
+	inf := &installFlags{}
+	fs.StringVar(&inf.dir, "dir", managedToolsDir(), "安装目录，默认目录中的 ffmpeg/ffprobe 会在 PATH 中找不到时自动使用")
+	fs.StringVar(&inf.url, "url", "", "改从该地址下载包含 ffmpeg 与 ffprobe 的 .zip 或 .tar.xz 压缩包 (例如内网镜像)")
+	fs.StringVar(&inf.sha256, "sha256", "", "压缩包的 SHA-256，指定后校验下载内容；只下载一个压缩包时可用")
+	fs.BoolVar(&inf.skipVerify, "skip-verify", false, "压缩包没有固定的 SHA-256 且未指定 --sha256 时仍然安装 (不校验下载内容)")
+	fs.BoolVar(&inf.force, "force", false, "已安装时重新下载")
+	return fs, inf
+}
+
+// managedToolsDir 返回 install-ffmpeg 的默认安装目录，目录名包含版本，升级后不会误用旧版本。
+func managedToolsDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, toolName, "ffmpeg-"+managedFFmpegVersion)
+}
+
+func executableName(name string) string {
+	if runtime.GOOS == "windows" {
+		return name + ".exe"
+	}
+	return name
+}
+
+// resolveManagedTools 在未指定 --ffmpeg/--ffprobe 且 PATH 中找不到时，改用 install-ffmpeg 安装的版本。
+func resolveManagedTools() {
+	dir := managedToolsDir()
+	if dir == "" {
+		return
+	}
+	for _, tool := range []struct {
+		path *string
+		name string
+	}{{&ffmpegPath, "ffmpeg"}, {&ffprobePath, "ffprobe"}} {
+		if *tool.path != tool.name {
+			continue
+		}
+		if _, err := exec.LookPath(tool.name); err == nil {
+			continue
+		}
+		managed := filepath.Join(dir, executableName(tool.name))
+		if info, err := os.Stat(managed); err == nil && !info.IsDir() {
+			*tool.path = managed
+		}
+	}
+}
+
+func runInstallFFmpeg(args []string) error {
+	fs, inf := newInstallFlagSet()
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	if inf.dir == "" {
+		return errors.New("无法确定默认安装目录，请通过 --dir 指定")
+	}
+
+	archives := ffmpegDownloads[runtime.GOOS+"/"+runtime.GOARCH]
+	if inf.url != "" {
+		archives = []ffmpegArchive{{url: inf.url}}
+	}
+	if len(archives) == 0 {
+		return fmt.Errorf("没有适用于 %s/%s 的预编译 ffmpeg，请通过 --url 指定压缩包地址或自行安装", runtime.GOOS, runtime.GOARCH)
+	}
+	if inf.sha256 != "" && len(archives) > 1 {
+		return errors.New("当前平台需要下载多个压缩包，--sha256 只能与 --url 一起使用")
+	}
+	// 安装后的 ffmpeg 会被所有子命令自动使用，没有校验依据时默认拒绝安装。
+	if inf.sha256 == "" && !inf.skipVerify {
+		for _, archive := range archives {
+			if archive.sha256 != "" {
+				continue
+			}
+			hint := "请通过 --sha256 指定压缩包的 SHA-256"
+			if len(archives) > 1 {
+				hint = "请通过 --url 与 --sha256 指定已校验的压缩包"
+			}
+			return fmt.Errorf("%s 没有固定的 SHA-256，%s，或确认来源可信后使用 --skip-verify 跳过校验", archive.url, hint)
+		}
+	}
+
+	ffmpegTarget := filepath.Join(inf.dir, executableName("ffmpeg"))
+	ffprobeTarget := filepath.Join(inf.dir, executableName("ffprobe"))
+	if !inf.force {
+		_, ffmpegErr := os.Stat(ffmpegTarget)
+		_, ffprobeErr := os.Stat(ffprobeTarget)
+		if ffmpegErr == nil && ffprobeErr == nil {
+			fmt.Printf("ffmpeg 已安装于 %s (使用 --force 重新下载)\n", inf.dir)
+			return nil
+		}
+	}
+	if err := os.MkdirAll(inf.dir, 0o755); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	installed := make(map[string]bool)
+	for _, archive := range archives {
+		expected := strings.ToLower(inf.sha256)
+		if expected == "" {
+			expected = archive.sha256
+		}
+		names, err := installArchive(ctx, archive.url, expected, inf.dir)
+		if err != nil {
+			return err
+		}
+		for _, name := range names {
+			installed[name] = true
+		}
+	}
+	for _, name := range []string{"ffmpeg", "ffprobe"} {
+		if !installed[name] {
+			return fmt.Errorf("压缩包中未找到 %s", executableName(name))
+		}
+	}
+
+	fmt.Printf("已安装 ffmpeg %s: %s\n", toolVersion(ffmpegTarget), inf.dir)
+	if inf.dir != managedToolsDir() {
+		fmt.Printf("非默认目录不会被自动使用，请通过 --ffmpeg %s --ffprobe %s 或 VPI_FFMPEG/VPI_FFPROBE 指定\n", ffmpegTarget, ffprobeTarget)
+	}
+	return nil
+}
+
+func httpGet(ctx context.Context, url string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", toolName+"/"+version)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%s 返回 %s", url, resp.Status)
+	}
+	return resp, nil
+}
+
+// installArchive 下载压缩包并校验，再将其中的 ffmpeg/ffprobe 解压到 dir，返回解压出的工具名称。
+func installArchive(ctx context.Context, url, expected, dir string) ([]string, error) {
+	fmt.Printf("正在下载 %s\n", url)
+	resp, err := httpGet(ctx, url)
+	if err != nil {
+		return nil, fmt.Errorf("下载 ffmpeg 失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	tmp, err := os.CreateTemp(dir, ".download-*")
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(io.MultiWriter(tmp, hash), resp.Body); err != nil {
+		return nil, fmt.Errorf("下载 ffmpeg 失败: %w", err)
+	}
+	sum := hex.EncodeToString(hash.Sum(nil))
+	if expected == "" {
+		fmt.Printf("已按 --skip-verify 跳过校验 (SHA-256: %s)\n", sum)
+	} else if sum != expected {
+		return nil, fmt.Errorf("%s 校验失败: SHA-256 为 %s，期望 %s", path.Base(url), sum, expected)
+	}
+
+	if strings.HasSuffix(url, ".zip") {
+		return extractZipTools(tmp, dir)
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	return extractTarXZTools(tmp, dir)
+}
+
+// toolEntryName 判断压缩包中的条目是否为 ffmpeg/ffprobe 可执行文件 (可位于任意子目录)，返回工具名称。
+func toolEntryName(entry string) string {
+	base := path.Base(strings.ReplaceAll(entry, "\\", "/"))
+	for _, name := range []string{"ffmpeg", "ffprobe"} {
+		if base == executableName(name) {
+			return name
+		}
+	}
+	return ""
+}
+
+func extractZipTools(file *os.File, dir string) ([]string, error) {
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	archive, err := zip.NewReader(file, info.Size())
+	if err != nil {
+		return nil, fmt.Errorf("解压失败: %w", err)
+	}
+	var names []string
+	for _, entry := range archive.File {
+		name := toolEntryName(entry.Name)
+		if name == "" || entry.FileInfo().IsDir() {
+			continue
+		}
+		r, err := entry.Open()
+		if err != nil {
+			return nil, fmt.Errorf("解压 %s 失败: %w", entry.Name, err)
+		}
+		err = writeExecutable(r, filepath.Join(dir, executableName(name)))
+		r.Close()
+		if err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+	return names, nil
+}
+
+func extractTarXZTools(r io.Reader, dir string) ([]string, error) {
+	decompressed, err := xz.NewReader(bufio.NewReader(r))
+	if err != nil {
+		return nil, fmt.Errorf("解压失败: %w", err)
+	}
+	archive := tar.NewReader(decompressed)
+	var names []string
+	for {
+		header, err := archive.Next()
+		if errors.Is(err, io.EOF) {
+			return names, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("解压失败: %w", err)
+		}
+		name := toolEntryName(header.Name)
+		if name == "" || header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if err := writeExecutable(archive, filepath.Join(dir, executableName(name))); err != nil {
+			return nil, err
+		}
+		names = append(names, name)
+	}
+}
+
+// writeExecutable 先写临时文件再重命名，正在运行的任务不会读到写了一半的可执行文件。
+func writeExecutable(r io.Reader, target string) error {
+	tmp, err := os.CreateTemp(filepath.Dir(target), ".tool-*")
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Chmod(tmp.Name(), 0o755)
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), target)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("写入 %s 失败: %w", target, err)
+	}
+	return nil
+}
@@ -0,0 +1,24 @@
+package preview
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestFFmpegDownloadsPinned(t *testing.T) {
+	for platform, archives := range ffmpegDownloads {
+		for _, archive := range archives {
+			if archive.sha256 == "" {
+				continue
+			}
+			// 摘要只对固定的发布有意义，latest 发布会被替换。
+			if strings.Contains(archive.url, "/latest/") {
+				t.Errorf("%s: %s 指向 latest 发布，不能固定 SHA-256", platform, archive.url)
+			}
+			if sum, err := hex.DecodeString(archive.sha256); err != nil || len(sum) != 32 || archive.sha256 != strings.ToLower(archive.sha256) {
+				t.Errorf("%s: %s 的 SHA-256 应为 64 位小写十六进制: %q", platform, archive.url, archive.sha256)
+			}
+		}
+	}
+}
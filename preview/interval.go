@@ -0,0 +1,44 @@
+package preview
+
+import (
+	"fmt"
+	"math"
+)
+
+// intervalCount 返回长度为 length 秒的范围内每隔 interval 秒取一帧时的帧数，第一帧位于范围起点。
+func intervalCount(length, interval float64) int {
+	return max(int(math.Ceil(length/interval-1e-9)), 1)
+}
+
+func intervalTimestamps(start, length, interval float64) []float64 {
+	timestamps := make([]float64, intervalCount(length, interval))
+	for i := range timestamps {
+		timestamps[i] = start + interval*float64(i)
+	}
+	return timestamps
+}
+
+// intervalPageSize 返回 --interval 时每张拼图容纳的截图数：自动行数时为不超过 --max-cells 的整行数，
+// 否则为布局的截图位置数。
+func (cfg *gridConfig) intervalPageSize() (int, error) {
+	if cfg.autoRows {
+		return max(cfg.maxCells/cfg.cols, 1) * cfg.cols, nil
+	}
+	layout, err := cfg.sheetLayout()
+	if err != nil {
+		return 0, err
+	}
+	return layout.frameCount(), nil
+}
+
+// intervalPages 在截图数超过单张拼图容量时按页拆分时间范围，每页恰好包含 pageSize 个采样点 (最后一页可能更少)。
+func intervalPages(duration, interval float64, pageSize int) []timeSpan {
+	if intervalCount(duration, interval) <= pageSize {
+		return nil
+	}
+	spans := segmentSpans(duration, interval*float64(pageSize))
+	for i := range spans {
+		spans[i].label = fmt.Sprintf("Page %d/%d", i+1, len(spans))
+	}
+	return spans
+}
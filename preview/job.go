@@ -0,0 +1,68 @@
+package preview
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+)
+
+// remoteOptions 为 gRPC、HTTP、JSON-RPC 与队列任务的 options 可以指定的参数。只开放影响拼图内容的参数，
+// 会读写服务端的任意路径 (save-frames、layout-file、lut 等)、就地修改输入视频、向任意地址发送请求或在服务端执行
+// 调用方提供的模板 (header-template 的嵌套 range 没有执行时间上限) 的参数一律拒绝，新增的参数默认不开放。
+var remoteOptions = []string{
+	"preset", "rows", "cols", "cell-width", "cell-height", "max-width", "max-height", "layout", "style",
+	"margin", "gap-x", "gap-y", "padding", "quality", "format", "max-bytes", "dpi", "background", "seed", "clip-duration",
+	"frame-numbers", "percentages", "at", "interval", "max-cells", "avoid-freeze", "snap-to-keyframe", "keyframes-only",
+	"stream", "deinterlace", "sample", "selector", "pipe-codec", "retries", "retry-delay", "prescale", "backend",
+	"tiff-compression", "jpeg-progressive", "jpeg-subsampling", "png-compression", "png-colors", "png-dither",
+	"embed-metadata", "deterministic", "header", "footer", "loudness", "waveform", "bitrate-graph",
+	"timestamps", "number-cells", "smart-labels", "text-color", "text-outline", "text-outline-color", "timestamp-format",
+	"checksum", "no-cache", "s3-region", "download-input",
+	"auto-levels", "rotate", "flip", "crop", "denoise", "deband", "color-management",
+}
+
+// remoteSheetOptions 按章节或时长输出多张拼图 (文件名在 output 后追加序号)，HTTP 响应只能返回一张图片，
+// 只对 gRPC、JSON-RPC 与队列任务开放。
+var remoteSheetOptions = []string{"segment", "sheet-per-chapter"}
+
+// jobConfig 按命令行参数的规则解析 gRPC 请求或队列任务中的 options，未指定的参数再依次取 VPI_* 环境变量、预设与默认值。
+func jobConfig(input, output string, options map[string]string) (*gridConfig, error) {
+	if input == "" {
+		return nil, errors.New("必须指定输入视频路径 input")
+	}
+	if output == "" || output == "-" {
+		return nil, errors.New("必须指定输出路径 output")
+	}
+
+	cfg, err := optionsConfig(options, slices.Concat(remoteOptions, remoteSheetOptions))
+	if err != nil {
+		return nil, err
+	}
+	cfg.input = input
+	cfg.output = output
+	return cfg, nil
+}
+
+// optionsConfig 按命令行参数的规则解析 options (键为不含前导 - 的参数名)；allowed 不为 nil 时只允许其中的参数。
+func optionsConfig(options map[string]string, allowed []string) (*gridConfig, error) {
+	fs := flag.NewFlagSet("job", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	gf := bindGridFlags(fs)
+	for name, value := range options {
+		if fs.Lookup(name) == nil {
+			return nil, fmt.Errorf("未知参数: %s", name)
+		}
+		if allowed != nil && !slices.Contains(allowed, name) {
+			return nil, fmt.Errorf("参数 %s 不能通过请求指定", name)
+		}
+		if err := fs.Set(name, value); err != nil {
+			return nil, fmt.Errorf("参数 %s 的值无效: %w", name, err)
+		}
+	}
+	if err := parseArgs(fs, nil); err != nil {
+		return nil, err
+	}
+	return gf.config()
+}
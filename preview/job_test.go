@@ -0,0 +1,62 @@
+package preview
+
+import (
+	"flag"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// pathOptions 为读写服务端路径、修改输入视频、向任意地址发送请求或执行调用方模板的参数，远程任务一律不能指定。
+var pathOptions = []string{
+	"save-frames", "anim-output", "cache-dir", "layout-file", "artwork", "trickplay", "variants", "sidecar",
+	"lut", "mediainfo", "embed-cover", "webhook", "s3-endpoint", "publish", "publish-url",
+	"header-template",
+}
+
+func TestJobConfigRejectsPathOptions(t *testing.T) {
+	fs := flag.NewFlagSet("job", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	bindGridFlags(fs)
+	allowed := slices.Concat(remoteOptions, remoteSheetOptions)
+	for _, name := range pathOptions {
+		if fs.Lookup(name) == nil {
+			t.Errorf("参数 %s 已不存在，请更新 pathOptions", name)
+		}
+		if slices.Contains(allowed, name) {
+			t.Errorf("参数 %s 会读写服务端路径，不应出现在远程任务可指定的参数中", name)
+		}
+	}
+
+	// 不在允许列表中的参数 (包括以后新增的参数) 都应被拒绝，且错误中不回显取值。
+	fs.VisitAll(func(f *flag.Flag) {
+		if slices.Contains(allowed, f.Name) {
+			return
+		}
+		_, err := jobConfig("in.mp4", "out.png", map[string]string{f.Name: "/etc/shadow"})
+		if err == nil || !strings.Contains(err.Error(), "不能通过请求指定") {
+			t.Errorf("参数 %s 应被拒绝，实际错误为 %v", f.Name, err)
+		} else if strings.Contains(err.Error(), "/etc/shadow") {
+			t.Errorf("参数 %s 的错误 %q 不应包含取值", f.Name, err)
+		}
+	})
+}
+
+func TestJobConfigAllowedOptions(t *testing.T) {
+	fs := flag.NewFlagSet("job", flag.ContinueOnError)
+	bindGridFlags(fs)
+	for _, name := range slices.Concat(remoteOptions, remoteSheetOptions) {
+		if fs.Lookup(name) == nil {
+			t.Errorf("允许的参数 %s 不存在", name)
+		}
+	}
+
+	cfg, err := jobConfig("in.mp4", "out.png", map[string]string{"rows": "2", "segment": "10m"})
+	if err != nil {
+		t.Fatalf("jobConfig: %v", err)
+	}
+	if cfg.rows != 2 || cfg.input != "in.mp4" || cfg.output != "out.png" {
+		t.Errorf("解析结果为 rows=%d input=%s output=%s", cfg.rows, cfg.input, cfg.output)
+	}
+}
@@ -0,0 +1,443 @@
+package preview
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+	"math"
+)
+
+type jpegOptions struct {
+	quality     int
+	progressive bool
+	subsampling string
+}
+
+type jpegComponent struct {
+	id      byte
+	h, v    int
+	table   int
+	blocksW int
+	blocksH int
+	scanW   int
+	scanH   int
+	blocks  [][64]int32
+}
+
+type huffmanCode struct {
+	code   uint32
+	length uint
+}
+
+// 标准量化表 (zig-zag 顺序)，与 image/jpeg 相同。
+var jpegQuantTables = [2][64]byte{
+	{
+		16, 11, 12, 14, 12, 10, 16, 14,
+		13, 14, 18, 17, 16, 19, 24, 40,
+		26, 24, 22, 22, 24, 49, 35, 37,
+		29, 40, 58, 51, 61, 60, 57, 51,
+		56, 55, 64, 72, 92, 78, 64, 68,
+		87, 69, 55, 56, 80, 109, 81, 87,
+		95, 98, 103, 104, 103, 62, 77, 113,
+		121, 112, 100, 120, 92, 101, 103, 99,
+	},
+	{
+		17, 18, 18, 24, 21, 24, 47, 26,
+		26, 47, 99, 66, 56, 66, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+		99, 99, 99, 99, 99, 99, 99, 99,
+	},
+}
+
+var jpegUnzig = [64]int{
+	0, 1, 8, 16, 9, 2, 3, 10,
+	17, 24, 32, 25, 18, 11, 4, 5,
+	12, 19, 26, 33, 40, 48, 41, 34,
+	27, 20, 13, 6, 7, 14, 21, 28,
+	35, 42, 49, 56, 57, 50, 43, 36,
+	29, 22, 15, 23, 30, 37, 44, 51,
+	58, 59, 52, 45, 38, 31, 39, 46,
+	53, 60, 61, 54, 47, 55, 62, 63,
+}
+
+// JPEG 标准附录 K 中的 Huffman 表: 亮度 DC、亮度 AC、色度 DC、色度 AC。
+var jpegHuffmanSpecs = [4]struct {
+	counts [16]byte
+	values []byte
+}{
+	{
+		[16]byte{0, 1, 5, 1, 1, 1, 1, 1, 1, 0, 0, 0, 0, 0, 0, 0},
+		[]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11},
+	},
+	{
+		[16]byte{0, 2, 1, 3, 3, 2, 4, 3, 5, 5, 4, 4, 0, 0, 1, 125},
+		[]byte{
+			0x01, 0x02, 0x03, 0x00, 0x04, 0x11, 0x05, 0x12,
+			0x21, 0x31, 0x41, 0x06, 0x13, 0x51, 0x61, 0x07,
+			0x22, 0x71, 0x14, 0x32, 0x81, 0x91, 0xa1, 0x08,
+			0x23, 0x42, 0xb1, 0xc1, 0x15, 0x52, 0xd1, 0xf0,
+			0x24, 0x33, 0x62, 0x72, 0x82, 0x09, 0x0a, 0x16,
+			0x17, 0x18, 0x19, 0x1a, 0x25, 0x26, 0x27, 0x28,
+			0x29, 0x2a, 0x34, 0x35, 0x36, 0x37, 0x38, 0x39,
+			0x3a, 0x43, 0x44, 0x45, 0x46, 0x47, 0x48, 0x49,
+			0x4a, 0x53, 0x54, 0x55, 0x56, 0x57, 0x58, 0x59,
+			0x5a, 0x63, 0x64, 0x65, 0x66, 0x67, 0x68, 0x69,
+			0x6a, 0x73, 0x74, 0x75, 0x76, 0x77, 0x78, 0x79,
+			0x7a, 0x83, 0x84, 0x85, 0x86, 0x87, 0x88, 0x89,
+			0x8a, 0x92, 0x93, 0x94, 0x95, 0x96, 0x97, 0x98,
+			0x99, 0x9a, 0xa2, 0xa3, 0xa4, 0xa5, 0xa6, 0xa7,
+			0xa8, 0xa9, 0xaa, 0xb2, 0xb3, 0xb4, 0xb5, 0xb6,
+			0xb7, 0xb8, 0xb9, 0xba, 0xc2, 0xc3, 0xc4, 0xc5,
+			0xc6, 0xc7, 0xc8, 0xc9, 0xca, 0xd2, 0xd3, 0xd4,
+			0xd5, 0xd6, 0xd7, 0xd8, 0xd9, 0xda, 0xe1, 0xe2,
+			0xe3, 0xe4, 0xe5, 0xe6, 0xe7, 0xe8, 0xe9, 0xea,
+			0xf1, 0xf2, 0xf3, 0xf4, 0xf5, 0xf6, 0xf7, 0xf8,
+			0xf9, 0xfa,
+		},
+	},
+	{
+		[16]byte{0, 3, 1, 1, 1, 1, 1, 1, 1, 1, 1, 0, 0, 0, 0, 0},
+		[]byte{0, 1, 2, 3, 4, 5, 6, 7, 8, 9, 10, 11},
+	},
+	{
+		[16]byte{0, 2, 1, 2, 4, 4, 3, 4, 7, 5, 4, 4, 0, 1, 2, 119},
+		[]byte{
+			0x00, 0x01, 0x02, 0x03, 0x11, 0x04, 0x05, 0x21,
+			0x31, 0x06, 0x12, 0x41, 0x51, 0x07, 0x61, 0x71,
+			0x13, 0x22, 0x32, 0x81, 0x08, 0x14, 0x42, 0x91,
+			0xa1, 0xb1, 0xc1, 0x09, 0x23, 0x33, 0x52, 0xf0,
+			0x15, 0x62, 0x72, 0xd1, 0x0a, 0x16, 0x24, 0x34,
+			0xe1, 0x25, 0xf1, 0x17, 0x18, 0x19, 0x1a, 0x26,
+			0x27, 0x28, 0x29, 0x2a, 0x35, 0x36, 0x37, 0x38,
+			0x39, 0x3a, 0x43, 0x44, 0x45, 0x46, 0x47, 0x48,
+			0x49, 0x4a, 0x53, 0x54, 0x55, 0x56, 0x57, 0x58,
+			0x59, 0x5a, 0x63, 0x64, 0x65, 0x66, 0x67, 0x68,
+			0x69, 0x6a, 0x73, 0x74, 0x75, 0x76, 0x77, 0x78,
+			0x79, 0x7a, 0x82, 0x83, 0x84, 0x85, 0x86, 0x87,
+			0x88, 0x89, 0x8a, 0x92, 0x93, 0x94, 0x95, 0x96,
+			0x97, 0x98, 0x99, 0x9a, 0xa2, 0xa3, 0xa4, 0xa5,
+			0xa6, 0xa7, 0xa8, 0xa9, 0xaa, 0xb2, 0xb3, 0xb4,
+			0xb5, 0xb6, 0xb7, 0xb8, 0xb9, 0xba, 0xc2, 0xc3,
+			0xc4, 0xc5, 0xc6, 0xc7, 0xc8, 0xc9, 0xca, 0xd2,
+			0xd3, 0xd4, 0xd5, 0xd6, 0xd7, 0xd8, 0xd9, 0xda,
+			0xe2, 0xe3, 0xe4, 0xe5, 0xe6, 0xe7, 0xe8, 0xe9,
+			0xea, 0xf2, 0xf3, 0xf4, 0xf5, 0xf6, 0xf7, 0xf8,
+			0xf9, 0xfa,
+		},
+	},
+}
+
+var dctCosines = func() (c [8][8]float64) {
+	for u := 0; u < 8; u++ {
+		scale := 0.5
+		if u == 0 {
+			scale = 0.5 / math.Sqrt2
+		}
+		for x := 0; x < 8; x++ {
+			c[u][x] = scale * math.Cos(float64(2*x+1)*float64(u)*math.Pi/16)
+		}
+	}
+	return c
+}()
+
+// encodeJPEG 是 image/jpeg 之外的编码器，用于标准库不支持的 4:4:4 色度采样与渐进式 (频谱选择) 输出。
+func encodeJPEG(w io.Writer, img image.Image, opts jpegOptions) error {
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	if width <= 0 || height <= 0 || width > 65535 || height > 65535 {
+		return fmt.Errorf("JPEG 尺寸超出范围: %dx%d", width, height)
+	}
+
+	rgba := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(rgba, rgba.Bounds(), img, bounds.Min, draw.Src)
+
+	hmax, vmax := 2, 2
+	switch opts.subsampling {
+	case "444":
+		hmax, vmax = 1, 1
+	case "420", "":
+	default:
+		return fmt.Errorf("不支持的色度采样: %s", opts.subsampling)
+	}
+
+	quant := scaledQuantTables(opts.quality)
+	mcusX := (width + 8*hmax - 1) / (8 * hmax)
+	mcusY := (height + 8*vmax - 1) / (8 * vmax)
+
+	components := []*jpegComponent{
+		{id: 1, h: hmax, v: vmax, table: 0},
+		{id: 2, h: 1, v: 1, table: 1},
+		{id: 3, h: 1, v: 1, table: 1},
+	}
+	for ci, comp := range components {
+		comp.blocksW = mcusX * comp.h
+		comp.blocksH = mcusY * comp.v
+		comp.scanW = ((width*comp.h+hmax-1)/hmax + 7) / 8
+		comp.scanH = ((height*comp.v+vmax-1)/vmax + 7) / 8
+		comp.blocks = make([][64]int32, comp.blocksW*comp.blocksH)
+
+		sx, sy := hmax/comp.h, vmax/comp.v
+		var samples [64]float64
+		for by := 0; by < comp.blocksH; by++ {
+			for bx := 0; bx < comp.blocksW; bx++ {
+				for y := 0; y < 8; y++ {
+					for x := 0; x < 8; x++ {
+						samples[y*8+x] = componentSample(rgba, ci, (bx*8+x)*sx, (by*8+y)*sy, sx, sy) - 128
+					}
+				}
+				quantizeBlock(&comp.blocks[by*comp.blocksW+bx], &samples, &quant[comp.table])
+			}
+		}
+	}
+
+	var huffCodes [4][256]huffmanCode
+	for i := range jpegHuffmanSpecs {
+		huffCodes[i] = buildHuffmanCodes(jpegHuffmanSpecs[i].counts, jpegHuffmanSpecs[i].values)
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.Write([]byte{0xFF, 0xD8})
+	writeJFIFHeader(bw)
+
+	for i, table := range quant {
+		bw.Write([]byte{0xFF, 0xDB, 0x00, 67, byte(i)})
+		for _, q := range table {
+			bw.WriteByte(byte(q))
+		}
+	}
+
+	sof := byte(0xC0)
+	if opts.progressive {
+		sof = 0xC2
+	}
+	bw.Write([]byte{0xFF, sof, 0x00, 17, 8, byte(height >> 8), byte(height), byte(width >> 8), byte(width), 3})
+	for _, comp := range components {
+		bw.Write([]byte{comp.id, byte(comp.h<<4 | comp.v), byte(comp.table)})
+	}
+
+	for i, spec := range jpegHuffmanSpecs {
+		length := 2 + 1 + 16 + len(spec.values)
+		class := byte(i % 2)
+		bw.Write([]byte{0xFF, 0xC4, byte(length >> 8), byte(length), class<<4 | byte(i/2)})
+		bw.Write(spec.counts[:])
+		bw.Write(spec.values)
+	}
+
+	bits := &jpegBitWriter{w: bw}
+	if opts.progressive {
+		writeScanHeader(bw, components, 0, 0)
+		encodeInterleaved(bits, components, mcusX, mcusY, &huffCodes, false)
+		for _, comp := range components {
+			writeScanHeader(bw, []*jpegComponent{comp}, 1, 63)
+			encodeACScan(bits, comp, &huffCodes)
+		}
+	} else {
+		writeScanHeader(bw, components, 0, 63)
+		encodeInterleaved(bits, components, mcusX, mcusY, &huffCodes, true)
+	}
+
+	bw.Write([]byte{0xFF, 0xD9})
+	return bw.Flush()
+}
+
+func scaledQuantTables(quality int) [2][64]int32 {
+	if quality < 1 {
+		quality = 1
+	} else if quality > 100 {
+		quality = 100
+	}
+	scale := 200 - quality*2
+	if quality < 50 {
+		scale = 5000 / quality
+	}
+
+	var tables [2][64]int32
+	for t := range jpegQuantTables {
+		for i, base := range jpegQuantTables[t] {
+			q := (int32(base)*int32(scale) + 50) / 100
+			tables[t][i] = min(max(q, 1), 255)
+		}
+	}
+	return tables
+}
+
+// componentSample 返回分量平面上 (x, y) 处的采样值，色度分量按 sx*sy 区域取平均，超出画面边缘的部分复制边缘像素。
+func componentSample(img *image.RGBA, component, x, y, sx, sy int) float64 {
+	bounds := img.Bounds()
+	var sum float64
+	for dy := 0; dy < sy; dy++ {
+		py := min(y+dy, bounds.Dy()-1)
+		for dx := 0; dx < sx; dx++ {
+			px := min(x+dx, bounds.Dx()-1)
+			offset := py*img.Stride + px*4
+			r := float64(img.Pix[offset])
+			g := float64(img.Pix[offset+1])
+			b := float64(img.Pix[offset+2])
+			switch component {
+			case 0:
+				sum += 0.299*r + 0.587*g + 0.114*b
+			case 1:
+				sum += -0.168736*r - 0.331264*g + 0.5*b + 128
+			default:
+				sum += 0.5*r - 0.418688*g - 0.081312*b + 128
+			}
+		}
+	}
+	return sum / float64(sx*sy)
+}
+
+func quantizeBlock(dst *[64]int32, samples *[64]float64, quant *[64]int32) {
+	var rows [64]float64
+	for y := 0; y < 8; y++ {
+		for u := 0; u < 8; u++ {
+			var sum float64
+			for x := 0; x < 8; x++ {
+				sum += dctCosines[u][x] * samples[y*8+x]
+			}
+			rows[y*8+u] = sum
+		}
+	}
+	for k := 0; k < 64; k++ {
+		natural := jpegUnzig[k]
+		u, v := natural%8, natural/8
+		var sum float64
+		for y := 0; y < 8; y++ {
+			sum += dctCosines[v][y] * rows[y*8+u]
+		}
+		dst[k] = int32(math.Round(sum / float64(quant[k])))
+	}
+}
+
+func buildHuffmanCodes(counts [16]byte, values []byte) [256]huffmanCode {
+	var codes [256]huffmanCode
+	code := uint32(0)
+	k := 0
+	for length := 1; length <= 16; length++ {
+		for i := 0; i < int(counts[length-1]); i++ {
+			codes[values[k]] = huffmanCode{code: code, length: uint(length)}
+			code++
+			k++
+		}
+		code <<= 1
+	}
+	return codes
+}
+
+func writeJFIFHeader(w *bufio.Writer) {
+	w.Write([]byte{0xFF, 0xE0, 0x00, 16, 'J', 'F', 'I', 'F', 0, 1, 1, 0, 0, 1, 0, 1, 0, 0})
+}
+
+func writeScanHeader(w *bufio.Writer, components []*jpegComponent, start, end byte) {
+	length := 6 + 2*len(components)
+	w.Write([]byte{0xFF, 0xDA, byte(length >> 8), byte(length), byte(len(components))})
+	for _, comp := range components {
+		dc := byte(comp.table)
+		w.Write([]byte{comp.id, dc<<4 | dc})
+	}
+	w.Write([]byte{start, end, 0})
+}
+
+func encodeInterleaved(bits *jpegBitWriter, components []*jpegComponent, mcusX, mcusY int, codes *[4][256]huffmanCode, withAC bool) {
+	predictors := make([]int32, len(components))
+	for my := 0; my < mcusY; my++ {
+		for mx := 0; mx < mcusX; mx++ {
+			for ci, comp := range components {
+				for v := 0; v < comp.v; v++ {
+					for h := 0; h < comp.h; h++ {
+						block := &comp.blocks[(my*comp.v+v)*comp.blocksW+mx*comp.h+h]
+						diff := block[0] - predictors[ci]
+						predictors[ci] = block[0]
+						bits.emitValue(&codes[comp.table*2], 0, diff)
+						if withAC {
+							encodeAC(bits, block, &codes[comp.table*2+1])
+						}
+					}
+				}
+			}
+		}
+	}
+	bits.flush()
+}
+
+// 非交错扫描只覆盖分量自身尺寸对应的块，不包含 MCU 对齐时补出的块。
+func encodeACScan(bits *jpegBitWriter, comp *jpegComponent, codes *[4][256]huffmanCode) {
+	for by := 0; by < comp.scanH; by++ {
+		for bx := 0; bx < comp.scanW; bx++ {
+			encodeAC(bits, &comp.blocks[by*comp.blocksW+bx], &codes[comp.table*2+1])
+		}
+	}
+	bits.flush()
+}
+
+func encodeAC(bits *jpegBitWriter, block *[64]int32, codes *[256]huffmanCode) {
+	run := 0
+	for k := 1; k < 64; k++ {
+		if block[k] == 0 {
+			run++
+			continue
+		}
+		for run > 15 {
+			bits.emitCode(codes[0xF0])
+			run -= 16
+		}
+		bits.emitValue(codes, run, block[k])
+		run = 0
+	}
+	if run > 0 {
+		bits.emitCode(codes[0x00])
+	}
+}
+
+type jpegBitWriter struct {
+	w    *bufio.Writer
+	bits uint32
+	n    uint
+}
+
+func (b *jpegBitWriter) emit(value uint32, length uint) {
+	b.bits |= (value & (1<<length - 1)) << (32 - b.n - length)
+	b.n += length
+	for b.n >= 8 {
+		c := byte(b.bits >> 24)
+		b.w.WriteByte(c)
+		if c == 0xFF {
+			b.w.WriteByte(0x00)
+		}
+		b.bits <<= 8
+		b.n -= 8
+	}
+}
+
+func (b *jpegBitWriter) emitCode(code huffmanCode) {
+	b.emit(code.code, code.length)
+}
+
+// emitValue 写出 (游程, 幅值类别) 对应的 Huffman 码以及幅值的附加位。
+func (b *jpegBitWriter) emitValue(codes *[256]huffmanCode, run int, value int32) {
+	magnitude, extra := value, value
+	if magnitude < 0 {
+		magnitude = -magnitude
+		extra--
+	}
+	size := uint(0)
+	for magnitude > 0 {
+		size++
+		magnitude >>= 1
+	}
+	b.emitCode(codes[byte(run<<4)|byte(size)])
+	if size > 0 {
+		b.emit(uint32(extra), size)
+	}
+}
+
+func (b *jpegBitWriter) flush() {
+	if b.n > 0 {
+		b.emit(0x7F, 8-b.n)
+	}
+}
@@ -0,0 +1,97 @@
+package preview
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math"
+	"testing"
+)
+
+// testPattern 返回三个通道方向不同的渐变图像，用于编码器的往返测试。
+func testPattern(width, height int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.Set(x, y, color.RGBA{
+				R: uint8(255 * x / max(width-1, 1)),
+				G: uint8(255 * y / max(height-1, 1)),
+				B: uint8(255 * (x + y) / max(width+height-2, 1)),
+				A: 255,
+			})
+		}
+	}
+	return img
+}
+
+// psnr 返回 a 与 b 的 RGB 峰值信噪比 (dB)，两者完全相同时返回 +Inf。
+func psnr(t *testing.T, a, b image.Image) float64 {
+	t.Helper()
+	if a.Bounds().Size() != b.Bounds().Size() {
+		t.Fatalf("尺寸不同: %v 与 %v", a.Bounds().Size(), b.Bounds().Size())
+	}
+	var sum float64
+	size := a.Bounds().Size()
+	for y := range size.Y {
+		for x := range size.X {
+			r1, g1, b1, _ := a.At(a.Bounds().Min.X+x, a.Bounds().Min.Y+y).RGBA()
+			r2, g2, b2, _ := b.At(b.Bounds().Min.X+x, b.Bounds().Min.Y+y).RGBA()
+			for _, d := range []float64{float64(r1>>8) - float64(r2>>8), float64(g1>>8) - float64(g2>>8), float64(b1>>8) - float64(b2>>8)} {
+				sum += d * d
+			}
+		}
+	}
+	mse := sum / float64(size.X*size.Y*3)
+	if mse == 0 {
+		return math.Inf(1)
+	}
+	return 10 * math.Log10(255*255/mse)
+}
+
+func TestEncodeJPEGRoundTrip(t *testing.T) {
+	for _, size := range []image.Point{{1, 1}, {17, 9}, {64, 48}, {333, 17}} {
+		for _, opts := range []jpegOptions{
+			{quality: 90, subsampling: "444"},
+			{quality: 90, progressive: true, subsampling: "420"},
+			{quality: 90, progressive: true, subsampling: "444"},
+		} {
+			src := testPattern(size.X, size.Y)
+			var buf bytes.Buffer
+			if err := encodeJPEG(&buf, src, opts); err != nil {
+				t.Fatalf("%v %+v: encodeJPEG: %v", size, opts, err)
+			}
+			// 渐进式 JPEG 使用 SOF2，基线使用 SOF0。
+			sof := []byte{0xFF, 0xC0}
+			if opts.progressive {
+				sof = []byte{0xFF, 0xC2}
+			}
+			if !bytes.Contains(buf.Bytes(), sof) {
+				t.Errorf("%v %+v: 输出中没有 %X 帧头", size, opts, sof)
+			}
+			decoded, err := jpeg.Decode(&buf)
+			if err != nil {
+				t.Fatalf("%v %+v: image/jpeg 无法解码: %v", size, opts, err)
+			}
+			// 质量不应明显低于同样质量下标准库的基线 (4:2:0) 编码。
+			var baseline bytes.Buffer
+			if err := jpeg.Encode(&baseline, src, &jpeg.Options{Quality: opts.quality}); err != nil {
+				t.Fatal(err)
+			}
+			reference, err := jpeg.Decode(&baseline)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, want := psnr(t, src, decoded), min(psnr(t, src, reference)-1, 35)
+			if got < want {
+				t.Errorf("%v %+v: PSNR 为 %.1f dB，期望至少 %.1f dB", size, opts, got, want)
+			}
+		}
+	}
+}
+
+func TestEncodeJPEGInvalidSubsampling(t *testing.T) {
+	if err := encodeJPEG(&bytes.Buffer{}, testPattern(8, 8), jpegOptions{quality: 90, subsampling: "422"}); err == nil {
+		t.Error("不支持的色度采样应返回错误")
+	}
+}
@@ -0,0 +1,72 @@
+package preview
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"slices"
+	"strconv"
+)
+
+// probeKeyframes 用 ffprobe 只解码关键帧 (-skip_frame nokey)，返回采样范围内各关键帧的时间。
+// 时间换算为相对容器起点，与 ffmpeg -ss 定位所用的时间一致。
+func probeKeyframes(ctx context.Context, cfg *gridConfig, start, length float64) ([]float64, error) {
+	cmd := newCommand(ctx, cfg.runner, ffprobePath,
+		"-v", "error",
+		"-select_streams", strconv.Itoa(cfg.streamIndex),
+		"-skip_frame", "nokey",
+		"-read_intervals", fmt.Sprintf("%.3f%%+%.3f", start, length),
+		"-show_entries", "format=start_time:frame=best_effort_timestamp_time",
+		"-of", "json",
+		toolPath(cfg.input),
+	)
+	var stderr stderrTail
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		observeToolFailure("probe")
+		return nil, fmt.Errorf("读取关键帧时间失败: %w", commandError(err, stderr.Bytes()))
+	}
+
+	var report struct {
+		Frames []struct {
+			Time string `json:"best_effort_timestamp_time"`
+		} `json:"frames"`
+		Format struct {
+			StartTime string `json:"start_time"`
+		} `json:"format"`
+	}
+	if err := json.Unmarshal(output, &report); err != nil {
+		return nil, fmt.Errorf("解析关键帧时间失败: %w", err)
+	}
+	offset := parseFloat(report.Format.StartTime)
+	var keyframes []float64
+	for _, frame := range report.Frames {
+		if t, err := strconv.ParseFloat(frame.Time, 64); err == nil {
+			keyframes = append(keyframes, max(t-offset, 0))
+		}
+	}
+	slices.Sort(keyframes)
+	return keyframes, nil
+}
+
+// snapToKeyframes 把每个采样点移到距离最近的关键帧，截图标注使用关键帧的实际时间。
+// 定位时间按毫秒向下取整，精确定位解码到其后的第一帧，即该关键帧本身。
+func snapToKeyframes(timestamps, keyframes []float64) []float64 {
+	if len(keyframes) == 0 {
+		return timestamps
+	}
+	snapped := make([]float64, len(timestamps))
+	for i, t := range timestamps {
+		j, _ := slices.BinarySearch(keyframes, t)
+		switch {
+		case j == len(keyframes):
+			j--
+		case j > 0 && t-keyframes[j-1] <= keyframes[j]-t:
+			j--
+		}
+		snapped[i] = math.Floor(keyframes[j]*1000) / 1000
+	}
+	return snapped
+}
@@ -0,0 +1,66 @@
+package preview
+
+import (
+	"image"
+	"math"
+)
+
+const (
+	// labelTargetLuma 为 --smart-labels 加深底色后文字背后画面的目标亮度上限 (0-1)。
+	labelTargetLuma = 0.25
+	maxPlateAlpha   = 230
+	// cornerMargin 为改用其他角落所需的最小得分差，避免画面相近时标注位置在各格间跳动。
+	cornerMargin = 0.1
+)
+
+// badgeCorner 为单格内角标的位置。
+type badgeCorner struct {
+	top, left bool
+}
+
+// regionLuma 返回 r 内画面亮度 (0-1) 的均值与标准差，标准差反映画面的繁杂程度。
+func regionLuma(img image.Image, r image.Rectangle) (float64, float64) {
+	r = r.Intersect(img.Bounds())
+	if r.Empty() {
+		return 0, 0
+	}
+	var sum, sumSq float64
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			cr, cg, cb, _ := img.At(x, y).RGBA()
+			l := (0.299*float64(cr) + 0.587*float64(cg) + 0.114*float64(cb)) / 0xffff
+			sum += l
+			sumSq += l * l
+		}
+	}
+	n := float64(r.Dx() * r.Dy())
+	mean := sum / n
+	return mean, math.Sqrt(math.Max(sumSq/n-mean*mean, 0))
+}
+
+// plateAlpha 返回底色的不透明度：画面越亮、越繁杂越不透明，使白色文字背后的亮度不超过 labelTargetLuma；
+// 暗而平坦的画面保持 base。
+func plateAlpha(img image.Image, r image.Rectangle, base uint8) uint8 {
+	mean, stddev := regionLuma(img, r)
+	if bright := mean + stddev; bright > labelTargetLuma {
+		need := (1 - labelTargetLuma/bright) * 255
+		return uint8(math.Min(math.Max(need, float64(base)), maxPlateAlpha))
+	}
+	return base
+}
+
+// pickCorner 返回 corners 中角标区域最暗、最平坦的一个；其他角落的得分需比第一个 (默认位置) 低 cornerMargin 以上才会改用。
+func pickCorner(img image.Image, corners []badgeCorner, rectFor func(badgeCorner) image.Rectangle) badgeCorner {
+	best, bestScore := corners[0], math.Inf(1)
+	for i, c := range corners {
+		mean, stddev := regionLuma(img, rectFor(c))
+		score := mean + stddev
+		if i > 0 {
+			score += cornerMargin
+		}
+		if score < bestScore {
+			best, bestScore = c, score
+		}
+	}
+	return best
+}
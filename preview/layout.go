@@ -0,0 +1,228 @@
+package preview
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// layoutCell 以网格单位描述一个单格: 左上角所在的行列及横跨的行列数；pixels 为 true 时直接使用 rect 像素区域。
+type layoutCell struct {
+	frame   int
+	col     int
+	row     int
+	colSpan int
+	rowSpan int
+	pixels  bool
+	rect    image.Rectangle
+	label   string
+}
+
+// sheetLayout 的 width/height 大于 0 时固定画布尺寸，否则按网格与像素单格的范围推算。
+type sheetLayout struct {
+	cols      int
+	rows      int
+	width     int
+	height    int
+	labelSize float64
+	cells     []layoutCell
+}
+
+func (cfg *gridConfig) sheetLayout() (*sheetLayout, error) {
+	if cfg.customLayout != nil {
+		return cfg.customLayout, nil
+	}
+	return buildLayout(cfg.layout, cfg.rows, cfg.cols)
+}
+
+func buildLayout(name string, rows, cols int) (*sheetLayout, error) {
+	switch name {
+	case "grid", "":
+		return gridLayout(rows, cols), nil
+	case "mosaic":
+		return mosaicLayout(rows, cols)
+	default:
+		return nil, fmt.Errorf("不支持的布局: %s", name)
+	}
+}
+
+func gridLayout(rows, cols int) *sheetLayout {
+	layout := &sheetLayout{cols: cols, rows: rows}
+	for i := 0; i < rows*cols; i++ {
+		layout.cells = append(layout.cells, layoutCell{frame: i, col: i % cols, row: i / cols, colSpan: 1, rowSpan: 1})
+	}
+	return layout
+}
+
+// mosaicLayout 让第一帧占据左上角 2x2 的主图位置，其余帧按行依次填满剩下的单格。
+func mosaicLayout(rows, cols int) (*sheetLayout, error) {
+	if rows < 2 || cols < 2 {
+		return nil, fmt.Errorf("mosaic 布局要求 rows 与 cols 均不小于 2")
+	}
+	layout := &sheetLayout{cols: cols, rows: rows}
+	layout.cells = append(layout.cells, layoutCell{frame: 0, col: 0, row: 0, colSpan: 2, rowSpan: 2})
+	frame := 1
+	for row := 0; row < rows; row++ {
+		for col := 0; col < cols; col++ {
+			if row < 2 && col < 2 {
+				continue
+			}
+			layout.cells = append(layout.cells, layoutCell{frame: frame, col: col, row: row, colSpan: 1, rowSpan: 1})
+			frame++
+		}
+	}
+	return layout, nil
+}
+
+func (l *sheetLayout) frameCount() int {
+	count := 0
+	for _, cell := range l.cells {
+		if cell.frame+1 > count {
+			count = cell.frame + 1
+		}
+	}
+	return count
+}
+
+type sheetGeometry struct {
+	cellWidth  int
+	cellHeight int
+	gapX       int
+	gapY       int
+	padX       int
+	padY       int
+}
+
+func (cfg *gridConfig) geometry() sheetGeometry {
+	return sheetGeometry{
+		cellWidth:  cfg.cellWidth,
+		cellHeight: cfg.cellHeight,
+		gapX:       cfg.gapX.pixels(cfg.cellWidth),
+		gapY:       cfg.gapY.pixels(cfg.cellHeight),
+		padX:       cfg.padding.pixels(cfg.cellWidth),
+		padY:       cfg.padding.pixels(cfg.cellHeight),
+	}
+}
+
+func (l *sheetLayout) canvasSize(g sheetGeometry) (int, int) {
+	var width, height int
+	if l.cols > 0 && l.rows > 0 {
+		width = l.cols*g.cellWidth + (l.cols-1)*g.gapX + 2*g.padX
+		height = l.rows*g.cellHeight + (l.rows-1)*g.gapY + 2*g.padY
+	}
+	for _, cell := range l.cells {
+		if cell.pixels {
+			width = max(width, cell.rect.Max.X+g.padX)
+			height = max(height, cell.rect.Max.Y+g.padY)
+		}
+	}
+	if l.width > 0 {
+		width = l.width
+	}
+	if l.height > 0 {
+		height = l.height
+	}
+	return width, height
+}
+
+// cellRect 返回单格在画布上的像素区域，跨越多行多列时包含其间的间距。
+func (l *sheetLayout) cellRect(cell layoutCell, g sheetGeometry) image.Rectangle {
+	if cell.pixels {
+		return cell.rect
+	}
+	x := g.padX + cell.col*(g.cellWidth+g.gapX)
+	y := g.padY + cell.row*(g.cellHeight+g.gapY)
+	width := cell.colSpan*g.cellWidth + (cell.colSpan-1)*g.gapX
+	height := cell.rowSpan*g.cellHeight + (cell.rowSpan-1)*g.gapY
+	return image.Rect(x, y, x+width, y+height)
+}
+
+// frameSizes 返回每一帧在布局中需要的最大尺寸，截图时按此缩放以节省内存。
+func (l *sheetLayout) frameSizes(g sheetGeometry) []image.Point {
+	sizes := make([]image.Point, l.frameCount())
+	for _, cell := range l.cells {
+		rect := l.cellRect(cell, g)
+		size := &sizes[cell.frame]
+		size.X = max(size.X, rect.Dx())
+		size.Y = max(size.Y, rect.Dy())
+	}
+	return sizes
+}
+
+func composeSheet(frames []image.Image, timestamps []float64, layout *sheetLayout, cfg *gridConfig) (image.Image, error) {
+	g := cfg.geometry()
+	width, height := layout.canvasSize(g)
+
+	canvas := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(canvas, canvas.Bounds(), &image.Uniform{C: cfg.background}, image.Point{}, draw.Src)
+
+	rng := styleRand(cfg.sourceName())
+	for _, cell := range layout.cells {
+		if cell.frame >= len(frames) || frames[cell.frame] == nil {
+			continue
+		}
+		rect := layout.cellRect(cell, g)
+		frame := frames[cell.frame]
+
+		if cfg.style == "polaroid" {
+			caption := cell.label
+			if caption == "" && cell.frame < len(timestamps) {
+				caption = cfg.timestampLabel(timestamps[cell.frame])
+			}
+			if cfg.numberCells {
+				caption = strings.TrimSpace(fmt.Sprintf("%d  %s", cell.frame+1, caption))
+			}
+			if err := drawPolaroid(canvas, rect, frame, caption, rng); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		if frame.Bounds().Dx() > rect.Dx() || frame.Bounds().Dy() > rect.Dy() {
+			frame = scaleToFit(frame, rect.Dx(), rect.Dy())
+		}
+
+		frameBounds := frame.Bounds()
+		offset := image.Pt(
+			rect.Min.X+(rect.Dx()-frameBounds.Dx())/2,
+			rect.Min.Y+(rect.Dy()-frameBounds.Dy())/2,
+		)
+		placed := frameBounds.Sub(frameBounds.Min).Add(offset)
+		draw.Draw(canvas, placed, frame, frameBounds.Min, draw.Over)
+
+		if cell.label != "" {
+			size := layout.labelSize
+			if size <= 0 {
+				size = math.Max(10, float64(placed.Dy())/12)
+			}
+			if err := drawLabel(canvas, placed, cell.label, size, cfg.overlayStyle()); err != nil {
+				return nil, fmt.Errorf("绘制标签失败: %w", err)
+			}
+		}
+		if cfg.timestamps && cell.frame < len(timestamps) {
+			// 时间戳默认在右下角 (有标签时为右上角)，--smart-labels 时可移到标签与序号以外的其他角落。
+			labeled := cell.label != ""
+			var corners []badgeCorner
+			for _, c := range []badgeCorner{{top: labeled}, {top: labeled, left: true}, {top: !labeled}, {top: !labeled, left: true}} {
+				if !(c.top && c.left && cfg.numberCells) && !(!c.top && labeled) {
+					corners = append(corners, c)
+				}
+			}
+			size := math.Max(9, float64(placed.Dy())/10)
+			if _, err := drawBadge(canvas, placed, cfg.timestampLabel(timestamps[cell.frame]), size, corners, cfg.overlayStyle()); err != nil {
+				return nil, fmt.Errorf("绘制时间戳失败: %w", err)
+			}
+		}
+		if cfg.numberCells {
+			size := math.Max(9, float64(placed.Dy())/10)
+			if _, err := drawBadge(canvas, placed, strconv.Itoa(cell.frame+1), size, []badgeCorner{{top: true, left: true}}, cfg.overlayStyle()); err != nil {
+				return nil, fmt.Errorf("绘制截图序号失败: %w", err)
+			}
+		}
+	}
+
+	return canvas, nil
+}
@@ -0,0 +1,97 @@
+package preview
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"os"
+)
+
+type layoutFile struct {
+	Unit      string           `json:"unit"`
+	Cols      int              `json:"cols"`
+	Rows      int              `json:"rows"`
+	Width     int              `json:"width"`
+	Height    int              `json:"height"`
+	LabelSize float64          `json:"label_size"`
+	Cells     []layoutFileCell `json:"cells"`
+}
+
+type layoutFileCell struct {
+	Frame *int    `json:"frame"`
+	Unit  string  `json:"unit"`
+	X     float64 `json:"x"`
+	Y     float64 `json:"y"`
+	W     float64 `json:"w"`
+	H     float64 `json:"h"`
+	Label string  `json:"label"`
+}
+
+// loadLayoutFile 读取 JSON 布局描述。坐标单位默认为网格 (grid)，也可整体或逐格设为像素 (px)；
+// 未指定 frame 的单格按出现顺序依次使用采样帧。
+func loadLayoutFile(path string) (*sheetLayout, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取布局文件失败: %w", err)
+	}
+	var spec layoutFile
+	if err := json.Unmarshal(data, &spec); err != nil {
+		return nil, fmt.Errorf("解析布局文件失败: %w", err)
+	}
+	if len(spec.Cells) == 0 {
+		return nil, errors.New("布局文件未定义任何单格")
+	}
+	if spec.Width < 0 || spec.Height < 0 || spec.Cols < 0 || spec.Rows < 0 {
+		return nil, errors.New("布局文件的 cols/rows/width/height 不能为负数")
+	}
+
+	layout := &sheetLayout{
+		cols:      spec.Cols,
+		rows:      spec.Rows,
+		width:     spec.Width,
+		height:    spec.Height,
+		labelSize: spec.LabelSize,
+	}
+	next := 0
+	for i, c := range spec.Cells {
+		unit := c.Unit
+		if unit == "" {
+			unit = spec.Unit
+		}
+
+		cell := layoutCell{frame: next, label: c.Label}
+		if c.Frame != nil {
+			cell.frame = *c.Frame
+		}
+		if cell.frame < 0 {
+			return nil, fmt.Errorf("第 %d 个单格的 frame 不能为负数", i+1)
+		}
+		next = cell.frame + 1
+		if c.W <= 0 || c.H <= 0 || c.X < 0 || c.Y < 0 {
+			return nil, fmt.Errorf("第 %d 个单格的坐标不能为负数且宽高必须大于 0", i+1)
+		}
+
+		switch unit {
+		case "grid", "":
+			if c.X != math.Trunc(c.X) || c.Y != math.Trunc(c.Y) || c.W != math.Trunc(c.W) || c.H != math.Trunc(c.H) {
+				return nil, fmt.Errorf("第 %d 个单格使用网格单位时坐标与宽高必须为整数", i+1)
+			}
+			cell.col, cell.row = int(c.X), int(c.Y)
+			cell.colSpan, cell.rowSpan = int(c.W), int(c.H)
+			layout.cols = max(layout.cols, cell.col+cell.colSpan)
+			layout.rows = max(layout.rows, cell.row+cell.rowSpan)
+		case "px":
+			cell.pixels = true
+			cell.rect = image.Rect(
+				int(math.Round(c.X)), int(math.Round(c.Y)),
+				int(math.Round(c.X+c.W)), int(math.Round(c.Y+c.H)),
+			)
+		default:
+			return nil, fmt.Errorf("第 %d 个单格的单位无效: %s (可选 grid 或 px)", i+1, unit)
+		}
+		layout.cells = append(layout.cells, cell)
+	}
+	return layout, nil
+}
@@ -0,0 +1,70 @@
+package preview
+
+import (
+	"image"
+	"image/draw"
+)
+
+const (
+	// autoLevelsClip 为统计黑白场时两端忽略的像素比例，避免画面中的少量高光 (路灯、字幕) 让拉伸失效。
+	autoLevelsClip = 0.005
+	// autoLevelsMaxGain 限制最大拉伸倍数，避免近乎纯黑的画面 (片头、转场) 把噪点放大成色块。
+	autoLevelsMaxGain = 4.0
+	autoLevelsMinSpan = 8
+)
+
+// autoLevels 按亮度直方图对截图做线性色阶拉伸：取两端各 autoLevelsClip 处的亮度作为黑场与白场，
+// 映射到 0-255，三个通道使用同一映射以保持色相。亮度范围已接近满幅的画面原样返回。
+func autoLevels(img image.Image) image.Image {
+	bounds := img.Bounds()
+	rgba, ok := img.(*image.RGBA)
+	if !ok {
+		rgba = image.NewRGBA(bounds)
+		draw.Draw(rgba, bounds, img, bounds.Min, draw.Src)
+	}
+
+	var histogram [256]int
+	total := 0
+	for y := 0; y < bounds.Dy(); y++ {
+		row := rgba.Pix[y*rgba.Stride : y*rgba.Stride+bounds.Dx()*4]
+		for i := 0; i < len(row); i += 4 {
+			luma := (299*int(row[i]) + 587*int(row[i+1]) + 114*int(row[i+2])) / 1000
+			histogram[luma]++
+			total++
+		}
+	}
+	if total == 0 {
+		return img
+	}
+
+	clip := int(float64(total) * autoLevelsClip)
+	low, high := 0, 255
+	for count := 0; low < 255 && count+histogram[low] <= clip; low++ {
+		count += histogram[low]
+	}
+	for count := 0; high > 0 && count+histogram[high] <= clip; high-- {
+		count += histogram[high]
+	}
+	if high-low < autoLevelsMinSpan || low <= 2 && high >= 253 {
+		return img
+	}
+
+	gain := min(255/float64(high-low), autoLevelsMaxGain)
+	var table [256]uint8
+	for v := range table {
+		table[v] = uint8(min(max((float64(v)-float64(low))*gain, 0), 255) + 0.5)
+	}
+
+	out := image.NewRGBA(bounds)
+	for y := 0; y < bounds.Dy(); y++ {
+		src := rgba.Pix[y*rgba.Stride : y*rgba.Stride+bounds.Dx()*4]
+		dst := out.Pix[y*out.Stride : y*out.Stride+bounds.Dx()*4]
+		for i := 0; i < len(src); i += 4 {
+			dst[i] = table[src[i]]
+			dst[i+1] = table[src[i+1]]
+			dst[i+2] = table[src[i+2]]
+			dst[i+3] = src[i+3]
+		}
+	}
+	return out
+}
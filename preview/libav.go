@@ -0,0 +1,265 @@
+//go:build libav
+
+package preview
+
+/*
+#cgo pkg-config: libavformat libavcodec libavfilter libavutil
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <libavformat/avformat.h>
+#include <libavcodec/avcodec.h>
+#include <libavfilter/avfilter.h>
+#include <libavfilter/buffersink.h>
+#include <libavfilter/buffersrc.h>
+#include <libavutil/imgutils.h>
+
+typedef struct {
+	uint8_t *data;
+	int width;
+	int height;
+} vpi_frame;
+
+static int vpi_interrupted(void *opaque) {
+	return __atomic_load_n((int *)opaque, __ATOMIC_RELAXED);
+}
+
+static void vpi_interrupt(int *flag) {
+	__atomic_store_n(flag, 1, __ATOMIC_RELAXED);
+}
+
+// vpi_filter 让解码出的一帧通过 filters 描述的滤镜链，输出 RGB24 图像。
+static int vpi_filter(AVFrame *frame, AVRational time_base, const char *filters, AVFrame *out) {
+	AVFilterGraph *graph = avfilter_graph_alloc();
+	AVFilterInOut *outputs = avfilter_inout_alloc();
+	AVFilterInOut *inputs = avfilter_inout_alloc();
+	AVFilterContext *src = NULL, *sink = NULL;
+	AVRational sar = frame->sample_aspect_ratio;
+	char args[256];
+	int ret;
+
+	if (!graph || !outputs || !inputs) {
+		ret = AVERROR(ENOMEM);
+		goto end;
+	}
+	if (sar.num <= 0 || sar.den <= 0) {
+		sar = (AVRational){1, 1};
+	}
+	snprintf(args, sizeof(args), "video_size=%dx%d:pix_fmt=%d:time_base=%d/%d:pixel_aspect=%d/%d",
+		frame->width, frame->height, frame->format, time_base.num, time_base.den, sar.num, sar.den);
+	if ((ret = avfilter_graph_create_filter(&src, avfilter_get_by_name("buffer"), "in", args, NULL, graph)) < 0)
+		goto end;
+	if ((ret = avfilter_graph_create_filter(&sink, avfilter_get_by_name("buffersink"), "out", NULL, NULL, graph)) < 0)
+		goto end;
+
+	outputs->name = av_strdup("in");
+	outputs->filter_ctx = src;
+	outputs->pad_idx = 0;
+	outputs->next = NULL;
+	inputs->name = av_strdup("out");
+	inputs->filter_ctx = sink;
+	inputs->pad_idx = 0;
+	inputs->next = NULL;
+	if ((ret = avfilter_graph_parse_ptr(graph, filters, &inputs, &outputs, NULL)) < 0)
+		goto end;
+	if ((ret = avfilter_graph_config(graph, NULL)) < 0)
+		goto end;
+
+	if ((ret = av_buffersrc_add_frame(src, frame)) < 0)
+		goto end;
+	if ((ret = av_buffersrc_add_frame(src, NULL)) < 0)
+		goto end;
+	ret = av_buffersink_get_frame(sink, out);
+
+end:
+	avfilter_inout_free(&inputs);
+	avfilter_inout_free(&outputs);
+	avfilter_graph_free(&graph);
+	return ret;
+}
+
+// vpi_capture 定位到 ts 秒之前最近的关键帧并向后解码到 ts 处，与 ffmpeg 命令行的 -ss 精确定位行为一致；
+// keyframes_only 时只解码关键帧并直接返回定位到的关键帧。
+static int vpi_capture(const char *path, int index, double ts, const char *filters, int keyframes_only, int *interrupt_flag, vpi_frame *out) {
+	AVFormatContext *fmt = avformat_alloc_context();
+	AVCodecContext *dec = NULL;
+	AVPacket *pkt = av_packet_alloc();
+	AVFrame *frame = av_frame_alloc();
+	AVFrame *last = av_frame_alloc();
+	AVFrame *rgb = av_frame_alloc();
+	const AVCodec *codec = NULL;
+	AVFrame *picked = NULL;
+	int eof = 0;
+	int ret;
+
+	if (!fmt || !pkt || !frame || !last || !rgb) {
+		ret = AVERROR(ENOMEM);
+		goto end;
+	}
+	fmt->interrupt_callback.callback = vpi_interrupted;
+	fmt->interrupt_callback.opaque = interrupt_flag;
+	if ((ret = avformat_open_input(&fmt, path, NULL, NULL)) < 0)
+		goto end;
+	if ((ret = avformat_find_stream_info(fmt, NULL)) < 0)
+		goto end;
+	if (index < 0 || index >= (int)fmt->nb_streams || fmt->streams[index]->codecpar->codec_type != AVMEDIA_TYPE_VIDEO) {
+		ret = AVERROR_STREAM_NOT_FOUND;
+		goto end;
+	}
+	AVStream *stream = fmt->streams[index];
+	if (!(codec = avcodec_find_decoder(stream->codecpar->codec_id))) {
+		ret = AVERROR_DECODER_NOT_FOUND;
+		goto end;
+	}
+
+	if (!(dec = avcodec_alloc_context3(codec))) {
+		ret = AVERROR(ENOMEM);
+		goto end;
+	}
+	if ((ret = avcodec_parameters_to_context(dec, stream->codecpar)) < 0)
+		goto end;
+	if (keyframes_only)
+		dec->skip_frame = AVDISCARD_NONKEY;
+	if ((ret = avcodec_open2(dec, codec, NULL)) < 0)
+		goto end;
+
+	int64_t target = av_rescale_q((int64_t)(ts * AV_TIME_BASE), AV_TIME_BASE_Q, stream->time_base);
+	if (stream->start_time != AV_NOPTS_VALUE)
+		target += stream->start_time;
+	if ((ret = av_seek_frame(fmt, index, target, AVSEEK_FLAG_BACKWARD)) < 0)
+		goto end;
+
+	while (!picked) {
+		if (!eof) {
+			ret = av_read_frame(fmt, pkt);
+			if (ret == AVERROR_EOF) {
+				eof = 1;
+				ret = avcodec_send_packet(dec, NULL);
+			} else if (ret < 0) {
+				goto end;
+			} else if (pkt->stream_index != index) {
+				av_packet_unref(pkt);
+				continue;
+			} else {
+				ret = avcodec_send_packet(dec, pkt);
+				av_packet_unref(pkt);
+			}
+			if (ret < 0 && ret != AVERROR(EAGAIN) && ret != AVERROR_EOF)
+				goto end;
+		}
+		while ((ret = avcodec_receive_frame(dec, frame)) >= 0) {
+			if (keyframes_only || frame->best_effort_timestamp == AV_NOPTS_VALUE || frame->best_effort_timestamp >= target) {
+				picked = frame;
+				break;
+			}
+			av_frame_unref(last);
+			av_frame_move_ref(last, frame);
+		}
+		if (picked)
+			break;
+		// 时间点超出最后一帧时使用最后解码出的一帧。
+		if (ret == AVERROR_EOF) {
+			if (!last->data[0])
+				goto end;
+			picked = last;
+		} else if (ret != AVERROR(EAGAIN)) {
+			goto end;
+		}
+	}
+
+	if ((ret = vpi_filter(picked, stream->time_base, filters, rgb)) < 0)
+		goto end;
+	if (rgb->format != AV_PIX_FMT_RGB24) {
+		ret = AVERROR(EINVAL);
+		goto end;
+	}
+
+	out->width = rgb->width;
+	out->height = rgb->height;
+	out->data = malloc((size_t)rgb->width * rgb->height * 3);
+	if (!out->data) {
+		ret = AVERROR(ENOMEM);
+		goto end;
+	}
+	for (int y = 0; y < rgb->height; y++)
+		memcpy(out->data + (size_t)y * rgb->width * 3, rgb->data[0] + (size_t)y * rgb->linesize[0], (size_t)rgb->width * 3);
+	ret = 0;
+
+end:
+	av_frame_free(&rgb);
+	av_frame_free(&last);
+	av_frame_free(&frame);
+	av_packet_free(&pkt);
+	avcodec_free_context(&dec);
+	avformat_close_input(&fmt);
+	return ret;
+}
+
+static void vpi_error(int code, char *buf, size_t size) {
+	av_strerror(code, buf, size);
+}
+*/
+import "C"
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"strings"
+	"time"
+	"unsafe"
+)
+
+const libavAvailable = true
+
+// captureFrameLibav 在进程内用 libavformat/libavcodec 解码一帧，并用 libavfilter 执行与 ffmpeg 命令行相同的滤镜链，
+// 省去每帧启动一次 ffmpeg 进程的开销。ffmpeg 命令行会自动按旋转信息转正画面，libav 不会，
+// 由 captureFilters 把转正滤镜放在 filters 开头。
+func captureFrameLibav(ctx context.Context, videoPath string, stream int, timestamp float64, filters []string, keyframesOnly bool) (image.Image, error) {
+	chain := append(append([]string(nil), filters...), "format=rgb24")
+	cPath := C.CString(videoPath)
+	defer C.free(unsafe.Pointer(cPath))
+	cFilters := C.CString(strings.Join(chain, ","))
+	defer C.free(unsafe.Pointer(cFilters))
+
+	// 中断标志分配在 C 内存中，ctx 结束时由 interrupt_callback 读取并终止阻塞的读取。
+	// 回调已经开始执行时不释放标志，避免回调写入已释放的内存。
+	interrupt := (*C.int)(C.calloc(1, C.size_t(unsafe.Sizeof(C.int(0)))))
+	stop := context.AfterFunc(ctx, func() { C.vpi_interrupt(interrupt) })
+	defer func() {
+		if stop() {
+			C.free(unsafe.Pointer(interrupt))
+		}
+	}()
+
+	var keyframes C.int
+	if keyframesOnly {
+		keyframes = 1
+	}
+	start := time.Now()
+	var out C.vpi_frame
+	if code := C.vpi_capture(cPath, C.int(stream), C.double(timestamp), cFilters, keyframes, interrupt, &out); code < 0 {
+		observeToolFailure("capture")
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		var buf [256]C.char
+		C.vpi_error(code, &buf[0], C.size_t(len(buf)))
+		return nil, fmt.Errorf("libav 解码失败: %s", C.GoString(&buf[0]))
+	}
+	defer C.free(unsafe.Pointer(out.data))
+
+	width, height := int(out.width), int(out.height)
+	pixels := unsafe.Slice((*byte)(unsafe.Pointer(out.data)), width*height*3)
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i, j := 0, 0; i < len(pixels); i, j = i+3, j+4 {
+		img.Pix[j] = pixels[i]
+		img.Pix[j+1] = pixels[i+1]
+		img.Pix[j+2] = pixels[i+2]
+		img.Pix[j+3] = 0xFF
+	}
+
+	framesCaptured.Inc()
+	captureLatency.Observe(time.Since(start).Seconds())
+	return img, nil
+}
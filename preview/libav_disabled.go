@@ -0,0 +1,15 @@
+//go:build !libav
+
+package preview
+
+import (
+	"context"
+	"errors"
+	"image"
+)
+
+const libavAvailable = false
+
+func captureFrameLibav(ctx context.Context, videoPath string, stream int, timestamp float64, filters []string, keyframesOnly bool) (image.Image, error) {
+	return nil, errors.New("未启用 libav 后端")
+}
@@ -0,0 +1,77 @@
+package preview
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+)
+
+// ebur128 滤镜在结束时输出 Summary，依次包含 I (综合响度)、LRA (响度范围) 与 Peak (真峰值)。
+var (
+	loudnessIntegratedPattern = regexp.MustCompile(`\bI:\s+(-?[\d.]+|-?inf) LUFS`)
+	loudnessRangePattern      = regexp.MustCompile(`\bLRA:\s+(-?[\d.]+) LU`)
+	loudnessPeakPattern       = regexp.MustCompile(`\bPeak:\s+(-?[\d.]+|-?inf) dBFS`)
+)
+
+type loudnessStats struct {
+	integrated float64 // LUFS
+	lra        float64 // LU
+	truePeak   float64 // dBTP
+}
+
+// measureLoudness 用 ebur128 滤镜完整解码第一条音轨，测量 EBU R128 综合响度、响度范围与真峰值。
+func measureLoudness(ctx context.Context, r Runner, path string) (*loudnessStats, error) {
+	// framelog=verbose 将逐帧测量值降到 verbose 级别，info 级别下只保留最终的 Summary。
+	cmd := newCommand(ctx, r, ffmpegPath,
+		"-nostats", "-hide_banner", "-loglevel", "info",
+		"-i", toolPath(path),
+		"-map", "0:a:0", "-vn",
+		"-af", "ebur128=peak=true:framelog=verbose",
+		"-f", "null", "-",
+	)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		observeToolFailure("loudness")
+		return nil, fmt.Errorf("测量响度失败: %w", commandError(err, stderr.Bytes()))
+	}
+
+	// Summary 位于输出末尾，取最后一次匹配，避免误读更早的日志。
+	output := stderr.Bytes()
+	if i := bytes.LastIndex(output, []byte("Summary:")); i >= 0 {
+		output = output[i:]
+	}
+	integrated, okI := matchLoudness(loudnessIntegratedPattern, output)
+	lra, okLRA := matchLoudness(loudnessRangePattern, output)
+	peak, okPeak := matchLoudness(loudnessPeakPattern, output)
+	if !okI || !okLRA || !okPeak {
+		return nil, errors.New("无法解析 ebur128 输出")
+	}
+	return &loudnessStats{integrated: integrated, lra: lra, truePeak: peak}, nil
+}
+
+func matchLoudness(pattern *regexp.Regexp, output []byte) (float64, bool) {
+	m := pattern.FindSubmatch(output)
+	if m == nil {
+		return 0, false
+	}
+	value, err := strconv.ParseFloat(string(m[1]), 64)
+	return value, err == nil
+}
+
+func (s *loudnessStats) headerLine() string {
+	return fmt.Sprintf("Loudness: %s LUFS    LRA: %.1f LU    True peak: %s dBTP",
+		formatLevel(s.integrated), s.lra, formatLevel(s.truePeak))
+}
+
+// formatLevel 保留一位小数；静音音轨的测量值为负无穷，显示为 -inf。
+func formatLevel(value float64) string {
+	if math.IsInf(value, -1) {
+		return "-inf"
+	}
+	return strconv.FormatFloat(value, 'f', 1, 64)
+}
@@ -0,0 +1,54 @@
+package preview
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// validateLUT 检查 --lut 指定的 3D LUT 文件，返回其绝对路径，使 ffmpeg 与 libav 后端都不依赖工作目录。
+func validateLUT(path string) (string, error) {
+	if isRemoteURI(path) {
+		return "", errors.New("lut 只支持本地路径")
+	}
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".cube", ".3dl", ".dat", ".m3d", ".csp":
+	default:
+		return "", fmt.Errorf("不支持的 LUT 文件格式: %s (可选: .cube、.3dl、.dat、.m3d、.csp)", path)
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return "", fmt.Errorf("读取 LUT 文件失败: %w", err)
+	}
+	if info.IsDir() {
+		return "", fmt.Errorf("LUT 路径是目录: %s", path)
+	}
+	return abs, nil
+}
+
+// lutFilter 返回应用 3D LUT 的 lut3d 滤镜，使用四面体插值。
+func lutFilter(path string) string {
+	return "lut3d=file=" + escapeFilterValue(path) + ":interp=tetrahedral"
+}
+
+// escapeFilterValue 按 ffmpeg 的两级转义规则处理滤镜参数值：先转义参数分隔符，再转义滤镜图中的特殊字符，
+// Windows 盘符中的冒号与路径中的逗号、方括号都能原样传递。
+func escapeFilterValue(value string) string {
+	escape := func(s, special string) string {
+		var b strings.Builder
+		for _, r := range s {
+			if strings.ContainsRune(special, r) {
+				b.WriteByte('\\')
+			}
+			b.WriteRune(r)
+		}
+		return b.String()
+	}
+	return escape(escape(value, `\':`), `\'[],;`)
+}
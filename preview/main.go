@@ -0,0 +1,1699 @@
+package preview
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+type gridConfig struct {
+	input string
+	// images 为 --images/--images-dir 给出的已有图片，不为空时直接拼接这些图片，不读取视频。
+	images []string
+	// inputs 为以位置参数给出的多个输入视频，由 runInputs 依次处理；只有一个时直接使用 input。
+	inputs []string
+	source string
+	output string
+	// extraOutputs 为重复指定的 --output，与 output 使用同一张拼图，格式由各自的扩展名决定。
+	extraOutputs []string
+	destination  string
+	format       string
+	variants     []int
+	dpi          int
+	manifest     string
+	workers      int
+	stateFile    string
+	resume       bool
+	report       string
+	rows         int
+	cols         int
+	cellWidth    int
+	cellHeight   int
+	maxWidth     int
+	maxHeight    int
+	maxBytes     int64
+	margin       int
+	layout       string
+	layoutFile   string
+	style        string
+	gapX         spacing
+	gapY         spacing
+	padding      spacing
+	jpegQuality  int
+	background   color.Color
+
+	saveFramesDir string
+	frameFormat   string
+	frameQuality  int
+	framesOnly    bool
+
+	animOutput string
+	animWidth  int
+	animFPS    float64
+
+	clipDuration  float64
+	keyframesOnly bool
+	frameNumbers  []int
+	percentages   []float64
+	at            *thumbnailPosition
+	// artwork 为 --artwork 的媒体中心预设名称，不为空时只生成 artworkPresets 中的图片。
+	artwork string
+	// trickplay 为 --trickplay 的拖动预览格式 (jellyfin 或 bif)，不为空时由 renderTrickplay 生成。
+	trickplay      string
+	interval       time.Duration
+	maxCells       int
+	avoidFreeze    bool
+	snapToKeyframe bool
+	stream         string
+	deinterlace    string
+	selector       string
+	sample         string
+	seed           int64
+	backend        string
+	pipeCodec      string
+	prescale       bool
+	retries        int
+	retryDelay     time.Duration
+
+	// streamIndex 为截图所用视频流的绝对序号，读取视频信息后根据 stream 确定。
+	streamIndex int
+	// autoRows 表示网格行数未显式指定，按帧序号或间隔采样时根据截图数确定。
+	autoRows bool
+	// paged 表示按间隔采样的截图超过单张容量，已分页输出为多张拼图。
+	paged bool
+	// formatLabel 按 timestampFormat 格式化截图上的时间标注，读取视频信息后确定。
+	formatLabel func(float64) string
+
+	tiffCompression string
+	jpegProgressive bool
+	jpegSubsampling string
+	pngCompression  string
+	pngColors       int
+	pngDither       bool
+	embedMetadata   bool
+	// embedCover 为 true 时生成后把输出写回输入视频作为封面，见 embedCover。
+	embedCover      bool
+	deterministic   bool
+	colorManagement bool
+	autoLevels      bool
+	denoise         bool
+	rotate          int
+	crop            *cropRegion
+	flip            string
+	deband          bool
+	lut             string
+	header          bool
+	headerTemplate  *template.Template
+	footer          []string
+	loudness        bool
+	waveform        bool
+	bitrateGraph    bool
+	timestamps      bool
+	numberCells     bool
+	smartLabels     bool
+	timestampFormat string
+	sidecar         bool
+	checksum        string
+	mediaInfo       string
+
+	// textColor、textOutline 与 textOutlineColor 为单格内标注的文字颜色与描边，见 overlayStyle。
+	textColor        color.Color
+	textOutline      int
+	textOutlineColor color.Color
+
+	cacheDir   string
+	cacheMaxMB int64
+	noCache    bool
+
+	storage storageOptions
+	webhook string
+
+	publish     string
+	publishOpts publishOptions
+
+	// ctx 为空时不设超时；服务模式下用于在请求超时或客户端断开时终止 ffmpeg。
+	ctx context.Context
+	// runner 为 Generator.Runner，为 nil 时直接启动 ffmpeg、ffprobe。
+	runner Runner
+
+	// progress 在每张截图完成后调用，用于 gRPC 等服务模式推送进度。
+	progress func(done, total int)
+	// dryRun 为 true 时只输出执行计划，不截图也不写入文件。
+	dryRun bool
+	// open 为 true 时在生成成功后用系统默认程序打开输出，只用于命令行单次生成。
+	open bool
+	// tui 为 true 时进入交互模式，由 runTUI 处理。
+	tui bool
+	// copyPath 与 copyImage 在生成成功后把输出路径或图片写入剪贴板，只用于命令行单次生成。
+	copyPath  bool
+	copyImage bool
+	// frameHook 在每张截图取得后、缩放与拼接前调用，返回的图像替代原截图 (保存单帧与动态预览也使用替换后的图像)。
+	frameHook func(index int, timestamp float64, frame image.Image) (image.Image, error)
+	// onProbe 与 onComposeStart 为 Generator 的 OnProbe、OnComposeStart 回调，分别在读取视频信息后与开始拼接前调用。
+	onProbe        func(meta *videoMetadata)
+	onComposeStart func(frames int)
+
+	customLayout *sheetLayout
+
+	segment         time.Duration
+	sheetPerChapter bool
+	// span 为按片段拆分时当前拼图覆盖的时间范围，为空时覆盖整个视频。
+	span *timeSpan
+}
+
+// sourceName 返回用于展示与元数据的输入名称；远程输入会被替换为临时地址，此时仍返回原始 URI。
+func (cfg *gridConfig) sourceName() string {
+	if cfg.source != "" {
+		return cfg.source
+	}
+	return cfg.input
+}
+
+func (cfg *gridConfig) context() context.Context {
+	if cfg.ctx != nil {
+		return cfg.ctx
+	}
+	return context.Background()
+}
+
+// outputName 与 sourceName 类似，返回远程输出的原始 URI。
+func (cfg *gridConfig) outputName() string {
+	if cfg.destination != "" {
+		return cfg.destination
+	}
+	return cfg.output
+}
+
+// Main 按命令行参数运行 video-preview-image 命令 (含各子命令)，出错时以非零状态退出。
+func Main() {
+	setupConsole()
+	defer restoreConsole()
+	defer waitIfOwnConsole()
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "watch":
+			if err := runWatch(os.Args[2:]); err != nil {
+				exitWithError(err)
+			}
+			return
+		case "probe":
+			if err := runProbe(os.Args[2:]); err != nil {
+				exitWithError(err)
+			}
+			return
+		case "serve":
+			if err := runServe(os.Args[2:]); err != nil {
+				exitWithError(err)
+			}
+			return
+		case "grpc":
+			if err := runGRPC(os.Args[2:]); err != nil {
+				exitWithError(err)
+			}
+			return
+		case "worker":
+			if err := runWorker(os.Args[2:]); err != nil {
+				exitWithError(err)
+			}
+			return
+		case "compose":
+			if err := runCompose(os.Args[2:]); err != nil {
+				exitWithError(err)
+			}
+			return
+		case "install-ffmpeg":
+			if err := runInstallFFmpeg(os.Args[2:]); err != nil {
+				exitWithError(err)
+			}
+			return
+		case "version":
+			printVersion(os.Stdout)
+			return
+		case "completion":
+			if err := runCompletion(os.Args[2:]); err != nil {
+				exitWithError(err)
+			}
+			return
+		}
+	}
+
+	cfg, err := parseFlags(os.Args[1:])
+	if errors.Is(err, errVersionRequested) {
+		printVersion(os.Stdout)
+		return
+	}
+	if errors.Is(err, errRPCStdioRequested) {
+		if err := runRPCStdio(); err != nil {
+			exitWithError(err)
+		}
+		return
+	}
+	if err != nil {
+		exitWithError(err)
+	}
+	if err := startProfiling(); err != nil {
+		exitWithError(err)
+	}
+	defer func() { stopProfiling() }()
+
+	// 拼接已有图片不调用 ffmpeg (WebP 输出除外，编码时再报错)。
+	if len(cfg.images) == 0 {
+		if err := ensureExecutables(); err != nil {
+			exitWithError(err)
+		}
+	}
+
+	if cfg.manifest != "" {
+		if err := runManifest(cfg); err != nil {
+			exitWithError(err)
+		}
+		return
+	}
+	if cfg.tui {
+		if err := runTUI(cfg); err != nil {
+			exitWithError(err)
+		}
+		return
+	}
+	if len(cfg.inputs) > 1 {
+		if err := runInputs(cfg); err != nil {
+			exitWithError(err)
+		}
+		return
+	}
+
+	if err := generatePreview(cfg); err != nil {
+		exitWithError(err)
+	}
+	if cfg.dryRun {
+		return
+	}
+
+	// 图片写到标准输出时，提示信息改走标准错误，避免混入图片数据。
+	if cfg.output == "-" {
+		fmt.Fprintln(os.Stderr, resultMessage(cfg))
+		return
+	}
+	if message := resultMessage(cfg); message != "" {
+		fmt.Println(message)
+	}
+	if cfg.copyPath || cfg.copyImage {
+		if err := copyResult(cfg); err != nil {
+			fmt.Fprintln(os.Stderr, "警告:", err)
+		}
+	}
+	if cfg.open {
+		if err := openResult(cfg); err != nil {
+			fmt.Fprintln(os.Stderr, "警告:", err)
+		}
+	}
+}
+
+func resultMessage(cfg *gridConfig) string {
+	if cfg.artwork != "" {
+		return fmt.Sprintf("已生成 %s 图片: %s", cfg.artwork, strings.Join(artworkPaths(cfg), "、"))
+	}
+	if cfg.trickplay != "" {
+		return fmt.Sprintf("已生成 %s 拖动预览图: %s", cfg.trickplay, trickplayPath(cfg))
+	}
+	var parts []string
+	// 拆分生成时每张拼图已在生成后单独输出提示。
+	if !cfg.framesOnly && !cfg.splitSheets() {
+		if isMontageOutput(cfg.output) {
+			parts = append(parts, "已生成预览短片: "+cfg.output)
+		} else if cfg.at != nil {
+			parts = append(parts, "已生成截图: "+cfg.outputNames())
+		} else {
+			parts = append(parts, "已生成九宫格截图: "+cfg.outputNames())
+		}
+	}
+	if len(cfg.variants) > 0 && !cfg.framesOnly && !cfg.splitSheets() {
+		outputs := make([]string, len(cfg.variants))
+		for i, width := range cfg.variants {
+			outputs[i] = variantOutput(cfg.output, width)
+		}
+		parts = append(parts, "其他尺寸: "+strings.Join(outputs, "、"))
+	}
+	if cfg.saveFramesDir != "" {
+		parts = append(parts, "已保存单帧截图至: "+cfg.saveFramesDir)
+	}
+	if cfg.animOutput != "" && !cfg.splitSheets() {
+		parts = append(parts, "已生成动态预览: "+cfg.animOutput)
+	}
+	return strings.Join(parts, "，")
+}
+
+func generatePreview(cfg *gridConfig) error {
+	_, err := runPreview(cfg)
+	return err
+}
+
+// runPreview 生成预览并返回视频时长与采样时间点，指定 --webhook 时在结束后发送通知。
+func runPreview(cfg *gridConfig) (*previewResult, error) {
+	result := &previewResult{}
+	jobsInProgress.Inc()
+	start := time.Now()
+	err := buildPreview(cfg, result)
+	jobsInProgress.Dec()
+	observeJob(start, err)
+	if cfg.webhook != "" {
+		notifyWebhook(cfg, result, err)
+	}
+	return result, err
+}
+
+func buildPreview(cfg *gridConfig, result *previewResult) error {
+	if len(cfg.images) > 0 {
+		return composeImages(cfg, cfg.images)
+	}
+	if cfg.input == "-" {
+		cleanup, err := spoolStdin(cfg)
+		if err != nil {
+			return err
+		}
+		defer cleanup()
+	}
+	if isRemoteURI(cfg.input) || isRemoteURI(cfg.output) {
+		return generateRemote(cfg, result)
+	}
+
+	meta, err := prepareVideo(cfg)
+	if err != nil {
+		return err
+	}
+	result.duration = meta.duration
+
+	if cfg.checksum != "" {
+		if meta.checksum, err = computeChecksum(cfg.input, cfg.checksum); err != nil {
+			return err
+		}
+	}
+
+	if cfg.crop != nil {
+		// 尺寸未知时 (内置解析器读不到分辨率) 交给 ffmpeg 的 crop 滤镜报错。
+		if width, height := cfg.orientedSize(meta); width > 0 && height > 0 {
+			if _, _, _, _, err := cfg.crop.rect(width, height); err != nil {
+				return err
+			}
+		}
+	}
+
+	if cfg.artwork != "" {
+		return renderArtwork(cfg, meta, result)
+	}
+	if cfg.trickplay != "" {
+		return renderTrickplay(cfg, meta, result)
+	}
+	if cfg.at != nil {
+		return renderThumbnail(cfg, meta, result)
+	}
+	if cfg.interval > 0 {
+		pageSize, err := cfg.intervalPageSize()
+		if err != nil {
+			return err
+		}
+		if spans := intervalPages(meta.duration, cfg.interval.Seconds(), pageSize); len(spans) > 0 {
+			if cfg.output == "-" {
+				return fmt.Errorf("按间隔采样需要 %d 张拼图，不能输出到标准输出", len(spans))
+			}
+			cfg.paged = true
+			return generateSpans(cfg, meta, result, spans)
+		}
+	}
+	if cfg.segment > 0 {
+		return generateSpans(cfg, meta, result, segmentSpans(meta.duration, cfg.segment.Seconds()))
+	}
+	if cfg.sheetPerChapter {
+		if spans := chapterSpans(meta); len(spans) > 0 {
+			return generateSpans(cfg, meta, result, spans)
+		}
+		// 没有章节时按整个视频生成，拆分模式下 resultMessage 不输出拼图路径，这里单独提示。
+		fmt.Fprintln(os.Stderr, "警告: 视频没有章节信息，改为生成整个视频的拼图")
+		if err := renderPreview(cfg, meta, result); err != nil {
+			return err
+		}
+		if !cfg.framesOnly && !cfg.dryRun {
+			fmt.Println("已生成九宫格截图: " + cfg.outputName())
+		}
+		return nil
+	}
+	return renderPreview(cfg, meta, result)
+}
+
+// prepareVideo 读取视频信息，按 --stream 选择截图所用的视频流，并确定时间标注格式与未指定时的单格高度。
+func prepareVideo(cfg *gridConfig) (*videoMetadata, error) {
+	var meta *videoMetadata
+	err := withRetry(cfg, "读取视频信息", func() (err error) {
+		meta, err = probeVideo(cfg.context(), cfg.runner, cfg.input)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	if cfg.stream != "" {
+		if err := meta.selectVideoStream(cfg.stream); err != nil {
+			return nil, err
+		}
+	}
+	cfg.streamIndex = meta.videoIndex
+	cfg.formatLabel = timestampLabel(cfg.timestampFormat, meta)
+	if cfg.cellHeight == 0 {
+		displayWidth, displayHeight := cfg.frameSize(meta)
+		cfg.cellHeight = inferCellHeight(cfg.cellWidth, displayWidth, displayHeight)
+	}
+	if cfg.onProbe != nil {
+		cfg.onProbe(meta)
+	}
+	return meta, nil
+}
+
+// renderPreview 根据已读取的视频信息采样并生成一张拼图 (或预览短片)；cfg.span 不为空时只在该时间范围内采样。
+func renderPreview(cfg *gridConfig, meta *videoMetadata, result *previewResult) error {
+	if cfg.interval > 0 && cfg.autoRows {
+		_, length := cfg.sampleRange(meta)
+		cfg.rows = (intervalCount(length, cfg.interval.Seconds()) + cfg.cols - 1) / cfg.cols
+	}
+	layout, err := cfg.sheetLayout()
+	if err != nil {
+		return err
+	}
+	if err := fitSheetSize(cfg, meta, layout); err != nil {
+		return err
+	}
+	frameSizes := layout.frameSizes(cfg.geometry())
+
+	timestamps, err := planTimestamps(cfg, meta, layout)
+	if err != nil {
+		return err
+	}
+	totalFrames := len(timestamps)
+	result.timestamps = append(result.timestamps, timestamps...)
+	frames := make([]image.Image, layout.frameCount())
+
+	var animFrames []image.Image
+	var animHeight int
+	if cfg.animOutput != "" {
+		displayWidth, displayHeight := cfg.frameSize(meta)
+		animHeight = inferCellHeight(cfg.animWidth, displayWidth, displayHeight)
+	}
+
+	filters := captureFilters(cfg, meta)
+
+	if cfg.dryRun {
+		return printDryRun(os.Stdout, cfg, meta, layout, timestamps, filters, animHeight)
+	}
+
+	if cfg.backend == "ffmpeg-tile" {
+		if cfg.onComposeStart != nil {
+			cfg.onComposeStart(totalFrames)
+		}
+		err := withRetry(cfg, "拼接截图", func() error {
+			return generateTileSheet(cfg, layout, timestamps, filters)
+		})
+		if err != nil {
+			return err
+		}
+		if cfg.sidecar && cfg.output != "-" {
+			width, height := layout.canvasSize(cfg.geometry())
+			hits := newSheetHitMap(layout, cfg, timestamps, image.Pt(width, height), 0)
+			if err := writeSidecar(cfg, meta, timestamps, hits); err != nil {
+				return err
+			}
+		}
+		if cfg.mediaInfo != "" {
+			if err := writeMediaInfo(cfg, meta); err != nil {
+				return err
+			}
+		}
+		if cfg.embedCover {
+			if err := embedCover(cfg, meta); err != nil {
+				return err
+			}
+		}
+		if cfg.publish != "" {
+			return publishSheet(cfg)
+		}
+		return nil
+	}
+
+	montage := isMontageOutput(cfg.output)
+	if cfg.prescale {
+		filters = append(filters, prescaleFilter(frameSizes, cfg.animWidth, animHeight))
+	}
+	if !montage || cfg.saveFramesDir != "" || cfg.animOutput != "" {
+		for captured, err := range captureFrames(cfg, meta, timestamps, filters) {
+			if err != nil {
+				return err
+			}
+			i, frame, ts := captured.index, captured.image, captured.timestamp
+			timestamps[i] = ts
+			if cfg.frameHook != nil {
+				if frame, err = cfg.frameHook(i, ts, frame); err != nil {
+					return err
+				}
+			}
+			if cfg.saveFramesDir != "" {
+				if err := saveFrame(frame, cfg, meta, i, ts, totalFrames); err != nil {
+					return err
+				}
+			}
+			if cfg.animOutput != "" {
+				animFrame := frame
+				if cfg.autoLevels {
+					animFrame = autoLevels(animFrame)
+				}
+				animFrames = append(animFrames, fitToCanvas(animFrame, cfg.animWidth, animHeight, cfg.background))
+			}
+			frames[i] = scaleToFit(frame, frameSizes[i].X, frameSizes[i].Y)
+			if cfg.autoLevels {
+				frames[i] = autoLevels(frames[i])
+			}
+			if cfg.progress != nil {
+				cfg.progress(i+1, totalFrames)
+			}
+		}
+	}
+
+	if cfg.animOutput != "" {
+		if err := encodeAnimatedWebP(cfg.runner, animFrames, cfg.animOutput, cfg.animFPS, cfg.jpegQuality, cfg.deterministic); err != nil {
+			return err
+		}
+	}
+
+	if cfg.framesOnly {
+		return nil
+	}
+	if cfg.onComposeStart != nil {
+		cfg.onComposeStart(totalFrames)
+	}
+
+	if montage {
+		return generateMontage(cfg, meta, timestamps)
+	}
+
+	collage, err := composeSheet(frames, timestamps, layout, cfg)
+	if err != nil {
+		return err
+	}
+	if cfg.waveform {
+		if meta.audioCodec == "" {
+			fmt.Fprintln(os.Stderr, "警告: 视频没有音轨，已跳过音频波形")
+		} else if collage, err = addWaveform(collage, cfg, meta, timestamps); err != nil {
+			return fmt.Errorf("绘制音频波形失败: %w", err)
+		}
+	}
+	if cfg.bitrateGraph {
+		if _, lookErr := exec.LookPath(ffprobePath); lookErr != nil && cfg.runner == nil {
+			fmt.Fprintln(os.Stderr, "警告: 码率图需要 ffprobe 读取数据包信息，已跳过")
+		} else if collage, err = addBitrateGraph(collage, cfg, meta, timestamps); err != nil {
+			return fmt.Errorf("绘制码率图失败: %w", err)
+		}
+	}
+	if len(cfg.footer) > 0 {
+		if collage, err = addFooter(collage, cfg.footer, cfg); err != nil {
+			return fmt.Errorf("绘制页脚失败: %w", err)
+		}
+	}
+	headerHeight := 0
+	if cfg.header {
+		lines := headerLines(cfg, meta)
+		if cfg.headerTemplate != nil {
+			if lines, err = templateHeaderLines(cfg, meta); err != nil {
+				return err
+			}
+		}
+		if cfg.loudness {
+			if meta.audioCodec == "" {
+				fmt.Fprintln(os.Stderr, "警告: 视频没有音轨，已跳过响度测量")
+			} else {
+				stats, err := measureLoudness(cfg.context(), cfg.runner, cfg.input)
+				if err != nil {
+					return err
+				}
+				lines = append(lines, stats.headerLine())
+			}
+		}
+		height := collage.Bounds().Dy()
+		if collage, err = addHeader(collage, lines, cfg); err != nil {
+			return fmt.Errorf("绘制信息栏失败: %w", err)
+		}
+		headerHeight = collage.Bounds().Dy() - height
+	}
+	if cfg.sidecar && cfg.output != "-" {
+		hits := newSheetHitMap(layout, cfg, timestamps, collage.Bounds().Size(), headerHeight)
+		if err := writeSidecar(cfg, meta, timestamps, hits); err != nil {
+			return err
+		}
+	}
+	if cfg.mediaInfo != "" {
+		if err := writeMediaInfo(cfg, meta); err != nil {
+			return err
+		}
+	}
+
+	opts := cfg.encodeOptions()
+	if cfg.embedMetadata {
+		opts.metadata = newSheetMetadata(cfg, meta, timestamps)
+	}
+	if err := saveVariants(collage, cfg, opts); err != nil {
+		return err
+	}
+	opts.maxBytes = cfg.maxBytes
+	if err := saveImage(collage, cfg.output, opts); err != nil {
+		return err
+	}
+	if err := saveExtraOutputs(collage, cfg, opts); err != nil {
+		return err
+	}
+	if cfg.embedCover {
+		if err := embedCover(cfg, meta); err != nil {
+			return err
+		}
+	}
+	if cfg.publish != "" {
+		return publishSheet(cfg)
+	}
+	return nil
+}
+
+// planTimestamps 按帧序号、百分比、固定间隔或 --sampling 计算拼图的采样时间点，并按 --avoid-freeze、--snap-to-keyframe 调整。
+func planTimestamps(cfg *gridConfig, meta *videoMetadata, layout *sheetLayout) ([]float64, error) {
+	var timestamps []float64
+	var err error
+	if len(cfg.frameNumbers) > 0 {
+		if len(cfg.frameNumbers) > layout.frameCount() {
+			return nil, fmt.Errorf("指定了 %d 个帧序号，但布局只有 %d 个截图位置", len(cfg.frameNumbers), layout.frameCount())
+		}
+		if timestamps, err = frameTimestamps(cfg.frameNumbers, meta); err != nil {
+			return nil, err
+		}
+	} else if len(cfg.percentages) > 0 {
+		if len(cfg.percentages) > layout.frameCount() {
+			return nil, fmt.Errorf("指定了 %d 个百分比，但布局只有 %d 个截图位置", len(cfg.percentages), layout.frameCount())
+		}
+		start, length := cfg.sampleRange(meta)
+		timestamps = percentageTimestamps(cfg.percentages, start, length, meta)
+	} else if cfg.interval > 0 {
+		start, length := cfg.sampleRange(meta)
+		if timestamps = intervalTimestamps(start, length, cfg.interval.Seconds()); len(timestamps) > layout.frameCount() {
+			return nil, fmt.Errorf("按间隔采样需要 %d 张截图，但布局只有 %d 个截图位置", len(timestamps), layout.frameCount())
+		}
+	} else {
+		start, length := cfg.sampleRange(meta)
+		timestamps = cfg.sampleTimestamps(length, layout.frameCount())
+		for i := range timestamps {
+			timestamps[i] += start
+		}
+		if cfg.avoidFreeze {
+			freezes, err := detectFreezes(cfg.context(), cfg, start, length)
+			if err != nil {
+				return nil, err
+			}
+			timestamps = avoidFreezes(timestamps, start, length, freezes)
+		}
+	}
+	if cfg.snapToKeyframe {
+		start, length := cfg.sampleRange(meta)
+		keyframes, err := probeKeyframes(cfg.context(), cfg, start, length)
+		if err != nil {
+			return nil, err
+		}
+		timestamps = snapToKeyframes(timestamps, keyframes)
+	}
+	return timestamps, nil
+}
+
+// captureFilters 返回截图时依次应用的滤镜：(libav 后端) 按旋转元数据转正、反交错、降噪、去色带、色彩转换、LUT、像素宽高比校正、旋转翻转与裁剪。
+func captureFilters(cfg *gridConfig, meta *videoMetadata) []string {
+	var filters []string
+	if cfg.backend == "libav" {
+		filters = autorotateFilters(meta.rotation)
+	}
+	if cfg.deinterlace != "off" && meta.interlaced() {
+		filters = append(filters, deinterlaceFilter(cfg.deinterlace, meta))
+	}
+	if cfg.denoise {
+		filters = append(filters, denoiseFilter)
+	}
+	if cfg.deband {
+		filters = append(filters, debandFilter)
+	}
+	if cfg.colorManagement {
+		filters = append(filters, colorFilters(meta)...)
+	}
+	if cfg.lut != "" {
+		filters = append(filters, lutFilter(toolPath(cfg.lut)))
+	}
+	if meta.anamorphic() {
+		filters = append(filters, sampleAspectFilter)
+	}
+	filters = append(filters, orientationFilters(cfg.rotate, cfg.flip)...)
+	if cfg.crop != nil {
+		filters = append(filters, cfg.crop.filter())
+	}
+	return filters
+}
+
+type mainFlags struct {
+	input        string
+	output       string
+	extraOutputs []string
+	manifest     string
+	workers      int
+	stateFile    string
+	noResume     bool
+	report       string
+	version      bool
+	frameHook    string
+	dryRun       bool
+	images       string
+	imagesDir    string
+	open         bool
+	tui          bool
+	rpcStdio     bool
+	copyPath     bool
+	copyImage    bool
+	grid         *gridFlags
+}
+
+var errVersionRequested = errors.New("version requested")
+
+func newMainFlagSet() (*flag.FlagSet, *mainFlags) {
+	fs := flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	mf := &mainFlags{}
+	fs.StringVar(&mf.input, "input", "", "输入视频文件路径 (未指定 --manifest 时必填)；为 - 时从标准输入读取，先写入临时文件再处理")
+	mf.output = "preview.png"
+	fs.Var(&outputsFlag{first: &mf.output, extra: &mf.extraOutputs}, "output", "输出图片路径，格式根据扩展名自动决定；为 - 时写到标准输出。可重复指定，同一张拼图按各自的扩展名分别输出")
+	fs.StringVar(&mf.manifest, "manifest", "", "批量任务清单 (.csv 或 .json)，逐行指定输入、输出及 rows/cols/quality 覆盖值")
+	fs.IntVar(&mf.workers, "workers", 1, "处理任务清单时并行生成的任务数")
+	fs.StringVar(&mf.stateFile, "state-file", "", "任务清单的进度文件，为空时使用 <清单路径>.state.json；中断后重新运行会跳过已完成的任务")
+	fs.StringVar(&mf.report, "report", "", "任务清单处理完成后写入报告 (.csv 或 .json)，列出每个任务的状态、输出、耗时与错误信息")
+	fs.BoolVar(&mf.noResume, "no-resume", false, "忽略已有的进度文件，重新处理清单中的所有任务")
+	fs.StringVar(&mf.frameHook, "frame-hook", "", "拼接前用该命令处理每张截图 (标准输入为 PNG，标准输出返回图片)，例如人脸打码；只能在命令行中指定")
+	fs.BoolVar(&mf.dryRun, "dry-run", false, "只读取视频信息并输出执行计划 (采样时间点、拼图尺寸与将要执行的 ffmpeg 命令)，不截图也不写入任何文件")
+	fs.BoolVar(&mf.open, "open", false, "生成成功后用系统默认的查看器打开输出 (xdg-open、open 或 start)")
+	bindImageFlags(fs, &mf.images, &mf.imagesDir)
+	fs.BoolVar(&mf.tui, "tui", false, "交互模式：在终端中调整行数、列数与质量，预览采样时间点并反复生成")
+	fs.BoolVar(&mf.rpcStdio, "rpc-stdio", false, "在标准输入输出上以按行分隔的 JSON-RPC 2.0 提供 generate、probe 与 cancel，供图形界面或编辑器插件调用")
+	fs.BoolVar(&mf.copyPath, "copy-path", false, "生成成功后把输出的绝对路径复制到剪贴板")
+	fs.BoolVar(&mf.copyImage, "copy-image", false, "生成成功后把拼图图片复制到剪贴板 (Linux 需要 wl-copy 或 xclip)")
+	fs.BoolVar(&mf.version, "version", false, "输出版本、提交、构建时间及检测到的 ffmpeg/ffprobe 版本")
+	bindToolFlags(fs)
+	bindFontFlags(fs)
+	bindProfileFlags(fs)
+	mf.grid = bindGridFlags(fs)
+	return fs, mf
+}
+
+func parseFlags(args []string) (*gridConfig, error) {
+	fs, mf := newMainFlagSet()
+	if err := parseArgs(fs, args); err != nil {
+		return nil, err
+	}
+	if mf.version {
+		return nil, errVersionRequested
+	}
+	if mf.rpcStdio {
+		return nil, errRPCStdioRequested
+	}
+	inputs, err := positionalInputs(fs)
+	if err != nil {
+		return nil, err
+	}
+	if len(inputs) > 0 {
+		switch {
+		case mf.input != "":
+			return nil, errors.New("不能同时使用 --input 与位置参数指定输入视频")
+		case mf.manifest != "":
+			return nil, errors.New("位置参数不能与 --manifest 同时使用")
+		case len(inputs) > 1 && flagWasSet(fs, "output"):
+			return nil, errors.New("指定多个输入视频时不能使用 --output，拼图保存在各视频所在目录")
+		case len(inputs) > 1 && (mf.open || mf.copyPath || mf.copyImage):
+			return nil, errors.New("open、copy-path 与 copy-image 只支持单个输入视频")
+		}
+		mf.input = inputs[0]
+	}
+
+	images, err := imagePaths(mf.images, mf.imagesDir)
+	if err != nil {
+		return nil, err
+	}
+	if len(images) > 0 {
+		switch {
+		case mf.input != "" || mf.manifest != "":
+			return nil, errors.New("images 与 images-dir 拼接已有图片，不能再指定输入视频或 --manifest")
+		case mf.dryRun || mf.tui:
+			return nil, errors.New("images 与 images-dir 不能与 dry-run 或 tui 同时使用")
+		}
+		if err := checkComposeFlags(fs); err != nil {
+			return nil, err
+		}
+		mf.input = images[0]
+	}
+
+	if mf.input == "" && mf.manifest == "" {
+		return nil, errors.New("必须指定输入视频路径 (--input 或位置参数) 或任务清单 --manifest")
+	}
+
+	cfg, err := mf.grid.config()
+	if err != nil {
+		return nil, err
+	}
+	cfg.input = mf.input
+	cfg.images = images
+	cfg.output = mf.output
+	cfg.extraOutputs = mf.extraOutputs
+	cfg.manifest = mf.manifest
+	if mf.workers < 1 {
+		return nil, errors.New("workers 必须为正整数")
+	}
+	cfg.workers = mf.workers
+	cfg.stateFile = mf.stateFile
+	cfg.resume = !mf.noResume
+	cfg.report = mf.report
+	if cfg.report != "" {
+		if _, err := reportFormat(cfg.report); err != nil {
+			return nil, err
+		}
+	}
+	if mf.dryRun {
+		switch {
+		case cfg.manifest != "":
+			return nil, errors.New("dry-run 不能与 manifest 同时使用，可先对清单中的单个视频试运行")
+		case isRemoteURI(cfg.output) || cfg.publish != "":
+			return nil, errors.New("dry-run 不能与对象存储输出或 publish 同时使用")
+		case cfg.at != nil || cfg.artwork != "" || cfg.trickplay != "":
+			return nil, errors.New("dry-run 不能与 at、artwork 或 trickplay 同时使用")
+		}
+		cfg.dryRun = true
+	}
+	if mf.open {
+		switch {
+		case cfg.manifest != "":
+			return nil, errors.New("open 不能与 manifest 同时使用")
+		case cfg.output == "-" || isRemoteURI(cfg.output):
+			return nil, errors.New("open 需要输出到本地文件")
+		}
+		cfg.open = !cfg.dryRun
+	}
+	if mf.copyPath || mf.copyImage {
+		switch {
+		case mf.copyPath && mf.copyImage:
+			return nil, errors.New("copy-path 与 copy-image 不能同时使用")
+		case cfg.manifest != "":
+			return nil, errors.New("copy-path 与 copy-image 不能与 manifest 同时使用")
+		case cfg.output == "-":
+			return nil, errors.New("输出到标准输出时不能复制到剪贴板")
+		case mf.copyImage && isRemoteURI(cfg.output):
+			return nil, errors.New("copy-image 需要输出到本地文件")
+		}
+		cfg.copyPath = mf.copyPath && !cfg.dryRun
+		cfg.copyImage = mf.copyImage && !cfg.dryRun
+	}
+	if mf.tui {
+		switch {
+		case cfg.manifest != "" || len(inputs) > 1:
+			return nil, errors.New("tui 只支持单个输入视频")
+		case cfg.input == "-" || cfg.output == "-":
+			return nil, errors.New("tui 不能从标准输入读取视频或输出到标准输出")
+		case cfg.dryRun:
+			return nil, errors.New("tui 已在界面中预览采样时间点，不能与 dry-run 同时使用")
+		}
+		cfg.tui = true
+	}
+	if mf.frameHook != "" {
+		if cfg.backend == "ffmpeg-tile" {
+			return nil, errors.New("--backend ffmpeg-tile 不支持 --frame-hook，请改用默认的 go 后端")
+		}
+		if cfg.frameHook, err = frameHookCommand(cfg, mf.frameHook); err != nil {
+			return nil, err
+		}
+	}
+	// 未显式指定 --output 时，默认文件名的扩展名跟随 --format (含预设中的格式)。
+	if cfg.format != "" && !flagWasSet(fs, "output") {
+		cfg.output = "preview" + formatExtension(cfg.format)
+	}
+	// 以位置参数给出输入时，默认输出放在视频所在目录，便于拖放文件到程序上直接生成。
+	if len(inputs) > 0 && !flagWasSet(fs, "output") {
+		cfg.output = positionalOutput(cfg, cfg.input)
+	}
+	if len(inputs) > 1 {
+		cfg.inputs = inputs
+	}
+	if cfg.splitSheets() && (cfg.output == "-" || isRemoteURI(cfg.output)) {
+		return nil, errors.New("--segment 与 --sheet-per-chapter 需要输出到本地文件")
+	}
+	if cfg.publish != "" && cfg.manifest == "" && (cfg.output == "-" || isMontageOutput(cfg.output)) {
+		return nil, errors.New("--publish 需要输出图片文件")
+	}
+	if len(cfg.variants) > 0 && cfg.manifest == "" && (cfg.output == "-" || isRemoteURI(cfg.output) || isMontageOutput(cfg.output)) {
+		return nil, errors.New("--variants 需要输出到本地图片文件")
+	}
+	if cfg.at != nil && cfg.manifest == "" && isMontageOutput(cfg.output) {
+		return nil, errors.New("--at 只能输出图片")
+	}
+	if (cfg.artwork != "" || cfg.trickplay != "") && cfg.manifest == "" {
+		switch {
+		case cfg.input == "-" || isRemoteURI(cfg.input):
+			return nil, errors.New("artwork 与 trickplay 把图片保存在视频所在目录，需要本地输入文件")
+		case flagWasSet(fs, "output"):
+			return nil, errors.New("artwork 与 trickplay 把图片保存在视频所在目录，不能指定 --output")
+		}
+	}
+	if cfg.embedCover && cfg.manifest == "" {
+		if err := checkCoverTarget(cfg); err != nil {
+			return nil, err
+		}
+	}
+	if len(cfg.extraOutputs) > 0 {
+		if err := validateExtraOutputs(cfg); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.maxBytes > 0 && cfg.manifest == "" {
+		if format, err := outputFormat(cfg.output, cfg.format); isMontageOutput(cfg.output) || err == nil && format != "jpeg" && format != "webp" {
+			return nil, errors.New("--max-bytes 只支持 JPEG 与 WebP 输出")
+		}
+	}
+	return cfg, nil
+}
+
+func flagWasSet(fs *flag.FlagSet, name string) bool {
+	set := false
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
+type gridFlags struct {
+	fs         *flag.FlagSet
+	preset     string
+	cfg        gridConfig
+	background string
+	textColor  string
+	outline    string
+	gapX       string
+	gapY       string
+	padding    string
+	frames     string
+	percents   string
+	at         string
+	variants   string
+	maxBytes   string
+	crop       string
+	headerTmpl string
+	footer     string
+}
+
+func bindGridFlags(fs *flag.FlagSet) *gridFlags {
+	gf := &gridFlags{fs: fs}
+	cfg := &gf.cfg
+
+	fs.StringVar(&gf.preset, "preset", "", "使用预设参数组合: torrent、web、archive 或用户预设文件中定义的名称，命令行参数优先")
+
+	fs.IntVar(&cfg.rows, "rows", 3, "九宫格行数")
+	fs.IntVar(&cfg.cols, "cols", 3, "九宫格列数")
+	fs.IntVar(&cfg.cellWidth, "cell-width", 320, "单个截图目标宽度 (像素)")
+	fs.IntVar(&cfg.cellHeight, "cell-height", 0, "单个截图目标高度 (像素)，为 0 时按视频比例自适应")
+	fs.IntVar(&cfg.maxWidth, "max-width", 0, "最终拼图的最大宽度 (像素，含信息栏等)，超出时自动缩小单格尺寸；为 0 时不限制")
+	fs.IntVar(&cfg.maxHeight, "max-height", 0, "最终拼图的最大高度 (像素，含信息栏等)，超出时自动缩小单格尺寸；为 0 时不限制")
+	fs.StringVar(&cfg.layout, "layout", "grid", "拼图布局: grid (均匀网格) 或 mosaic (第一帧以 2x2 尺寸作为主图，其余帧环绕填充)")
+	fs.StringVar(&cfg.layoutFile, "layout-file", "", "JSON 布局描述文件，自定义每个单格的位置尺寸 (网格单位或像素)、帧序号及标签，指定后忽略 --layout/--rows/--cols")
+	fs.StringVar(&cfg.style, "style", "plain", "单格样式: plain (直接贴图) 或 polaroid (白边相纸、时间说明、随机倾斜与投影)")
+	fs.IntVar(&cfg.margin, "margin", 8, "截图之间及四周的边距 (像素)，未指定 --gap-x/--gap-y/--padding 时作为它们的默认值")
+	fs.StringVar(&gf.gapX, "gap-x", "", "截图之间的水平间距，像素或相对单格宽度的百分比 (例如 12 或 5%)")
+	fs.StringVar(&gf.gapY, "gap-y", "", "截图之间的垂直间距，像素或相对单格高度的百分比")
+	fs.StringVar(&gf.padding, "padding", "", "拼图四周的外边距，像素或百分比 (左右相对单格宽度，上下相对单格高度)")
+	fs.IntVar(&cfg.jpegQuality, "quality", 90, "输出 JPEG 时的质量 (1-100)")
+	fs.StringVar(&cfg.format, "format", "", "输出格式 (png、jpeg、webp、tiff 或 bmp)，指定后忽略扩展名")
+	fs.StringVar(&gf.maxBytes, "max-bytes", "", "JPEG/WebP 拼图的文件大小上限 (例如 4MB 或 500KiB)，超出时自动降低质量，必要时缩小拼图")
+	fs.IntVar(&cfg.dpi, "dpi", 0, "在 PNG/JPEG/TIFF 输出中记录的物理分辨率 (每英寸像素数，例如 300)，便于按实际尺寸打印；为 0 时不写入")
+	fs.StringVar(&gf.variants, "variants", "", "同时输出缩小到这些宽度的拼图 (像素，逗号分隔，例如 960,1920)，文件名追加 _960 等宽度后缀")
+	fs.StringVar(&gf.background, "background", "#FFFFFF", "背景色 (HEX，例如 #202020 或 #FFFFFFFF)")
+	fs.StringVar(&cfg.saveFramesDir, "save-frames", "", "同时将每张原始截图按序号保存到该目录")
+	fs.StringVar(&cfg.frameFormat, "frame-format", "png", "单帧截图格式 (png、jpeg、webp、tiff 或 bmp)")
+	fs.IntVar(&cfg.frameQuality, "frame-quality", 0, "单帧截图为 JPEG 时的质量 (1-100)，为 0 时沿用 --quality")
+	fs.BoolVar(&cfg.framesOnly, "frames-only", false, "只保存单帧截图，不生成拼接图 (需配合 --save-frames)")
+	fs.StringVar(&cfg.animOutput, "anim-output", "", "同时用采样帧生成动态 WebP 悬停预览 (例如 hover.webp)")
+	fs.IntVar(&cfg.animWidth, "anim-width", 240, "动态预览宽度 (像素)，高度按视频比例自适应")
+	fs.Float64Var(&cfg.animFPS, "anim-fps", 2, "动态预览帧率 (每秒帧数)")
+	fs.StringVar(&gf.frames, "frame-numbers", "", "按帧序号截图 (从 0 开始，逗号分隔，例如 100,2500,88000)，按探测到的帧率换算并精确定位，取代均匀采样")
+	fs.StringVar(&gf.percents, "percentages", "", "在采样范围的指定百分比处截图 (逗号分隔，例如 10,25,50,75,90)，取代均匀采样")
+	fs.StringVar(&cfg.artwork, "artwork", "", "在视频所在目录生成媒体中心命名与尺寸的海报、背景图与缩略图: kodi、jellyfin 或 plex")
+	fs.StringVar(&cfg.trickplay, "trickplay", "", "在视频所在目录生成媒体服务器的拖动预览图: jellyfin (拼图目录) 或 bif (Emby/Roku/Plex 使用的 BIF 文件)")
+	fs.StringVar(&gf.at, "at", "", "只在该位置截取一张缩略图，不拼接网格: 百分比 (37%) 或时间 (00:12:30、750)，按 --cell-width/--cell-height 缩放")
+	fs.DurationVar(&cfg.interval, "interval", 0, "每隔该时长截取一帧 (例如 30s)，未指定 --rows 时按截图数自动确定行数，超过 --max-cells 时分页输出多张拼图")
+	fs.IntVar(&cfg.maxCells, "max-cells", 100, "--interval 时单张拼图最多包含的截图数，超出时分页输出 (文件名追加 _001 等序号)")
+	fs.BoolVar(&cfg.avoidFreeze, "avoid-freeze", false, "先用 freezedetect 检测静止画面 (循环片头、暂停的录屏等)，把采样点重新分布到静止段以外，需完整解码一遍视频")
+	fs.BoolVar(&cfg.snapToKeyframe, "snap-to-keyframe", false, "把每个采样点移到最近的关键帧 (I 帧)，压缩率高的片源画面更干净，截图标注显示关键帧的实际时间")
+	fs.BoolVar(&cfg.keyframesOnly, "keyframes-only", false, "只解码关键帧，每个采样点取其之前最近的关键帧，速度快数倍但时间点不精确")
+	fs.StringVar(&cfg.stream, "stream", "", "截图所用的视频流: v:N 为第 N 条视频流 (从 0 开始)，纯数字为流的绝对序号，为空时使用第一条视频流")
+	fs.StringVar(&cfg.deinterlace, "deinterlace", "off", "检测到隔行扫描片源 (ffprobe 场序为 tt/bb/tb/bt) 时使用的反交错滤镜: off、yadif 或 bwdif")
+	fs.StringVar(&cfg.sample, "sample", "uniform", "采样时间点: uniform (均匀分布) 或 random (随机分布且相邻采样点不过近)")
+	fs.Int64Var(&cfg.seed, "seed", 0, "--sample random 的随机种子，为 0 时按文件名确定，同一文件每次结果相同")
+	fs.StringVar(&cfg.selector, "selector", "uniform", "选帧方式: uniform (均匀时间点)、thumbnail (每段内用 thumbnail 滤镜挑选代表帧) 或 scene (每段内镜头切换后的第一帧)")
+	fs.StringVar(&cfg.pipeCodec, "pipe-codec", "png", "ffmpeg 经管道传回截图的格式: png、mjpeg (解码快数倍，画质损失在缩略图中可忽略) 或 rawvideo (未压缩，不需编解码但数据量最大)")
+	fs.IntVar(&cfg.retries, "retries", 0, "读取视频信息或截图遇到暂时性错误 (网络输入中断、NFS 读取超时等) 时的最大重试次数，文件损坏、编码不受支持等错误不重试")
+	fs.DurationVar(&cfg.retryDelay, "retry-delay", time.Second, "第一次重试前的等待时间，之后每次重试加倍")
+	fs.BoolVar(&cfg.prescale, "prescale", false, "由 ffmpeg 先把截图缩小到截图位置的尺寸再传回，处理 8K 等超高分辨率片源时大幅降低内存占用 (不能与 --save-frames 同时使用)")
+	fs.StringVar(&cfg.backend, "backend", "go", "处理后端: go (逐帧调用 ffmpeg 截图后在 Go 中拼接，支持全部样式)、libav (进程内解码，需使用 -tags libav 构建) 、persistent (单个 ffmpeg 进程截取全部截图后在 Go 中拼接) 或 ffmpeg-tile (单条 ffmpeg 命令完成采样与拼接，速度最快)")
+	fs.Float64Var(&cfg.clipDuration, "clip-duration", 1, "输出为 .mp4 时每个采样点截取的片段时长 (秒)")
+	fs.StringVar(&cfg.tiffCompression, "tiff-compression", "lzw", "输出 TIFF 时的压缩方式 (none、lzw 或 deflate)")
+	fs.BoolVar(&cfg.jpegProgressive, "jpeg-progressive", false, "输出渐进式 JPEG")
+	fs.StringVar(&cfg.jpegSubsampling, "jpeg-subsampling", "420", "JPEG 色度采样 (420 或 444，444 可避免文字边缘色彩溢出)")
+	fs.StringVar(&cfg.pngCompression, "png-compression", "default", "PNG 压缩级别 (none、fast、default 或 best)")
+	fs.IntVar(&cfg.pngColors, "png-colors", 0, "将 PNG 量化为不超过该数量的调色板颜色 (2-256)，为 0 时保留真彩色")
+	fs.BoolVar(&cfg.pngDither, "png-dither", true, "调色板量化时使用 Floyd-Steinberg 抖动")
+	fs.BoolVar(&cfg.embedMetadata, "embed-metadata", true, "在 PNG/JPEG 输出中写入来源文件、时长、采样时间点及工具版本 (XMP/EXIF)")
+	fs.BoolVar(&cfg.embedCover, "embed-cover", false, "生成后把输出图片写回 MP4/MOV/MKV 输入作为封面 (会就地修改输入视频)")
+	fs.BoolVar(&cfg.deterministic, "deterministic", false, "确定性输出: 不嵌入元数据与生成时间，ffmpeg 编码使用 bitexact，相同输入多次运行得到逐字节相同的文件")
+	fs.BoolVar(&cfg.header, "header", false, "在拼图顶部添加文件名、大小、时长、分辨率、编码以及全部音轨与字幕轨信息栏")
+	fs.StringVar(&gf.headerTmpl, "header-template", "", "用 Go text/template 自定义信息栏内容 (例如 \"{{.Filename}} • {{.Duration}} • {{.Codec}} {{.Width}}x{{.Height}}\")，指定后自动启用 --header")
+	fs.StringVar(&gf.footer, "footer", "", "在拼图底部添加自定义文字栏 (例如 \"Encoded by X | internal use only\")，字体与排版与信息栏相同，\\n 换行")
+	fs.BoolVar(&cfg.loudness, "loudness", false, "在信息栏中加入第一条音轨的 EBU R128 综合响度、响度范围与真峰值 (需配合 --header)")
+	fs.BoolVar(&cfg.waveform, "waveform", false, "在拼图下方添加第一条音轨的波形条，并标出各截图的采样时间点")
+	fs.BoolVar(&cfg.bitrateGraph, "bitrate-graph", false, "在拼图下方添加视频码率随时间 (每秒) 变化的柱状图，并标出各截图的采样时间点")
+	fs.BoolVar(&cfg.timestamps, "timestamps", false, "在每张截图右下角标注采样时间 (HH:MM:SS)")
+	fs.BoolVar(&cfg.numberCells, "number-cells", false, "在每张截图左上角标注序号 (1..N)，便于在审阅意见中引用")
+	fs.BoolVar(&cfg.smartLabels, "smart-labels", false, "按画面亮度为时间戳选择最暗的角落并加深标注底色，避免在明亮画面上看不清")
+	fs.StringVar(&gf.textColor, "text-color", "#FFFFFF", "时间戳、序号与标签的文字颜色 (HEX)")
+	fs.IntVar(&cfg.textOutline, "text-outline", 0, "为时间戳、序号与标签的文字加上指定像素宽的描边并去掉半透明底色 (0 为不描边)")
+	fs.StringVar(&gf.outline, "text-outline-color", "#000000", "文字描边颜色 (HEX)")
+	fs.StringVar(&cfg.timestampFormat, "timestamp-format", "clock", "截图时间标注格式: clock (HH:MM:SS)、seconds (秒数)、frames (帧序号) 或 smpte (HH:MM:SS:FF，从文件的起始时间码起算)")
+	fs.BoolVar(&cfg.sidecar, "sidecar", false, "在输出图片旁写入同名 .json 元数据文件 (视频信息与采样时间点)")
+	fs.DurationVar(&cfg.segment, "segment", 0, "将长视频按该时长拆分 (例如 10m)，每段生成一张拼图，输出文件名依次追加 _001、_002 等序号")
+	fs.BoolVar(&cfg.sheetPerChapter, "sheet-per-chapter", false, "为每个章节单独生成一张拼图，输出文件名追加章节序号与标题")
+	fs.StringVar(&cfg.checksum, "checksum", "", "计算源文件的校验值 (sha256、crc32 或 blake3) 并写入信息栏与 .json 元数据文件")
+	fs.StringVar(&cfg.mediaInfo, "mediainfo", "", "同时写出 MediaInfo 格式的容器与流信息文本文件 (例如 info.txt)")
+	fs.StringVar(&cfg.cacheDir, "cache-dir", "", "截图缓存目录，为空时使用系统缓存目录下的 video-preview-image/frames")
+	fs.Int64Var(&cfg.cacheMaxMB, "cache-max-mb", 2048, "截图缓存的大小上限 (MB)，超过后按最近使用时间删除最旧的截图，为 0 时不限制")
+	fs.BoolVar(&cfg.noCache, "no-cache", false, "不读取也不写入截图缓存")
+	fs.StringVar(&cfg.storage.s3Region, "s3-region", "", "访问 s3:// 输入输出时使用的区域，为空时沿用 AWS 配置")
+	fs.StringVar(&cfg.storage.s3Endpoint, "s3-endpoint", "", "S3 兼容服务的自定义端点 (例如 MinIO)，使用路径风格访问")
+	fs.BoolVar(&cfg.storage.downloadInput, "download-input", false, "先将 s3://、gs://、az:// 输入下载到临时目录再处理，默认使用预签名地址流式读取")
+	fs.StringVar(&cfg.webhook, "webhook", "", "任务结束后向该地址 POST JSON 通知 (输入、输出、时长、采样时间点及错误信息)")
+	fs.StringVar(&cfg.publish, "publish", "", "生成后将拼图上传到图床 (imgbb、catbox 或 custom)，并输出可直接粘贴的代码")
+	fs.StringVar(&cfg.publishOpts.snippet, "publish-format", "bbcode", "上传后输出的代码格式 (bbcode 或 markdown)")
+	fs.StringVar(&cfg.publishOpts.imgbbKey, "imgbb-key", "", "imgbb API 密钥，也可通过 VPI_IMGBB_KEY 环境变量提供")
+	fs.StringVar(&cfg.publishOpts.catboxUser, "catbox-userhash", "", "catbox 账户的 userhash，为空时匿名上传")
+	fs.StringVar(&cfg.publishOpts.customURL, "publish-url", "", "--publish custom 时的上传地址 (以 multipart 表单 POST)")
+	fs.StringVar(&cfg.publishOpts.customField, "publish-field", "file", "--publish custom 时文件所在的表单字段名")
+	fs.StringVar(&cfg.publishOpts.customToken, "publish-token", "", "--publish custom 时以 Authorization: Bearer 发送的令牌")
+	fs.StringVar(&cfg.publishOpts.customJSONKey, "publish-json-key", "url", "--publish custom 响应为 JSON 时图片地址所在的字段路径 (以点分隔，例如 data.url)")
+	fs.BoolVar(&cfg.autoLevels, "auto-levels", false, "按亮度直方图自动拉伸每张截图的色阶，提亮夜景等偏暗画面 (不影响 --save-frames 保存的原始截图)")
+	fs.IntVar(&cfg.rotate, "rotate", 0, "将每张截图顺时针旋转 90、180 或 270 度，用于方向错误且没有旋转元数据的片源")
+	fs.StringVar(&cfg.flip, "flip", "", "将每张截图水平 (h) 或垂直 (v) 翻转")
+	fs.StringVar(&gf.crop, "crop", "", "只截取画面中的区域 x,y,w,h (像素或百分比，例如 75%,0,25%,15%)，坐标相对于旋转后的显示画面")
+	fs.BoolVar(&cfg.denoise, "denoise", false, "缩放前对截图做空间降噪 (hqdn3d)，适合噪点多的片源")
+	fs.BoolVar(&cfg.deband, "deband", false, "缩放前消除低码率片源渐变区域的色带 (deband)")
+	fs.StringVar(&cfg.lut, "lut", "", "截图时应用的 3D LUT 文件 (.cube 等，通过 ffmpeg lut3d 滤镜)，用于 log 编码的摄影机素材")
+	fs.BoolVar(&cfg.colorManagement, "color-management", true, "按视频标注的色彩矩阵/范围 (BT.601/709/2020，HDR 色调映射) 转换截图为 sRGB，并在输出中标注 sRGB/ICC")
+
+	return gf
+}
+
+func (gf *gridFlags) config() (*gridConfig, error) {
+	if gf.preset != "" {
+		if err := applyPreset(gf.fs, gf.preset); err != nil {
+			return nil, err
+		}
+	}
+	cfg := gf.cfg
+
+	if gf.frames != "" {
+		frames, err := parseFrameNumbers(gf.frames)
+		if err != nil {
+			return nil, err
+		}
+		cfg.frameNumbers = frames
+	}
+	if gf.percents != "" {
+		percentages, err := parsePercentages(gf.percents)
+		if err != nil {
+			return nil, err
+		}
+		cfg.percentages = percentages
+	}
+	// 按帧序号、百分比或间隔采样时，未指定行数的网格按截图数自动确定行数。
+	cfg.autoRows = cfg.layoutFile == "" && cfg.layout == "grid" && !flagWasSet(gf.fs, "rows")
+	if count := len(cfg.frameNumbers) + len(cfg.percentages); count > 0 && cfg.autoRows && cfg.cols > 0 {
+		cfg.rows = (count + cfg.cols - 1) / cfg.cols
+	}
+
+	if cfg.rows <= 0 || cfg.cols <= 0 {
+		return nil, errors.New("rows 和 cols 必须为正整数")
+	}
+
+	if cfg.layoutFile != "" {
+		layout, err := loadLayoutFile(cfg.layoutFile)
+		if err != nil {
+			return nil, err
+		}
+		cfg.customLayout = layout
+	} else if _, err := buildLayout(cfg.layout, cfg.rows, cfg.cols); err != nil {
+		return nil, err
+	}
+
+	if err := validateStyle(cfg.style); err != nil {
+		return nil, err
+	}
+
+	if cfg.cellWidth <= 0 {
+		return nil, errors.New("cell-width 必须为正整数")
+	}
+	if cfg.maxWidth < 0 || cfg.maxHeight < 0 {
+		return nil, errors.New("max-width 与 max-height 不能为负数")
+	}
+
+	if cfg.margin < 0 {
+		return nil, errors.New("margin 不能为负数")
+	}
+
+	for _, option := range []struct {
+		name  string
+		value string
+		dst   *spacing
+	}{
+		{"gap-x", gf.gapX, &cfg.gapX},
+		{"gap-y", gf.gapY, &cfg.gapY},
+		{"padding", gf.padding, &cfg.padding},
+	} {
+		if option.value == "" {
+			*option.dst = pixelSpacing(cfg.margin)
+			continue
+		}
+		value, err := parseSpacing(option.name, option.value)
+		if err != nil {
+			return nil, err
+		}
+		*option.dst = value
+	}
+
+	if cfg.jpegQuality < 1 || cfg.jpegQuality > 100 {
+		return nil, errors.New("quality 范围为 1-100")
+	}
+
+	if cfg.format != "" {
+		format, err := normalizeFormat(cfg.format)
+		if err != nil {
+			return nil, err
+		}
+		cfg.format = format
+	}
+
+	frameFormat, err := normalizeFormat(cfg.frameFormat)
+	if err != nil {
+		return nil, fmt.Errorf("不支持的单帧截图格式: %s", cfg.frameFormat)
+	}
+	cfg.frameFormat = frameFormat
+
+	if cfg.frameQuality == 0 {
+		cfg.frameQuality = cfg.jpegQuality
+	}
+	if cfg.frameQuality < 1 || cfg.frameQuality > 100 {
+		return nil, errors.New("frame-quality 范围为 1-100")
+	}
+
+	if cfg.animOutput != "" {
+		if cfg.animWidth <= 0 {
+			return nil, errors.New("anim-width 必须为正整数")
+		}
+		if cfg.animFPS <= 0 {
+			return nil, errors.New("anim-fps 必须大于 0")
+		}
+	}
+
+	switch cfg.tiffCompression {
+	case "none", "lzw", "deflate":
+	default:
+		return nil, fmt.Errorf("不支持的 TIFF 压缩方式: %s", cfg.tiffCompression)
+	}
+
+	switch cfg.jpegSubsampling {
+	case "420", "444":
+	default:
+		return nil, fmt.Errorf("不支持的 JPEG 色度采样: %s", cfg.jpegSubsampling)
+	}
+
+	switch cfg.pngCompression {
+	case "none", "fast", "default", "best":
+	default:
+		return nil, fmt.Errorf("不支持的 PNG 压缩级别: %s", cfg.pngCompression)
+	}
+
+	if cfg.pngColors != 0 && (cfg.pngColors < 2 || cfg.pngColors > 256) {
+		return nil, errors.New("png-colors 范围为 2-256")
+	}
+
+	if cfg.stream != "" && !streamSpecPattern.MatchString(cfg.stream) {
+		return nil, fmt.Errorf("无效的视频流: %s (应为 v:N 或流序号，例如 v:1)", cfg.stream)
+	}
+
+	if err := validateTimestampFormat(cfg.timestampFormat); err != nil {
+		return nil, err
+	}
+
+	if err := validateDeinterlace(cfg.deinterlace); err != nil {
+		return nil, err
+	}
+
+	if err := validateOrientation(cfg.rotate, cfg.flip); err != nil {
+		return nil, err
+	}
+
+	// 嵌入的元数据包含生成时间与工具版本，确定性输出时一律不写入。
+	if cfg.deterministic {
+		cfg.embedMetadata = false
+	}
+
+	if cfg.dpi < 0 {
+		return nil, errors.New("dpi 不能为负数")
+	}
+
+	if gf.maxBytes != "" {
+		if cfg.maxBytes, err = parseByteSize(gf.maxBytes); err != nil {
+			return nil, err
+		}
+	}
+
+	if gf.variants != "" {
+		variants, err := parseVariants(gf.variants)
+		if err != nil {
+			return nil, err
+		}
+		cfg.variants = variants
+	}
+
+	if gf.crop != "" {
+		crop, err := parseCrop(gf.crop)
+		if err != nil {
+			return nil, err
+		}
+		cfg.crop = crop
+	}
+
+	if err := validateSelector(cfg.selector); err != nil {
+		return nil, err
+	}
+
+	if err := validateSampleMode(cfg.sample); err != nil {
+		return nil, err
+	}
+	if cfg.sample == "random" && cfg.selector != "uniform" {
+		return nil, errors.New("--sample random 只能与 --selector uniform 同时使用")
+	}
+
+	if cfg.lut != "" {
+		lut, err := validateLUT(cfg.lut)
+		if err != nil {
+			return nil, err
+		}
+		cfg.lut = lut
+	}
+
+	if isRemoteURI(cfg.mediaInfo) {
+		return nil, errors.New("mediainfo 只支持本地路径")
+	}
+
+	if cfg.segment < 0 {
+		return nil, errors.New("segment 不能为负数")
+	}
+	if cfg.segment > 0 && cfg.sheetPerChapter {
+		return nil, errors.New("segment 与 sheet-per-chapter 不能同时使用")
+	}
+
+	if cfg.interval < 0 {
+		return nil, errors.New("interval 不能为负数")
+	}
+	if cfg.interval > 0 {
+		switch {
+		case cfg.maxCells <= 0:
+			return nil, errors.New("max-cells 必须为正整数")
+		case cfg.selector != "uniform" || cfg.sample != "uniform":
+			return nil, errors.New("interval 不能与 --selector 或 --sample 同时使用")
+		case len(cfg.frameNumbers) > 0 || len(cfg.percentages) > 0:
+			return nil, errors.New("interval 不能与 frame-numbers 或 percentages 同时使用")
+		case cfg.splitSheets():
+			return nil, errors.New("interval 不能与 segment 或 sheet-per-chapter 同时使用")
+		}
+	}
+
+	if cfg.artwork != "" {
+		if _, ok := artworkPresets[cfg.artwork]; !ok {
+			return nil, fmt.Errorf("未知的 artwork 预设: %s (可选 %s)", cfg.artwork, artworkNames())
+		}
+		for _, name := range artworkUnsupported {
+			if flagWasSet(gf.fs, name) {
+				return nil, fmt.Errorf("artwork 按预设生成图片，不能与 --%s 同时使用", name)
+			}
+		}
+		if cfg.backend == "ffmpeg-tile" {
+			return nil, errors.New("artwork 不支持 --backend ffmpeg-tile")
+		}
+	}
+
+	if cfg.trickplay != "" {
+		if cfg.trickplay != "jellyfin" && cfg.trickplay != "bif" {
+			return nil, fmt.Errorf("未知的 trickplay 格式: %s (可选 jellyfin、bif)", cfg.trickplay)
+		}
+		for _, name := range trickplayUnsupported {
+			if flagWasSet(gf.fs, name) {
+				return nil, fmt.Errorf("trickplay 不能与 --%s 同时使用", name)
+			}
+		}
+		if cfg.backend == "ffmpeg-tile" {
+			return nil, errors.New("trickplay 不支持 --backend ffmpeg-tile")
+		}
+		if !flagWasSet(gf.fs, "rows") {
+			cfg.rows = defaultTrickplayTiles
+		}
+		if !flagWasSet(gf.fs, "cols") {
+			cfg.cols = defaultTrickplayTiles
+		}
+	}
+
+	if gf.at != "" {
+		for _, name := range thumbnailUnsupported {
+			if flagWasSet(gf.fs, name) {
+				return nil, fmt.Errorf("at 只输出单张截图，不能与 --%s 同时使用", name)
+			}
+		}
+		if cfg.backend == "ffmpeg-tile" {
+			return nil, errors.New("at 不支持 --backend ffmpeg-tile")
+		}
+		if cfg.at, err = parseThumbnailPosition(gf.at); err != nil {
+			return nil, err
+		}
+	}
+
+	if cfg.retries < 0 || cfg.retryDelay < 0 {
+		return nil, errors.New("retries 与 retry-delay 不能为负数")
+	}
+
+	if cfg.avoidFreeze {
+		switch {
+		case cfg.selector != "uniform":
+			return nil, errors.New("avoid-freeze 只能与 --selector uniform 同时使用")
+		case len(cfg.frameNumbers) > 0 || len(cfg.percentages) > 0 || cfg.interval > 0:
+			return nil, errors.New("avoid-freeze 不能与 frame-numbers、percentages 或 interval 同时使用")
+		}
+	}
+
+	if cfg.snapToKeyframe {
+		switch {
+		case cfg.selector != "uniform":
+			return nil, errors.New("snap-to-keyframe 只能与 --selector uniform 同时使用")
+		case len(cfg.frameNumbers) > 0:
+			return nil, errors.New("snap-to-keyframe 不能与 frame-numbers 同时使用")
+		}
+	}
+
+	if len(cfg.frameNumbers) > 0 {
+		switch {
+		case cfg.keyframesOnly:
+			return nil, errors.New("frame-numbers 不能与 keyframes-only 同时使用")
+		case cfg.selector != "uniform" || cfg.sample != "uniform":
+			return nil, errors.New("frame-numbers 不能与 --selector 或 --sample 同时使用")
+		case cfg.splitSheets():
+			return nil, errors.New("frame-numbers 不能与 segment 或 sheet-per-chapter 同时使用")
+		case len(cfg.percentages) > 0:
+			return nil, errors.New("frame-numbers 不能与 percentages 同时使用")
+		}
+	}
+
+	if len(cfg.percentages) > 0 {
+		switch {
+		case cfg.selector != "uniform" || cfg.sample != "uniform":
+			return nil, errors.New("percentages 不能与 --selector 或 --sample 同时使用")
+		case cfg.splitSheets():
+			return nil, errors.New("percentages 不能与 segment 或 sheet-per-chapter 同时使用")
+		}
+	}
+
+	if err := validatePublish(&cfg); err != nil {
+		return nil, err
+	}
+
+	if err := validateChecksum(cfg.checksum); err != nil {
+		return nil, err
+	}
+
+	if cfg.prescale && cfg.saveFramesDir != "" {
+		return nil, errors.New("prescale 会缩小截图，不能与 save-frames 同时使用")
+	}
+	if err := validatePipeCodec(cfg.pipeCodec); err != nil {
+		return nil, err
+	}
+	if err := validateBackend(&cfg); err != nil {
+		return nil, err
+	}
+
+	if cfg.clipDuration <= 0 {
+		return nil, errors.New("clip-duration 必须大于 0")
+	}
+
+	if gf.headerTmpl != "" {
+		tmpl, err := parseHeaderTemplate(gf.headerTmpl)
+		if err != nil {
+			return nil, err
+		}
+		cfg.headerTemplate = tmpl
+		cfg.header = true
+	}
+
+	if gf.footer != "" {
+		cfg.footer = strings.Split(strings.ReplaceAll(gf.footer, `\n`, "\n"), "\n")
+	}
+
+	if cfg.loudness && !cfg.header {
+		return nil, errors.New("loudness 需要同时指定 --header")
+	}
+
+	if cfg.framesOnly && cfg.saveFramesDir == "" {
+		return nil, errors.New("frames-only 需要同时指定 --save-frames")
+	}
+
+	colorValue, err := parseHexColor(gf.background)
+	if err != nil {
+		return nil, err
+	}
+	cfg.background = colorValue
+	if cfg.textColor, err = parseHexColor(gf.textColor); err != nil {
+		return nil, err
+	}
+	if cfg.textOutlineColor, err = parseHexColor(gf.outline); err != nil {
+		return nil, err
+	}
+	if cfg.textOutline < 0 {
+		return nil, errors.New("text-outline 不能为负数")
+	}
+
+	return &cfg, nil
+}
+
+func sampleTimestamps(duration float64, count int) []float64 {
+	if count <= 0 {
+		return nil
+	}
+	if count == 1 {
+		return []float64{duration / 2}
+	}
+
+	timestamps := make([]float64, count)
+	interval := duration / float64(count+1)
+	for i := 0; i < count; i++ {
+		timestamps[i] = interval * float64(i+1)
+	}
+	return timestamps
+}
+
+// formatTimestamp 将秒数格式化为 HH:MM:SS。
+func formatTimestamp(seconds float64) string {
+	total := int(math.Round(seconds))
+	return fmt.Sprintf("%02d:%02d:%02d", total/3600, total/60%60, total%60)
+}
+
+// captureFrame 截取 timestamp 处的一帧；keyframesOnly 为 true 时只解码关键帧，直接返回定位点之前最近的关键帧。
+func captureFrame(ctx context.Context, r Runner, videoPath string, stream int, timestamp float64, filters []string, keyframesOnly bool, codec string) (image.Image, error) {
+	cmd := newCommand(ctx, r, ffmpegPath, captureFrameArgs(videoPath, stream, timestamp, filters, keyframesOnly, codec)...)
+	var stderr stderrTail
+	cmd.Stderr = &stderr
+
+	start := time.Now()
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+
+	if err := cmd.Start(); err != nil {
+		observeToolFailure("capture")
+		return nil, err
+	}
+
+	img, err := decodePipeFrame(bufio.NewReader(stdout), codec)
+	if err != nil {
+		observeToolFailure("capture")
+		// ffmpeg 异常退出时解码只会得到 EOF，错误原因在其 stderr 中。
+		if waitErr := cmd.Wait(); waitErr != nil {
+			return nil, commandError(waitErr, stderr.Bytes())
+		}
+		return nil, err
+	}
+
+	if err := cmd.Wait(); err != nil {
+		observeToolFailure("capture")
+		return nil, commandError(err, stderr.Bytes())
+	}
+
+	framesCaptured.Inc()
+	captureLatency.Observe(time.Since(start).Seconds())
+	return img, nil
+}
+
+func captureFrameArgs(videoPath string, stream int, timestamp float64, filters []string, keyframesOnly bool, codec string) []string {
+	ts := fmt.Sprintf("%.3f", timestamp)
+	args := []string{"-loglevel", "error"}
+	if keyframesOnly {
+		args = append(args, "-skip_frame", "nokey", "-noaccurate_seek")
+	}
+	args = append(args,
+		"-ss", ts,
+		"-i", toolPath(videoPath),
+		"-map", fmt.Sprintf("0:%d", stream),
+		"-frames:v", "1",
+	)
+	if len(filters) > 0 {
+		args = append(args, "-vf", strings.Join(filters, ","))
+	}
+	args = append(args, "-f", "image2pipe")
+	args = append(args, pipeCodecArgs(codec)...)
+	return append(args, "-")
+}
+
+func scaleToFit(img image.Image, maxWidth, maxHeight int) image.Image {
+	bounds := img.Bounds()
+	width := bounds.Dx()
+	height := bounds.Dy()
+
+	scale := math.Min(float64(maxWidth)/float64(width), float64(maxHeight)/float64(height))
+	if scale <= 0 || math.IsInf(scale, 0) || math.IsNaN(scale) {
+		scale = 1
+	}
+
+	newWidth := int(math.Round(float64(width) * scale))
+	newHeight := int(math.Round(float64(height) * scale))
+
+	if newWidth <= 0 {
+		newWidth = 1
+	}
+	if newHeight <= 0 {
+		newHeight = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, newWidth, newHeight))
+	xdraw.ApproxBiLinear.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
+	return dst
+}
+
+func newSheetMetadata(cfg *gridConfig, meta *videoMetadata, timestamps []float64) *sheetMetadata {
+	return &sheetMetadata{
+		source:     filepath.Base(cfg.sourceName()),
+		duration:   meta.duration,
+		timestamps: timestamps,
+		created:    time.Now(),
+	}
+}
+
+func saveFrame(frame image.Image, cfg *gridConfig, meta *videoMetadata, index int, timestamp float64, total int) error {
+	name := strings.TrimSuffix(filepath.Base(cfg.sourceName()), filepath.Ext(cfg.sourceName()))
+	digits := len(strconv.Itoa(total))
+	path := filepath.Join(cfg.saveFramesDir, fmt.Sprintf("%s_%0*d%s", name, digits, index+1, formatExtension(cfg.frameFormat)))
+	opts := cfg.encodeOptions()
+	opts.format = cfg.frameFormat
+	opts.quality = cfg.frameQuality
+	if cfg.embedMetadata {
+		opts.metadata = newSheetMetadata(cfg, meta, []float64{timestamp})
+	}
+	if err := saveImage(frame, path, opts); err != nil {
+		return fmt.Errorf("保存第 %d 张截图失败: %w", index+1, err)
+	}
+	return nil
+}
+
+func parseHexColor(value string) (color.Color, error) {
+	hex := strings.TrimPrefix(strings.TrimSpace(value), "#")
+	switch len(hex) {
+	case 6:
+		r, err := strconv.ParseUint(hex[0:2], 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("解析颜色失败: %w", err)
+		}
+		g, err := strconv.ParseUint(hex[2:4], 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("解析颜色失败: %w", err)
+		}
+		b, err := strconv.ParseUint(hex[4:6], 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("解析颜色失败: %w", err)
+		}
+		return color.NRGBA{uint8(r), uint8(g), uint8(b), 255}, nil
+	case 8:
+		r, err := strconv.ParseUint(hex[0:2], 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("解析颜色失败: %w", err)
+		}
+		g, err := strconv.ParseUint(hex[2:4], 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("解析颜色失败: %w", err)
+		}
+		b, err := strconv.ParseUint(hex[4:6], 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("解析颜色失败: %w", err)
+		}
+		a, err := strconv.ParseUint(hex[6:8], 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("解析颜色失败: %w", err)
+		}
+		return color.NRGBA{uint8(r), uint8(g), uint8(b), uint8(a)}, nil
+	default:
+		return nil, fmt.Errorf("颜色格式必须为 #RRGGBB 或 #RRGGBBAA: %s", value)
+	}
+}
+
+func inferCellHeight(cellWidth, videoWidth, videoHeight int) int {
+	if videoWidth <= 0 || videoHeight <= 0 {
+		return int(float64(cellWidth) * 9.0 / 16.0)
+	}
+	ratio := float64(videoHeight) / float64(videoWidth)
+	height := int(math.Round(float64(cellWidth) * ratio))
+	if height <= 0 {
+		height = int(float64(cellWidth) * 9.0 / 16.0)
+	}
+	return height
+}
+
+func exitWithError(err error) {
+	fmt.Fprintln(os.Stderr, "错误:", err)
+	stopProfiling()
+	waitIfOwnConsole()
+	restoreConsole()
+	os.Exit(1)
+}
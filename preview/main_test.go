@@ -0,0 +1,83 @@
+package preview
+
+import (
+	"context"
+	"image/color"
+	"slices"
+	"strings"
+	"testing"
+
+	"video-preview-image/previewtest"
+)
+
+func TestMain(m *testing.M) {
+	previewtest.Main(m)
+}
+
+func TestCaptureFrame(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	fake := previewtest.NewRunner(t, func(previewtest.Call) previewtest.Result {
+		return previewtest.Result{Stdout: previewtest.PNG(64, 36, red)}
+	})
+
+	img, err := captureFrame(context.Background(), fake, "in.mp4", 2, 12.5, []string{"scale=64:-1"}, false, "png")
+	if err != nil {
+		t.Fatalf("captureFrame: %v", err)
+	}
+	if got := img.Bounds().Size(); got.X != 64 || got.Y != 36 {
+		t.Errorf("截图尺寸为 %v，期望 64x36", got)
+	}
+	if r, g, b, _ := img.At(10, 10).RGBA(); r != 0xffff || g != 0 || b != 0 {
+		t.Errorf("截图颜色为 (%d, %d, %d)，期望红色", r, g, b)
+	}
+
+	calls := fake.Calls()
+	if len(calls) != 1 {
+		t.Fatalf("调用了 %d 次外部程序，期望 1 次", len(calls))
+	}
+	if calls[0].Name != ffmpegPath {
+		t.Errorf("调用的程序为 %s，期望 %s", calls[0].Name, ffmpegPath)
+	}
+	args := strings.Join(calls[0].Args, " ")
+	for _, want := range []string{"-ss 12.500 -i in.mp4", "-map 0:2", "-frames:v 1", "-vf scale=64:-1", "-f image2pipe"} {
+		if !strings.Contains(args, want) {
+			t.Errorf("参数 %q 中缺少 %q", args, want)
+		}
+	}
+	if slices.Contains(calls[0].Args, "-skip_frame") {
+		t.Errorf("未指定 keyframesOnly 时不应跳过非关键帧: %q", args)
+	}
+}
+
+func TestCaptureFrameKeyframesOnly(t *testing.T) {
+	fake := previewtest.NewRunner(t, func(previewtest.Call) previewtest.Result {
+		return previewtest.Result{Stdout: previewtest.PNG(8, 8, color.White)}
+	})
+
+	if _, err := captureFrame(context.Background(), fake, "in.mp4", 0, 3, nil, true, "png"); err != nil {
+		t.Fatalf("captureFrame: %v", err)
+	}
+	args := strings.Join(fake.Calls()[0].Args, " ")
+	if !strings.Contains(args, "-skip_frame nokey -noaccurate_seek -ss 3.000") {
+		t.Errorf("只取关键帧时应在 -ss 之前指定 -skip_frame nokey -noaccurate_seek: %q", args)
+	}
+	if strings.Contains(args, "-vf") {
+		t.Errorf("没有滤镜时不应指定 -vf: %q", args)
+	}
+}
+
+func TestCaptureFrameError(t *testing.T) {
+	fake := previewtest.NewRunner(t, func(previewtest.Call) previewtest.Result {
+		return previewtest.Result{Stderr: "in.mp4: Invalid data found when processing input\n", ExitCode: 1}
+	})
+
+	_, err := captureFrame(context.Background(), fake, "in.mp4", 0, 1, nil, false, "png")
+	if err == nil {
+		t.Fatal("ffmpeg 失败时 captureFrame 应返回错误")
+	}
+	for _, want := range []string{"exit status 1", "Invalid data found when processing input"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("错误 %q 中缺少 %q", err, want)
+		}
+	}
+}
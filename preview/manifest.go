@@ -0,0 +1,239 @@
+package preview
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type manifestJob struct {
+	Input   string `json:"input"`
+	Output  string `json:"output"`
+	Rows    int    `json:"rows"`
+	Cols    int    `json:"cols"`
+	Quality int    `json:"quality"`
+}
+
+func runManifest(base *gridConfig) error {
+	jobs, err := loadManifest(base.manifest)
+	if err != nil {
+		return err
+	}
+	if len(jobs) == 0 {
+		return fmt.Errorf("任务清单为空: %s", base.manifest)
+	}
+
+	statePath := base.stateFile
+	if statePath == "" {
+		statePath = defaultStatePath(base.manifest)
+	}
+	state, err := loadBatchState(statePath, base.resume)
+	if err != nil {
+		return err
+	}
+
+	entries := make([]batchReportEntry, len(jobs))
+	var mu sync.Mutex
+	failed := 0
+	indexes := make(chan int)
+	var workers sync.WaitGroup
+	for w := 0; w < max(base.workers, 1); w++ {
+		workers.Go(func() {
+			for i := range indexes {
+				job := jobs[i]
+				start := time.Now()
+				cfg, err := manifestJobConfig(base, job)
+				if err == nil {
+					err = generatePreview(cfg)
+				}
+				entries[i] = newBatchReportEntry(job, cfg, time.Since(start), err)
+				var stateErr error
+				if err == nil {
+					stateErr = state.markDone(job, cfg.outputName())
+				}
+
+				mu.Lock()
+				if err != nil {
+					failed++
+					fmt.Fprintf(os.Stderr, "错误: 任务 %d (%s) 失败: %v\n", i+1, job.Input, err)
+				} else {
+					fmt.Printf("[%d/%d] %s\n", i+1, len(jobs), resultMessage(cfg))
+				}
+				if stateErr != nil {
+					fmt.Fprintln(os.Stderr, "警告: 保存批量进度失败:", stateErr)
+				}
+				mu.Unlock()
+			}
+		})
+	}
+	skipped := 0
+	for i, job := range jobs {
+		if output, ok := state.completedOutput(job); ok {
+			entries[i] = batchReportEntry{Input: job.Input, Output: output, Status: "skipped"}
+			skipped++
+			continue
+		}
+		indexes <- i
+	}
+	close(indexes)
+	workers.Wait()
+
+	if skipped > 0 {
+		fmt.Printf("已跳过 %d 个在上次运行中完成的任务 (进度文件: %s)\n", skipped, statePath)
+	}
+	if base.report != "" {
+		if err := writeBatchReport(base.report, entries); err != nil {
+			return err
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d/%d 个任务失败，重新运行将只处理未完成的任务", failed, len(jobs))
+	}
+	if err := state.remove(); err != nil {
+		fmt.Fprintln(os.Stderr, "警告: 删除批量进度文件失败:", err)
+	}
+	return nil
+}
+
+func manifestJobConfig(base *gridConfig, job manifestJob) (*gridConfig, error) {
+	cfg := *base
+	cfg.input = job.Input
+	cfg.output = job.Output
+	if cfg.output == "" {
+		ext := ".png"
+		if cfg.format != "" {
+			ext = formatExtension(cfg.format)
+		}
+		cfg.output = previewPathFor(job.Input, "", ext)
+	}
+	if job.Rows != 0 {
+		cfg.rows = job.Rows
+	}
+	if job.Cols != 0 {
+		cfg.cols = job.Cols
+	}
+	if job.Quality != 0 {
+		cfg.jpegQuality = job.Quality
+	}
+
+	if cfg.input == "" {
+		return nil, errors.New("缺少 input 字段")
+	}
+	if cfg.rows <= 0 || cfg.cols <= 0 {
+		return nil, errors.New("rows 和 cols 必须为正整数")
+	}
+	if cfg.jpegQuality < 1 || cfg.jpegQuality > 100 {
+		return nil, errors.New("quality 范围为 1-100")
+	}
+	return &cfg, nil
+}
+
+func loadManifest(path string) ([]manifestJob, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开任务清单失败: %w", err)
+	}
+	defer file.Close()
+
+	var jobs []manifestJob
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".json":
+		if err := json.NewDecoder(file).Decode(&jobs); err != nil {
+			return nil, fmt.Errorf("解析任务清单失败: %w", err)
+		}
+	case ".csv":
+		jobs, err = parseManifestCSV(file)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("不支持的任务清单格式: %s (仅支持 .csv 与 .json)", filepath.Ext(path))
+	}
+
+	// 清单中的相对路径以清单文件所在目录为基准，便于整体移动任务目录。
+	dir := filepath.Dir(path)
+	for i := range jobs {
+		jobs[i].Input = resolveManifestPath(dir, jobs[i].Input)
+		jobs[i].Output = resolveManifestPath(dir, jobs[i].Output)
+	}
+	return jobs, nil
+}
+
+func resolveManifestPath(dir, path string) string {
+	if path == "" || filepath.IsAbs(path) {
+		return path
+	}
+	return filepath.Join(dir, path)
+}
+
+func parseManifestCSV(r io.Reader) ([]manifestJob, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	reader.Comment = '#'
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("读取任务清单表头失败: %w", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	if _, ok := columns["input"]; !ok {
+		return nil, errors.New("任务清单缺少 input 列")
+	}
+
+	var jobs []manifestJob
+	line := 1
+	for {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		line++
+		if err != nil {
+			return nil, fmt.Errorf("解析任务清单第 %d 行失败: %w", line, err)
+		}
+
+		field := func(name string) string {
+			idx, ok := columns[name]
+			if !ok || idx >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[idx])
+		}
+		number := func(name string) (int, error) {
+			value := field(name)
+			if value == "" {
+				return 0, nil
+			}
+			n, err := strconv.Atoi(value)
+			if err != nil {
+				return 0, fmt.Errorf("任务清单第 %d 行 %s 列不是整数: %s", line, name, value)
+			}
+			return n, nil
+		}
+
+		job := manifestJob{Input: field("input"), Output: field("output")}
+		if job.Rows, err = number("rows"); err != nil {
+			return nil, err
+		}
+		if job.Cols, err = number("cols"); err != nil {
+			return nil, err
+		}
+		if job.Quality, err = number("quality"); err != nil {
+			return nil, err
+		}
+		jobs = append(jobs, job)
+	}
+	return jobs, nil
+}
@@ -0,0 +1,121 @@
+package preview
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	// minSizeQuality 为按 --max-bytes 搜索编码质量时的下限，低于该质量后改为缩小拼图。
+	minSizeQuality = 40
+	// minSizeWidth 为按 --max-bytes 缩小拼图时的最小宽度。
+	minSizeWidth = 320
+	maxSizeSteps = 6
+)
+
+// parseByteSize 解析 --max-bytes 的大小，支持 KB/MB/GB (1000 进制) 与 KiB/MiB/GiB (1024 进制) 后缀，不带后缀时为字节数。
+func parseByteSize(value string) (int64, error) {
+	text := strings.TrimSpace(value)
+	units := []struct {
+		suffix string
+		scale  float64
+	}{
+		{"kib", 1 << 10}, {"mib", 1 << 20}, {"gib", 1 << 30},
+		{"kb", 1e3}, {"mb", 1e6}, {"gb", 1e9},
+		{"k", 1e3}, {"m", 1e6}, {"g", 1e9},
+		{"b", 1},
+	}
+	scale := 1.0
+	lower := strings.ToLower(text)
+	for _, unit := range units {
+		if strings.HasSuffix(lower, unit.suffix) {
+			text, scale = strings.TrimSpace(text[:len(text)-len(unit.suffix)]), unit.scale
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(text, 64)
+	if err != nil || n <= 0 || math.IsInf(n, 0) {
+		return 0, fmt.Errorf("无效的文件大小: %s (例如 4MB、500KiB)", value)
+	}
+	return int64(n * scale), nil
+}
+
+// encodeWithinSize 编码 JPEG/WebP 拼图并使文件不超过 opts.maxBytes：先按 --quality 编码，超出时二分搜索
+// 不低于 minSizeQuality 的最高质量；最低质量仍然超出时按面积比例缩小拼图后重新搜索。
+func encodeWithinSize(img image.Image, format string, opts encodeOptions) ([]byte, error) {
+	if format != "jpeg" && format != "webp" {
+		return nil, errors.New("max-bytes 只支持 JPEG 与 WebP 输出")
+	}
+	original := img.Bounds()
+	for step := 0; step < maxSizeSteps; step++ {
+		data, quality, err := searchQuality(img, format, opts)
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(data)) <= opts.maxBytes {
+			if bounds := img.Bounds(); quality != opts.quality || bounds != original {
+				fmt.Fprintf(os.Stderr, "警告: 为满足 --max-bytes %s，拼图以质量 %d、%dx%d 输出 (%s)\n",
+					formatBytes(opts.maxBytes), quality, bounds.Dx(), bounds.Dy(), formatBytes(int64(len(data))))
+			}
+			return data, nil
+		}
+
+		bounds := img.Bounds()
+		scale := math.Sqrt(float64(opts.maxBytes)/float64(len(data))) * 0.95
+		width := int(float64(bounds.Dx()) * scale)
+		if width < minSizeWidth {
+			break
+		}
+		img = scaleToFit(img, width, int(float64(bounds.Dy())*scale))
+	}
+	return nil, fmt.Errorf("拼图缩小到 %d 像素宽仍无法压缩到 %s 以内，请减少截图数或增大 --max-bytes", minSizeWidth, formatBytes(opts.maxBytes))
+}
+
+// searchQuality 返回不超过 opts.maxBytes 的最高质量编码结果；都超出时返回最低质量的编码结果。
+func searchQuality(img image.Image, format string, opts encodeOptions) ([]byte, int, error) {
+	encode := func(quality int) ([]byte, error) {
+		var buf bytes.Buffer
+		o := opts
+		o.quality = quality
+		if err := encodeImage(&buf, img, format, o); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	best, err := encode(opts.quality)
+	if err != nil || int64(len(best)) <= opts.maxBytes {
+		return best, opts.quality, err
+	}
+	low, high := minSizeQuality, opts.quality-1
+	if low > high {
+		return best, opts.quality, nil
+	}
+	bestQuality := 0
+	for low <= high {
+		mid := (low + high) / 2
+		data, err := encode(mid)
+		if err != nil {
+			return nil, 0, err
+		}
+		if int64(len(data)) <= opts.maxBytes {
+			best, bestQuality, low = data, mid, mid+1
+		} else {
+			high = mid - 1
+			if bestQuality == 0 {
+				best = data
+			}
+		}
+	}
+	if bestQuality == 0 {
+		// 没有质量满足限制时 best 为最后一次 (即最低) 尝试的结果。
+		return best, minSizeQuality, nil
+	}
+	return best, bestQuality, nil
+}
@@ -0,0 +1,211 @@
+package preview
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// MediaInfo 文本输出中字段名固定占 41 列，发布站通常按该格式检查 NFO 内容。
+const mediaInfoLabelWidth = 41
+
+var mediaInfoSections = map[string]string{
+	"video":    "Video",
+	"audio":    "Audio",
+	"subtitle": "Text",
+}
+
+// writeMediaInfo 以 MediaInfo 默认文本视图的格式写出容器与各条流的信息。
+func writeMediaInfo(cfg *gridConfig, meta *videoMetadata) error {
+	var buf bytes.Buffer
+	writeMediaInfoText(&buf, cfg.sourceName(), meta)
+	if err := ensureOutputDir(cfg.mediaInfo); err != nil {
+		return err
+	}
+	if err := os.WriteFile(cfg.mediaInfo, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("写入 MediaInfo 文本失败: %w", err)
+	}
+	return nil
+}
+
+func writeMediaInfoText(w io.Writer, source string, meta *videoMetadata) {
+	field := func(label, value string) {
+		if value != "" {
+			fmt.Fprintf(w, "%-*s: %s\n", mediaInfoLabelWidth, label, value)
+		}
+	}
+
+	fmt.Fprintln(w, "General")
+	field("Complete name", filepath.Base(source))
+	field("Format", meta.formatLongName)
+	if meta.size > 0 {
+		field("File size", formatBytes(meta.size))
+	}
+	field("Duration", formatMediaDuration(meta.duration))
+	field("Overall bit rate", formatMediaBitRate(meta.bitRate))
+	field("Movie name", meta.tags["title"])
+	field("Encoded date", meta.tags["creation_time"])
+	field("Writing application", meta.tags["encoder"])
+	if meta.checksum != nil {
+		field(strings.ToUpper(meta.checksum.algorithm), meta.checksum.value)
+	}
+
+	// 内嵌封面与 MediaInfo 一样只在 General 中标注，不单独列为视频流。
+	counts := map[string]int{}
+	for _, stream := range meta.streams {
+		if stream.attachedPic {
+			field("Cover", "Yes")
+			continue
+		}
+		counts[stream.codecType]++
+	}
+	numbers := map[string]int{}
+	for _, stream := range meta.streams {
+		section, ok := mediaInfoSections[stream.codecType]
+		if !ok || stream.attachedPic {
+			continue
+		}
+		numbers[stream.codecType]++
+		if counts[stream.codecType] > 1 {
+			section += " #" + strconv.Itoa(numbers[stream.codecType])
+		}
+
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, section)
+		// MP4 与 Matroska 的轨道编号从 1 开始，与 MediaInfo 显示的 ID 一致。
+		field("ID", strconv.Itoa(stream.index+1))
+		field("Format", stream.codecName)
+		field("Format/Info", stream.codecLongName)
+		field("Format profile", stream.profile)
+		if stream.duration > 0 {
+			field("Duration", formatMediaDuration(stream.duration))
+		}
+		field("Bit rate", formatMediaBitRate(stream.bitRate))
+
+		switch stream.codecType {
+		case "video":
+			field("Width", groupDigits(stream.width)+" pixels")
+			field("Height", groupDigits(stream.height)+" pixels")
+			if stream.rotation != 0 {
+				field("Rotation", strconv.Itoa(stream.rotation)+"°")
+			}
+			if stream.fps > 0 {
+				field("Frame rate", fmt.Sprintf("%.3f FPS", stream.fps))
+			}
+			field("Pixel format", stream.pixelFormat)
+			field("Scan type", scanType(stream.fieldOrder))
+			field("Scan order", scanOrder(stream.fieldOrder))
+			field("Color range", stream.colorRange)
+			field("Color primaries", stream.colorPrimaries)
+			field("Transfer characteristics", stream.colorTransfer)
+			field("Matrix coefficients", stream.colorSpace)
+		case "audio":
+			if stream.channels > 0 {
+				field("Channel(s)", fmt.Sprintf("%d channel%s", stream.channels, plural(stream.channels)))
+			}
+			field("Channel layout", stream.channelLayout)
+			if stream.sampleRate > 0 {
+				field("Sampling rate", strconv.FormatFloat(float64(stream.sampleRate)/1000, 'f', 1, 64)+" kHz")
+			}
+		}
+		field("Title", stream.title)
+		field("Language", stream.language)
+	}
+
+	if len(meta.chapters) > 0 {
+		fmt.Fprintln(w)
+		fmt.Fprintln(w, "Menu")
+		for i, chapter := range meta.chapters {
+			title := chapter.title
+			if title == "" {
+				title = fmt.Sprintf("Chapter %d", i+1)
+			}
+			fmt.Fprintf(w, "%-*s: %s\n", mediaInfoLabelWidth, formatPreciseTimestamp(chapter.start), title)
+		}
+	}
+}
+
+// formatMediaDuration 按 MediaInfo 的习惯只显示最高的两个时间单位，例如 1 h 2 min、1 min 40 s、5 s 300 ms。
+func formatMediaDuration(seconds float64) string {
+	ms := int64(math.Round(seconds * 1000))
+	units := []struct {
+		size int64
+		name string
+	}{{3600000, "h"}, {60000, "min"}, {1000, "s"}, {1, "ms"}}
+	var parts []string
+	for _, u := range units {
+		if value := ms / u.size; value > 0 || len(parts) > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", value, u.name))
+			ms %= u.size
+		}
+		if len(parts) == 2 {
+			break
+		}
+	}
+	if len(parts) == 0 {
+		return "0 ms"
+	}
+	return strings.Join(parts, " ")
+}
+
+func formatMediaBitRate(bitRate int64) string {
+	switch {
+	case bitRate <= 0:
+		return ""
+	case bitRate >= 10_000_000:
+		return fmt.Sprintf("%.1f Mb/s", float64(bitRate)/1e6)
+	default:
+		return groupDigits(int(math.Round(float64(bitRate)/1000))) + " kb/s"
+	}
+}
+
+// groupDigits 以空格分隔千位，例如 1920 显示为 1 920。
+func groupDigits(value int) string {
+	digits := strconv.Itoa(value)
+	var b strings.Builder
+	for i, c := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteByte(' ')
+		}
+		b.WriteRune(c)
+	}
+	return b.String()
+}
+
+func scanType(fieldOrder string) string {
+	switch fieldOrder {
+	case "progressive":
+		return "Progressive"
+	case "tt", "bb", "tb", "bt":
+		return "Interlaced"
+	}
+	return ""
+}
+
+// scanOrder 对应 ffprobe 的场序: tt/tb 为顶场优先，bb/bt 为底场优先。
+func scanOrder(fieldOrder string) string {
+	switch fieldOrder {
+	case "tt", "tb":
+		return "Top Field First"
+	case "bb", "bt":
+		return "Bottom Field First"
+	}
+	return ""
+}
+
+func formatPreciseTimestamp(seconds float64) string {
+	ms := int64(math.Round(seconds * 1000))
+	return fmt.Sprintf("%02d:%02d:%02d.%03d", ms/3600000, ms/60000%60, ms/1000%60, ms%1000)
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
@@ -0,0 +1,103 @@
+package preview
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// 指标在所有模式下都会累计，只有 serve、grpc 与 worker 模式会通过 /metrics 暴露。
+var (
+	metricsRegistry = prometheus.NewRegistry()
+
+	jobsProcessed = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "vpi_jobs_processed_total",
+		Help: "已处理的预览任务数，按结果 (success/failed) 区分。",
+	}, []string{"status"})
+	jobsInProgress = prometheus.NewGauge(prometheus.GaugeOpts{
+		Name: "vpi_jobs_in_progress",
+		Help: "正在处理的预览任务数。",
+	})
+	jobDuration = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "vpi_job_duration_seconds",
+		Help:    "单个预览任务的耗时。",
+		Buckets: prometheus.ExponentialBuckets(0.5, 2, 12),
+	})
+	framesCaptured = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "vpi_frames_captured_total",
+		Help: "成功截取的帧数。",
+	})
+	captureLatency = prometheus.NewHistogram(prometheus.HistogramOpts{
+		Name:    "vpi_frame_capture_duration_seconds",
+		Help:    "单帧截图 (含 ffmpeg 启动、定位与解码) 的耗时。",
+		Buckets: prometheus.ExponentialBuckets(0.05, 2, 10),
+	})
+	ffmpegFailures = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "vpi_ffmpeg_failures_total",
+		Help: "ffmpeg/ffprobe 调用失败次数，按操作 (probe/capture/montage/animation/webp/cover) 区分。",
+	}, []string{"operation"})
+)
+
+func init() {
+	metricsRegistry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		jobsProcessed,
+		jobsInProgress,
+		jobDuration,
+		framesCaptured,
+		captureLatency,
+		ffmpegFailures,
+	)
+	for _, status := range []string{"success", "failed"} {
+		jobsProcessed.WithLabelValues(status)
+	}
+	for _, operation := range []string{"probe", "capture", "montage", "animation", "webp"} {
+		ffmpegFailures.WithLabelValues(operation)
+	}
+}
+
+func observeJob(start time.Time, err error) {
+	jobDuration.Observe(time.Since(start).Seconds())
+	if err != nil {
+		jobsProcessed.WithLabelValues("failed").Inc()
+		return
+	}
+	jobsProcessed.WithLabelValues("success").Inc()
+}
+
+func observeToolFailure(operation string) {
+	ffmpegFailures.WithLabelValues(operation).Inc()
+}
+
+func metricsHandler() http.Handler {
+	return promhttp.HandlerFor(metricsRegistry, promhttp.HandlerOpts{})
+}
+
+// serveMetrics 在后台启动 /metrics 服务，地址为空时不启动。
+func serveMetrics(addr string) error {
+	if addr == "" {
+		return nil
+	}
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("监听指标地址 %s 失败: %w", addr, err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metricsHandler())
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Fprintln(os.Stderr, "警告: 指标服务异常退出:", err)
+		}
+	}()
+	fmt.Printf("指标服务已启动: http://%s/metrics\n", listener.Addr())
+	return nil
+}
@@ -0,0 +1,94 @@
+package preview
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+func isMontageOutput(path string) bool {
+	return strings.ToLower(filepath.Ext(path)) == ".mp4"
+}
+
+// generateMontage 在每个采样时间点附近截取一小段，缩放到单格尺寸后拼接成短片，整个过程只启动一次 ffmpeg。
+func generateMontage(cfg *gridConfig, meta *videoMetadata, timestamps []float64) error {
+	if err := ensureOutputDir(cfg.output); err != nil {
+		return err
+	}
+
+	cmd := newCommand(cfg.context(), cfg.runner, ffmpegPath, montageArgs(cfg, meta, timestamps)...)
+	var stderr stderrTail
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		observeToolFailure("montage")
+		return fmt.Errorf("生成预览短片失败: %w", commandError(err, stderr.Bytes()))
+	}
+	return nil
+}
+
+func montageArgs(cfg *gridConfig, meta *videoMetadata, timestamps []float64) []string {
+	clip := math.Min(cfg.clipDuration, meta.duration)
+	width := evenDimension(cfg.cellWidth)
+	height := evenDimension(cfg.cellHeight)
+	pad := ffmpegColor(cfg.background)
+	var chain []string
+	if meta.anamorphic() {
+		chain = append(chain, sampleAspectFilter)
+	}
+	chain = append(chain, orientationFilters(cfg.rotate, cfg.flip)...)
+	if cfg.crop != nil {
+		chain = append(chain, cfg.crop.filter())
+	}
+	chain = append(chain, fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease", width, height))
+
+	args := []string{"-loglevel", "error", "-y"}
+	var filters []string
+	var labels []string
+	for i, ts := range timestamps {
+		start := math.Max(0, math.Min(ts-clip/2, meta.duration-clip))
+		args = append(args,
+			"-ss", fmt.Sprintf("%.3f", start),
+			"-t", fmt.Sprintf("%.3f", clip),
+			"-i", toolPath(cfg.input),
+		)
+		label := fmt.Sprintf("v%d", i)
+		filters = append(filters, fmt.Sprintf(
+			"[%d:%d]%s,pad=%d:%d:(ow-iw)/2:(oh-ih)/2:color=%s,setsar=1,fps=25,format=yuv420p[%s]",
+			i, cfg.streamIndex, strings.Join(chain, ","), width, height, pad, label,
+		))
+		labels = append(labels, "["+label+"]")
+	}
+	filters = append(filters, fmt.Sprintf("%sconcat=n=%d:v=1:a=0[out]", strings.Join(labels, ""), len(timestamps)))
+
+	args = append(args,
+		"-filter_complex", strings.Join(filters, ";"),
+		"-map", "[out]",
+		"-c:v", "libx264",
+		"-preset", "veryfast",
+		"-crf", strconv.Itoa(montageCRF(cfg.jpegQuality)),
+		"-movflags", "+faststart",
+	)
+	args = append(args, bitexactArgs(cfg.deterministic)...)
+	return append(args, toolPath(cfg.output))
+}
+
+// yuv420p 要求宽高为偶数。
+func evenDimension(value int) int {
+	if value%2 != 0 {
+		value++
+	}
+	return value
+}
+
+// 将 1-100 的质量值映射到 x264 的 CRF 区间 (质量 100 对应 CRF 18，质量 1 对应 CRF 40)。
+func montageCRF(quality int) int {
+	return int(math.Round(40 - float64(quality-1)*22/99))
+}
+
+func ffmpegColor(c color.Color) string {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return fmt.Sprintf("0x%02X%02X%02X", n.R, n.G, n.B)
+}
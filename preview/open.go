@@ -0,0 +1,47 @@
+package preview
+
+import (
+	"context"
+	"fmt"
+	"path/filepath"
+	"runtime"
+)
+
+// openCommand 返回用系统默认程序打开 path 的命令。
+func openCommand(path string) (string, []string) {
+	switch runtime.GOOS {
+	case "darwin":
+		return "open", []string{path}
+	case "windows":
+		// start 的第一个带引号参数会被当作窗口标题，因此先传入空标题。
+		return "cmd", []string{"/c", "start", "", path}
+	default:
+		return "xdg-open", []string{path}
+	}
+}
+
+// resultLocation 返回本次生成的结果位置：通常为输出文件，拆分为多张拼图时为输出所在的目录，只保存单帧时为单帧目录。
+func resultLocation(cfg *gridConfig) string {
+	switch {
+	case cfg.artwork != "":
+		return filepath.Dir(cfg.input)
+	case cfg.trickplay != "":
+		return trickplayPath(cfg)
+	case cfg.framesOnly:
+		return cfg.saveFramesDir
+	case cfg.splitSheets():
+		return filepath.Dir(cfg.output)
+	}
+	return cfg.output
+}
+
+// openResult 在生成成功后用系统默认查看器打开 resultLocation，查看器在后台运行，不等待其退出。
+func openResult(cfg *gridConfig) error {
+	path := resultLocation(cfg)
+	name, args := openCommand(path)
+	cmd := newCommand(context.Background(), cfg.runner, name, args...)
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("打开 %s 失败: %w", path, err)
+	}
+	return cmd.Process.Release()
+}
@@ -0,0 +1,70 @@
+package preview
+
+import "fmt"
+
+func validateOrientation(rotate int, flip string) error {
+	switch rotate {
+	case 0, 90, 180, 270:
+	default:
+		return fmt.Errorf("不支持的旋转角度: %d (可选: 90、180、270)", rotate)
+	}
+	switch flip {
+	case "", "h", "v":
+		return nil
+	default:
+		return fmt.Errorf("不支持的翻转方向: %s (可选: h、v)", flip)
+	}
+}
+
+// orientationFilters 返回 --rotate (顺时针角度) 与 --flip 对应的滤镜，在 ffmpeg 按旋转元数据自动转正之后执行，
+// 用于纠正拍摄方向错误且没有记录旋转信息的片源。
+func orientationFilters(rotate int, flip string) []string {
+	var filters []string
+	switch rotate {
+	case 90:
+		filters = append(filters, "transpose=clock")
+	case 180:
+		filters = append(filters, "hflip", "vflip")
+	case 270:
+		filters = append(filters, "transpose=cclock")
+	}
+	switch flip {
+	case "h":
+		filters = append(filters, "hflip")
+	case "v":
+		filters = append(filters, "vflip")
+	}
+	return filters
+}
+
+// autorotateFilters 返回按旋转元数据 (顺时针角度) 转正画面的滤镜，与 ffmpeg 命令行默认的自动旋转相同。
+// libav 后端需要把它放在其他截图滤镜之前，--crop、--flip 等才相对于转正后的显示画面。
+func autorotateFilters(degrees int) []string {
+	return orientationFilters(quarterTurns(degrees)*90, "")
+}
+
+// quarterTurns 把任意角度 (可以为负) 换算为顺时针旋转的 90 度次数 (0-3)，取最接近的 90 度。
+func quarterTurns(degrees int) int {
+	d := (degrees%360 + 360) % 360
+	return (d + 45) / 90 % 4
+}
+
+// orientedSize 返回画面经 --rotate 调整后的显示尺寸，--crop 的坐标即相对于该画面。
+func (cfg *gridConfig) orientedSize(meta *videoMetadata) (int, int) {
+	width, height := meta.displaySize()
+	if cfg.rotate == 90 || cfg.rotate == 270 {
+		return height, width
+	}
+	return width, height
+}
+
+// frameSize 返回截图经旋转与裁切后的尺寸，用于按比例推算单格高度。
+func (cfg *gridConfig) frameSize(meta *videoMetadata) (int, int) {
+	width, height := cfg.orientedSize(meta)
+	if cfg.crop != nil {
+		if _, _, w, h, err := cfg.crop.rect(width, height); err == nil {
+			return w, h
+		}
+	}
+	return width, height
+}
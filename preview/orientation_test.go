@@ -0,0 +1,56 @@
+package preview
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestQuarterTurns(t *testing.T) {
+	for _, tc := range []struct {
+		degrees, turns int
+	}{
+		{-270, 1}, {-180, 2}, {-150, 2}, {-90, 3}, {-46, 3}, {-44, 0},
+		{0, 0}, {44, 0}, {45, 1}, {90, 1}, {135, 2}, {180, 2}, {270, 3}, {315, 0}, {360, 0}, {450, 1},
+	} {
+		if got := quarterTurns(tc.degrees); got != tc.turns {
+			t.Errorf("quarterTurns(%d) = %d，期望 %d", tc.degrees, got, tc.turns)
+		}
+	}
+}
+
+func TestAutorotateFilters(t *testing.T) {
+	for _, tc := range []struct {
+		degrees int
+		want    []string
+	}{
+		{0, nil},
+		{90, []string{"transpose=clock"}},
+		{-270, []string{"transpose=clock"}},
+		{180, []string{"hflip", "vflip"}},
+		{-180, []string{"hflip", "vflip"}},
+		{270, []string{"transpose=cclock"}},
+		{-90, []string{"transpose=cclock"}},
+	} {
+		if got := autorotateFilters(tc.degrees); !slices.Equal(got, tc.want) {
+			t.Errorf("autorotateFilters(%d) = %q，期望 %q", tc.degrees, got, tc.want)
+		}
+	}
+}
+
+func TestCaptureFiltersLibavRotation(t *testing.T) {
+	// libav 后端先转正竖屏视频，再执行翻转与裁切；ffmpeg 命令行会自动转正，不需要额外的滤镜。
+	crop, err := parseCrop("10,20,100,200")
+	if err != nil {
+		t.Fatal(err)
+	}
+	meta := &videoMetadata{width: 1920, height: 1080, rotation: 90}
+	cfg := &gridConfig{backend: "libav", deinterlace: "off", flip: "h", crop: crop}
+	want := []string{"transpose=clock", "hflip", crop.filter()}
+	if got := captureFilters(cfg, meta); !slices.Equal(got, want) {
+		t.Errorf("libav 滤镜为 %q，期望 %q", got, want)
+	}
+	cfg.backend = "go"
+	if got := captureFilters(cfg, meta); !slices.Equal(got, want[1:]) {
+		t.Errorf("ffmpeg 滤镜为 %q，期望 %q", got, want[1:])
+	}
+}
@@ -0,0 +1,68 @@
+package preview
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/math/fixed"
+)
+
+// overlayStyle 为单格内标注 (标签、时间戳、序号) 的绘制方式：fill 为文字颜色，outline 大于 0 时
+// 为文字加上 outline 像素宽的描边并去掉半透明底色；smart 对应 --smart-labels。
+type overlayStyle struct {
+	smart        bool
+	fill         color.Color
+	outline      int
+	outlineColor color.Color
+}
+
+func (cfg *gridConfig) overlayStyle() overlayStyle {
+	return overlayStyle{smart: cfg.smartLabels, fill: cfg.textColor, outline: cfg.textOutline, outlineColor: cfg.textOutlineColor}
+}
+
+// drawOutline 沿 text 的字形向外扩展 width 像素 (圆形笔刷) 后以 c 绘制，之后在同一位置绘制的文字即带有描边。
+func drawOutline(dst draw.Image, dot fixed.Point26_6, text string, face font.Face, width int, c color.Color) {
+	bounds, _ := font.BoundString(face, text)
+	r := image.Rect(
+		(dot.X + bounds.Min.X).Floor(), (dot.Y + bounds.Min.Y).Floor(),
+		(dot.X + bounds.Max.X).Ceil(), (dot.Y + bounds.Max.Y).Ceil(),
+	).Inset(-width)
+	if r.Empty() {
+		return
+	}
+
+	glyphs := image.NewAlpha(r)
+	drawer := font.Drawer{Dst: glyphs, Src: image.Opaque, Face: face, Dot: dot}
+	drawer.DrawString(text)
+
+	draw.DrawMask(dst, r, &image.Uniform{C: c}, image.Point{}, dilate(glyphs, width), r.Min, draw.Over)
+}
+
+// dilate 以半径 width 的圆形笔刷扩展 mask 的覆盖范围，作为描边的遮罩。
+func dilate(mask *image.Alpha, width int) *image.Alpha {
+	var brush []image.Point
+	for dy := -width; dy <= width; dy++ {
+		for dx := -width; dx <= width; dx++ {
+			if dx*dx+dy*dy <= width*width {
+				brush = append(brush, image.Pt(dx, dy))
+			}
+		}
+	}
+	r := mask.Bounds()
+	stroke := image.NewAlpha(r)
+	for y := r.Min.Y; y < r.Max.Y; y++ {
+		for x := r.Min.X; x < r.Max.X; x++ {
+			var a uint8
+			for _, p := range brush {
+				q := image.Pt(x+p.X, y+p.Y)
+				if q.In(r) {
+					a = max(a, mask.Pix[mask.PixOffset(q.X, q.Y)])
+				}
+			}
+			stroke.Pix[stroke.PixOffset(x, y)] = a
+		}
+	}
+	return stroke
+}
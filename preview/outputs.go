@@ -0,0 +1,78 @@
+package preview
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"strings"
+)
+
+// outputsFlag 实现可重复的 --output：第一次指定时替换默认输出，之后每次追加一个额外输出。
+type outputsFlag struct {
+	first *string
+	extra *[]string
+	set   bool
+}
+
+func (f *outputsFlag) String() string {
+	if f.first == nil {
+		return ""
+	}
+	return strings.Join(append([]string{*f.first}, *f.extra...), ",")
+}
+
+func (f *outputsFlag) Set(value string) error {
+	if !f.set {
+		*f.first, f.set = value, true
+		return nil
+	}
+	*f.extra = append(*f.extra, value)
+	return nil
+}
+
+// validateExtraOutputs 检查多个 --output 的组合：额外输出与主输出都需要是本地图片文件，格式由各自的扩展名决定。
+func validateExtraOutputs(cfg *gridConfig) error {
+	switch {
+	case cfg.manifest != "":
+		return errors.New("多个 --output 不能与 --manifest 同时使用")
+	case cfg.framesOnly:
+		return errors.New("--frames-only 不生成拼图，不能指定多个 --output")
+	case cfg.backend == "ffmpeg-tile":
+		return errors.New("--backend ffmpeg-tile 不支持多个 --output，请改用默认的 go 后端")
+	}
+	seen := map[string]bool{}
+	for _, path := range append([]string{cfg.output}, cfg.extraOutputs...) {
+		if path == "-" || isRemoteURI(path) || isMontageOutput(path) {
+			return fmt.Errorf("指定多个 --output 时每个输出都需要是本地图片文件: %s", path)
+		}
+		if _, err := outputFormat(path, ""); err != nil {
+			return err
+		}
+		if seen[path] {
+			return fmt.Errorf("重复的输出路径: %s", path)
+		}
+		seen[path] = true
+	}
+	return nil
+}
+
+// saveExtraOutputs 把已合成的拼图按额外 --output 的扩展名分别编码保存，所有格式共用同一次截图与合成；
+// --max-bytes 只作用于其中的 JPEG 与 WebP 输出。
+func saveExtraOutputs(img image.Image, cfg *gridConfig, opts encodeOptions) error {
+	opts.format = ""
+	for _, path := range cfg.extraOutputs {
+		o := opts
+		if format, _ := outputFormat(path, ""); format != "jpeg" && format != "webp" {
+			o.maxBytes = 0
+		}
+		if err := saveImage(img, path, o); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// outputNames 返回主输出与额外输出，用于提示信息。
+func (cfg *gridConfig) outputNames() string {
+	return strings.Join(append([]string{cfg.outputName()}, cfg.extraOutputs...), "、")
+}
@@ -0,0 +1,127 @@
+package preview
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"slices"
+)
+
+const maxPaletteSamples = 1 << 18
+
+type paletteBox struct {
+	points []color.NRGBA
+}
+
+// quantizeImage 使用中位切分生成调色板，再按需以 Floyd-Steinberg 抖动映射到调色板。
+func quantizeImage(img image.Image, colors int, dither bool) *image.Paletted {
+	bounds := img.Bounds()
+	palette := medianCutPalette(img, colors)
+	dst := image.NewPaletted(bounds, palette)
+	if dither {
+		draw.FloydSteinberg.Draw(dst, bounds, img, bounds.Min)
+	} else {
+		draw.Draw(dst, bounds, img, bounds.Min, draw.Src)
+	}
+	return dst
+}
+
+func medianCutPalette(img image.Image, colors int) color.Palette {
+	bounds := img.Bounds()
+	step := 1
+	for (bounds.Dx()/step)*(bounds.Dy()/step) > maxPaletteSamples {
+		step++
+	}
+
+	points := make([]color.NRGBA, 0, (bounds.Dx()/step+1)*(bounds.Dy()/step+1))
+	for y := bounds.Min.Y; y < bounds.Max.Y; y += step {
+		for x := bounds.Min.X; x < bounds.Max.X; x += step {
+			points = append(points, color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA))
+		}
+	}
+
+	boxes := []paletteBox{{points: points}}
+	for len(boxes) < colors {
+		target, channel, widest := -1, 0, 0
+		for i, box := range boxes {
+			if len(box.points) < 2 {
+				continue
+			}
+			ch, spread := box.widestChannel()
+			if spread*len(box.points) > widest {
+				target, channel, widest = i, ch, spread*len(box.points)
+			}
+		}
+		if target < 0 {
+			break
+		}
+
+		box := boxes[target]
+		slices.SortFunc(box.points, func(a, b color.NRGBA) int {
+			return int(nrgbaChannel(a, channel)) - int(nrgbaChannel(b, channel))
+		})
+		mid := len(box.points) / 2
+		boxes[target] = paletteBox{points: box.points[:mid]}
+		boxes = append(boxes, paletteBox{points: box.points[mid:]})
+	}
+
+	palette := make(color.Palette, 0, len(boxes))
+	for _, box := range boxes {
+		if len(box.points) > 0 {
+			palette = append(palette, box.average())
+		}
+	}
+	if len(palette) == 0 {
+		palette = append(palette, color.NRGBA{})
+	}
+	return palette
+}
+
+func (b paletteBox) widestChannel() (int, int) {
+	lo := [4]uint8{255, 255, 255, 255}
+	var hi [4]uint8
+	for _, p := range b.points {
+		for ch := 0; ch < 4; ch++ {
+			v := nrgbaChannel(p, ch)
+			lo[ch] = min(lo[ch], v)
+			hi[ch] = max(hi[ch], v)
+		}
+	}
+	channel, spread := 0, 0
+	for ch := 0; ch < 4; ch++ {
+		if s := int(hi[ch]) - int(lo[ch]); s > spread {
+			channel, spread = ch, s
+		}
+	}
+	return channel, spread
+}
+
+func (b paletteBox) average() color.NRGBA {
+	var sum [4]int
+	for _, p := range b.points {
+		sum[0] += int(p.R)
+		sum[1] += int(p.G)
+		sum[2] += int(p.B)
+		sum[3] += int(p.A)
+	}
+	n := len(b.points)
+	return color.NRGBA{
+		R: uint8((sum[0] + n/2) / n),
+		G: uint8((sum[1] + n/2) / n),
+		B: uint8((sum[2] + n/2) / n),
+		A: uint8((sum[3] + n/2) / n),
+	}
+}
+
+func nrgbaChannel(c color.NRGBA, channel int) uint8 {
+	switch channel {
+	case 0:
+		return c.R
+	case 1:
+		return c.G
+	case 2:
+		return c.B
+	default:
+		return c.A
+	}
+}
@@ -0,0 +1,70 @@
+package preview
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func TestQuantizeImagePaletteSize(t *testing.T) {
+	for _, size := range []image.Point{{1, 1}, {17, 9}, {64, 48}} {
+		src := testPattern(size.X, size.Y)
+		for _, colors := range []int{2, 16, 256} {
+			for _, dither := range []bool{false, true} {
+				dst := quantizeImage(src, colors, dither)
+				if dst.Bounds() != src.Bounds() {
+					t.Fatalf("%v %d: 尺寸为 %v", size, colors, dst.Bounds())
+				}
+				if len(dst.Palette) == 0 || len(dst.Palette) > colors {
+					t.Errorf("%v %d 色 (dither=%v): 调色板有 %d 色", size, colors, dither, len(dst.Palette))
+				}
+			}
+		}
+	}
+}
+
+func TestQuantizeImageExactColors(t *testing.T) {
+	// 颜色数不超过调色板大小且各色像素数相同时，中位切分正好把每种颜色分到一个盒子，应原样还原。
+	want := []color.NRGBA{{255, 0, 0, 255}, {0, 200, 0, 255}, {0, 0, 255, 255}, {30, 30, 30, 128}}
+	src := image.NewNRGBA(image.Rect(0, 0, 16, 8))
+	for y := range 8 {
+		for x := range 16 {
+			src.SetNRGBA(x, y, want[x/8+y/4*2])
+		}
+	}
+	dst := quantizeImage(src, 4, false)
+	if len(dst.Palette) != 4 {
+		t.Errorf("调色板有 %d 色，期望 4 色", len(dst.Palette))
+	}
+	for y := range 8 {
+		for x := range 16 {
+			if got := color.NRGBAModel.Convert(dst.At(x, y)); got != src.NRGBAAt(x, y) {
+				t.Fatalf("(%d, %d) 为 %v，期望 %v", x, y, got, src.NRGBAAt(x, y))
+			}
+		}
+	}
+}
+
+func TestEncodePNGColors(t *testing.T) {
+	src := testPattern(64, 48)
+	var buf bytes.Buffer
+	if err := encodePNG(&buf, src, encodeOptions{pngColors: 256}); err != nil {
+		t.Fatalf("encodePNG: %v", err)
+	}
+	decoded, err := png.Decode(&buf)
+	if err != nil {
+		t.Fatalf("image/png 无法解码: %v", err)
+	}
+	paletted, ok := decoded.(*image.Paletted)
+	if !ok {
+		t.Fatalf("解码结果为 %T，期望调色板图像", decoded)
+	}
+	if len(paletted.Palette) > 256 {
+		t.Errorf("调色板有 %d 色", len(paletted.Palette))
+	}
+	if got := psnr(t, src, decoded); got < 30 {
+		t.Errorf("256 色量化后 PSNR 为 %.1f dB，期望至少 30 dB", got)
+	}
+}
@@ -0,0 +1,52 @@
+package preview
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+var sampleMarkerColor = color.NRGBA{230, 90, 40, 255}
+
+// appendPanel 在拼图下方追加一条高度为 height 的面板，返回新画布与面板的绘图区域；左右与拼图的外边距对齐。
+// 拼图宽度不足以容纳绘图区域时返回空区域。
+func appendPanel(sheet image.Image, cfg *gridConfig, height int) (*image.RGBA, image.Rectangle) {
+	bounds := sheet.Bounds()
+	padX, padY := panelPadding(cfg)
+	if bounds.Dx()-2*padX <= 0 {
+		return nil, image.Rectangle{}
+	}
+
+	canvas := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()+height+padY))
+	draw.Draw(canvas, canvas.Bounds(), &image.Uniform{C: cfg.background}, image.Point{}, draw.Src)
+	draw.Draw(canvas, image.Rect(0, 0, bounds.Dx(), bounds.Dy()), sheet, bounds.Min, draw.Over)
+	return canvas, image.Rect(padX, bounds.Dy(), bounds.Dx()-padX, bounds.Dy()+height)
+}
+
+func panelPadding(cfg *gridConfig) (int, int) {
+	return cfg.padding.pixels(cfg.cellWidth), max(cfg.padding.pixels(cfg.cellHeight), 4)
+}
+
+// panelExtent 返回在宽度为 width 的拼图下方追加高度为 height 的面板所增加的高度，拼图过窄而不追加时为 0。
+func panelExtent(cfg *gridConfig, width, height int) int {
+	padX, padY := panelPadding(cfg)
+	if width-2*padX <= 0 {
+		return 0
+	}
+	return height + padY
+}
+
+// drawSampleMarkers 在面板中每张截图的采样时间点处画一条竖线。
+func drawSampleMarkers(canvas draw.Image, area image.Rectangle, timestamps []float64, duration float64) {
+	for _, ts := range timestamps {
+		x := area.Min.X + int(math.Round(ts/duration*float64(area.Dx()-1)))
+		draw.Draw(canvas, image.Rect(x, area.Min.Y, x+1, area.Max.Y), &image.Uniform{C: sampleMarkerColor}, image.Point{}, draw.Over)
+	}
+}
+
+func withAlpha(c color.Color, alpha uint8) color.NRGBA {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	n.A = alpha
+	return n
+}
@@ -0,0 +1,42 @@
+package preview
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// parsePercentages 解析 --percentages 的采样位置 (逗号分隔的 0-100 百分比，可带 % 后缀，例如 10,25,50,75,90)。
+func parsePercentages(value string) ([]float64, error) {
+	var percentages []float64
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		p, err := strconv.ParseFloat(strings.TrimSuffix(field, "%"), 64)
+		if err != nil || p < 0 || p > 100 {
+			return nil, fmt.Errorf("无效的百分比: %s (取值 0-100)", field)
+		}
+		percentages = append(percentages, p)
+	}
+	if len(percentages) == 0 {
+		return nil, errors.New("percentages 至少需要一个百分比")
+	}
+	return percentages, nil
+}
+
+// percentageTimestamps 把百分比换算为采样范围内的时间点；与 --at 相同，100% 向前留出一帧。
+func percentageTimestamps(percentages []float64, start, length float64, meta *videoMetadata) []float64 {
+	frame := 0.1
+	if meta.fps > 0 {
+		frame = 1 / meta.fps
+	}
+	timestamps := make([]float64, len(percentages))
+	for i, p := range percentages {
+		timestamps[i] = start + math.Max(math.Min(length*p/100, length-frame), 0)
+	}
+	return timestamps
+}
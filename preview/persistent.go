@@ -0,0 +1,112 @@
+package preview
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"image"
+	"os"
+	"strings"
+)
+
+// capturePersistent 只启动一个 ffmpeg 进程截取全部时间点：每个时间点作为一路快速定位的输入各取一帧，
+// 经 concat 滤镜依次输出，所有截图依次通过同一管道返回，省去逐帧启动 ffmpeg 与重复打开文件的开销。
+func capturePersistent(cfg *gridConfig, timestamps []float64, filters []string) ([]image.Image, error) {
+	if len(timestamps) == 0 {
+		return nil, nil
+	}
+	cmd := newCommand(cfg.context(), cfg.runner, ffmpegPath, persistentArgs(cfg, timestamps, filters)...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		observeToolFailure("capture")
+		return nil, err
+	}
+
+	reader := bufio.NewReader(stdout)
+	frames := make([]image.Image, 0, len(timestamps))
+	for range timestamps {
+		img, err := decodePipeFrame(reader, cfg.pipeCodec)
+		if err != nil {
+			_ = cmd.Wait()
+			observeToolFailure("capture")
+			if msg := strings.TrimSpace(stderr.String()); msg != "" {
+				return nil, fmt.Errorf("ffmpeg 只输出了 %d 张截图: %s", len(frames), msg)
+			}
+			return nil, fmt.Errorf("ffmpeg 只输出了 %d 张截图: %w", len(frames), err)
+		}
+		frames = append(frames, img)
+	}
+	if err := cmd.Wait(); err != nil {
+		observeToolFailure("capture")
+		return nil, commandError(err, stderr.Bytes())
+	}
+
+	framesCaptured.Add(float64(len(frames)))
+	return frames, nil
+}
+
+func persistentArgs(cfg *gridConfig, timestamps []float64, filters []string) []string {
+	args := []string{"-loglevel", "error"}
+	var chains []string
+	var labels []string
+	for i, ts := range timestamps {
+		if cfg.keyframesOnly {
+			args = append(args, "-skip_frame", "nokey", "-noaccurate_seek")
+		}
+		args = append(args, "-ss", fmt.Sprintf("%.3f", ts), "-i", toolPath(cfg.input))
+
+		chain := append([]string{"trim=end_frame=1", "setpts=PTS-STARTPTS"}, filters...)
+		label := fmt.Sprintf("v%d", i)
+		chains = append(chains, fmt.Sprintf("[%d:%d]%s[%s]", i, cfg.streamIndex, strings.Join(chain, ","), label))
+		labels = append(labels, "["+label+"]")
+	}
+	chains = append(chains, fmt.Sprintf("%sconcat=n=%d:v=1:a=0[out]", strings.Join(labels, ""), len(timestamps)))
+	args = append(args,
+		"-filter_complex", strings.Join(chains, ";"),
+		"-map", "[out]",
+		"-fps_mode", "passthrough",
+		"-f", "image2pipe",
+	)
+	args = append(args, pipeCodecArgs(cfg.pipeCodec)...)
+	return append(args, "-")
+}
+
+// prefetchFrames 为 --backend persistent 预先取得全部截图：先读取截图缓存，其余时间点由一个 ffmpeg 进程一次截取并写入缓存。
+func prefetchFrames(cfg *gridConfig, cache *frameCache, timestamps []float64, filters []string) ([]image.Image, error) {
+	frames := make([]image.Image, len(timestamps))
+	var missing []int
+	var pending []float64
+	for i, sample := range timestamps {
+		if cache != nil {
+			frames[i], _ = cache.load(sample)
+		}
+		if frames[i] == nil {
+			missing = append(missing, i)
+			pending = append(pending, sample)
+		}
+	}
+
+	var captured []image.Image
+	err := withRetry(cfg, "提取截图", func() (err error) {
+		captured, err = capturePersistent(cfg, pending, filters)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("提取截图失败: %w", err)
+	}
+	for j, i := range missing {
+		frames[i] = captured[j]
+		if cache != nil {
+			if err := cache.store(timestamps[i], timestamps[i], captured[j]); err != nil {
+				fmt.Fprintln(os.Stderr, "警告: 写入截图缓存失败，本次任务不再使用缓存:", err)
+				cache = nil
+			}
+		}
+	}
+	return frames, nil
+}
@@ -0,0 +1,122 @@
+package preview
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strconv"
+	"strings"
+)
+
+func validatePipeCodec(name string) error {
+	switch name {
+	case "png", "mjpeg", "rawvideo":
+		return nil
+	default:
+		return fmt.Errorf("不支持的截图传输格式: %s (可选: png、mjpeg、rawvideo)", name)
+	}
+}
+
+// pipeCodecArgs 返回 ffmpeg 经管道输出截图时使用的编码器参数。mjpeg 使用接近无损的质量；
+// rawvideo 以带 PAM 文件头的未压缩 RGBA 输出，经过裁剪、旋转等滤镜后无需预先知道截图尺寸。
+func pipeCodecArgs(codec string) []string {
+	switch codec {
+	case "mjpeg":
+		return []string{"-vcodec", "mjpeg", "-q:v", "2"}
+	case "rawvideo":
+		return []string{"-vcodec", "pam", "-pix_fmt", "rgba"}
+	default:
+		return []string{"-vcodec", "png"}
+	}
+}
+
+// decodePipeFrame 从管道中读取一张截图。同一读取器上可连续调用以拆分多张截图组成的序列：
+// png.Decode 读到 IEND 块即返回；image/jpeg 会预读，因此先按 EOI 标记截出完整的 JPEG 数据再解码。
+func decodePipeFrame(r *bufio.Reader, codec string) (image.Image, error) {
+	switch codec {
+	case "mjpeg":
+		data, err := readJPEG(r)
+		if err != nil {
+			return nil, err
+		}
+		return jpeg.Decode(bytes.NewReader(data))
+	case "rawvideo":
+		return decodePAM(r)
+	default:
+		return png.Decode(r)
+	}
+}
+
+// readJPEG 读取到 EOI (FF D9) 为止。熵编码数据中的 0xFF 之后总是 0x00 或 RST 标记，不会误判。
+func readJPEG(r *bufio.Reader) ([]byte, error) {
+	var data []byte
+	for {
+		chunk, err := r.ReadSlice(0xFF)
+		data = append(data, chunk...)
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			if err == io.EOF && len(data) > 0 {
+				err = io.ErrUnexpectedEOF
+			}
+			return nil, err
+		}
+		next, err := r.ReadByte()
+		if err != nil {
+			return nil, io.ErrUnexpectedEOF
+		}
+		data = append(data, next)
+		if next == 0xD9 {
+			return data, nil
+		}
+		if next == 0xFF {
+			// 连续的 0xFF 为填充字节，退回最后一个以便与下一个字节组成标记。
+			data = data[:len(data)-1]
+			_ = r.UnreadByte()
+		}
+	}
+}
+
+// decodePAM 解码 ffmpeg pam 编码器输出的 RGB_ALPHA 图像。
+func decodePAM(r *bufio.Reader) (image.Image, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if line != "P7\n" {
+		return nil, errors.New("无效的 PAM 数据")
+	}
+	var width, height, depth int
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil, io.ErrUnexpectedEOF
+		}
+		key, value, _ := strings.Cut(strings.TrimSpace(line), " ")
+		switch key {
+		case "WIDTH":
+			width, _ = strconv.Atoi(value)
+		case "HEIGHT":
+			height, _ = strconv.Atoi(value)
+		case "DEPTH":
+			depth, _ = strconv.Atoi(value)
+		}
+		if key == "ENDHDR" {
+			break
+		}
+	}
+	if width <= 0 || height <= 0 || depth != 4 {
+		return nil, fmt.Errorf("不支持的 PAM 图像: %dx%d，%d 个通道", width, height, depth)
+	}
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	if _, err := io.ReadFull(r, img.Pix); err != nil {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return img, nil
+}
@@ -0,0 +1,56 @@
+package preview
+
+import (
+	"flag"
+	"fmt"
+	"os"
+)
+
+// positionalInputs 返回命令行中的位置参数，作为输入视频。flag 包遇到第一个位置参数就停止解析，
+// 这里继续解析其后的参数，"video-preview-image movie.mkv --rows 4" 与参数写在前面效果相同。
+func positionalInputs(fs *flag.FlagSet) ([]string, error) {
+	var inputs []string
+	for fs.NArg() > 0 {
+		inputs = append(inputs, fs.Arg(0))
+		if err := fs.Parse(fs.Args()[1:]); err != nil {
+			return nil, err
+		}
+	}
+	return inputs, nil
+}
+
+// runInputs 依次处理以位置参数给出的多个视频 (如一次拖放多个文件)，拼图保存在各视频所在目录。
+// 某个视频失败时继续处理其余视频，最后以错误返回失败数量。
+func runInputs(base *gridConfig) error {
+	failed := 0
+	for i, input := range base.inputs {
+		cfg := *base
+		cfg.input = input
+		cfg.output = positionalOutput(base, input)
+		if err := generatePreview(&cfg); err != nil {
+			failed++
+			fmt.Fprintf(os.Stderr, "错误: %s 处理失败: %v\n", input, err)
+			continue
+		}
+		if !cfg.dryRun {
+			fmt.Printf("[%d/%d] %s\n", i+1, len(base.inputs), resultMessage(&cfg))
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d/%d 个视频处理失败", failed, len(base.inputs))
+	}
+	return nil
+}
+
+// positionalOutput 为以位置参数给出的本地视频返回默认输出 <视频名>_preview.<扩展名>，与视频放在同一目录；
+// 拖放启动时工作目录通常是程序所在目录或系统目录，不适合存放输出。
+func positionalOutput(cfg *gridConfig, input string) string {
+	if input == "-" || isRemoteURI(input) {
+		return cfg.output
+	}
+	ext := ".png"
+	if cfg.format != "" {
+		ext = formatExtension(cfg.format)
+	}
+	return previewPathFor(input, "", ext)
+}
@@ -0,0 +1,17 @@
+package preview
+
+import (
+	"fmt"
+	"image"
+)
+
+// prescaleFilter 返回 --prescale 时接在滤镜链末尾的缩放滤镜：ffmpeg 先把截图缩小到能放入最大截图位置
+// (及动态预览帧) 的尺寸再经管道传回，8K 片源也不会在 Go 中分配整帧大小的 RGBA 图像。
+func prescaleFilter(sizes []image.Point, animWidth, animHeight int) string {
+	width, height := animWidth, animHeight
+	for _, size := range sizes {
+		width = max(width, size.X)
+		height = max(height, size.Y)
+	}
+	return fmt.Sprintf("scale=w=%d:h=%d:force_original_aspect_ratio=decrease:flags=lanczos", max(width, 1), max(height, 1))
+}
@@ -0,0 +1,113 @@
+package preview
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// 预设只是一组参数默认值，命令行中显式指定的参数始终优先。
+var builtinPresets = map[string]map[string]string{
+	"torrent": {
+		"rows":       "4",
+		"cols":       "4",
+		"format":     "jpeg",
+		"quality":    "85",
+		"header":     "true",
+		"timestamps": "true",
+	},
+	"web": {
+		"rows":           "3",
+		"cols":           "3",
+		"format":         "webp",
+		"quality":        "80",
+		"header":         "false",
+		"timestamps":     "false",
+		"embed-metadata": "false",
+	},
+	"archive": {
+		"rows":            "5",
+		"cols":            "5",
+		"format":          "png",
+		"png-compression": "best",
+		"sidecar":         "true",
+	},
+}
+
+// userPresetPath 返回用户预设文件路径，例如 Linux 下的 ~/.config/video-preview-image/presets.json。
+func userPresetPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, toolName, "presets.json"), nil
+}
+
+// loadPresets 合并内置预设与用户预设: 同名预设逐项覆盖，新名称则新增预设。
+func loadPresets() (map[string]map[string]string, error) {
+	presets := make(map[string]map[string]string, len(builtinPresets))
+	for name, values := range builtinPresets {
+		presets[name] = maps.Clone(values)
+	}
+
+	path, err := userPresetPath()
+	if err != nil {
+		return presets, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return presets, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取用户预设失败: %w", err)
+	}
+
+	var user map[string]map[string]any
+	if err := json.Unmarshal(data, &user); err != nil {
+		return nil, fmt.Errorf("解析用户预设失败 (%s): %w", path, err)
+	}
+	for name, values := range user {
+		if presets[name] == nil {
+			presets[name] = make(map[string]string)
+		}
+		for key, value := range values {
+			presets[name][key] = fmt.Sprint(value)
+		}
+	}
+	return presets, nil
+}
+
+func applyPreset(fs *flag.FlagSet, name string) error {
+	presets, err := loadPresets()
+	if err != nil {
+		return err
+	}
+	values, ok := presets[name]
+	if !ok {
+		return fmt.Errorf("未知的预设: %s (可选: %s)", name, strings.Join(slices.Sorted(maps.Keys(presets)), ", "))
+	}
+
+	explicit := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		explicit[f.Name] = true
+	})
+
+	for _, key := range slices.Sorted(maps.Keys(values)) {
+		if key == "preset" || explicit[key] {
+			continue
+		}
+		if fs.Lookup(key) == nil {
+			return fmt.Errorf("预设 %s 包含未知参数: %s", name, key)
+		}
+		if err := fs.Set(key, values[key]); err != nil {
+			return fmt.Errorf("预设 %s 的参数 %s 无效: %w", name, key, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,389 @@
+package preview
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+type videoMetadata struct {
+	formatName     string
+	formatLongName string
+	duration       float64
+	size           int64
+	bitRate        int64
+	width          int
+	height         int
+	rotation       int
+	videoIndex     int
+	videoCodec     string
+	pixelFormat    string
+	colorSpace     string
+	colorTransfer  string
+	colorPrimaries string
+	colorRange     string
+	fieldOrder     string
+	sampleAspect   float64
+	fps            float64
+	videoBitRate   int64
+	audioCodec     string
+	audioChannels  int
+	sampleRate     int
+	tags           map[string]string
+	streams        []streamInfo
+	chapters       []chapterInfo
+	checksum       *fileChecksum
+}
+
+type streamInfo struct {
+	index          int
+	codecType      string
+	codecName      string
+	codecLongName  string
+	profile        string
+	width          int
+	height         int
+	pixelFormat    string
+	colorSpace     string
+	colorTransfer  string
+	colorPrimaries string
+	colorRange     string
+	fieldOrder     string
+	sampleAspect   float64
+	fps            float64
+	bitRate        int64
+	duration       float64
+	channels       int
+	channelLayout  string
+	sampleRate     int
+	rotation       int
+	language       string
+	title          string
+	tags           map[string]string
+	// attachedPic 表示该流是 MP3/MKV 等文件内嵌的封面图片，而不是真正的视频。
+	attachedPic bool
+}
+
+type chapterInfo struct {
+	id    int64
+	start float64
+	end   float64
+	title string
+}
+
+type ffprobeOutput struct {
+	Format   ffprobeFormat    `json:"format"`
+	Streams  []ffprobeStream  `json:"streams"`
+	Chapters []ffprobeChapter `json:"chapters"`
+}
+
+type ffprobeFormat struct {
+	FormatName     string            `json:"format_name"`
+	FormatLongName string            `json:"format_long_name"`
+	Duration       string            `json:"duration"`
+	Size           string            `json:"size"`
+	BitRate        string            `json:"bit_rate"`
+	Tags           map[string]string `json:"tags"`
+}
+
+type ffprobeStream struct {
+	Index         int               `json:"index"`
+	CodecType     string            `json:"codec_type"`
+	CodecName     string            `json:"codec_name"`
+	CodecLongName string            `json:"codec_long_name"`
+	Profile       string            `json:"profile"`
+	Width         int               `json:"width"`
+	Height        int               `json:"height"`
+	PixFmt        string            `json:"pix_fmt"`
+	ColorSpace    string            `json:"color_space"`
+	ColorTransfer string            `json:"color_transfer"`
+	ColorPrim     string            `json:"color_primaries"`
+	ColorRange    string            `json:"color_range"`
+	FieldOrder    string            `json:"field_order"`
+	SampleAspect  string            `json:"sample_aspect_ratio"`
+	AvgFrameRate  string            `json:"avg_frame_rate"`
+	RFrameRate    string            `json:"r_frame_rate"`
+	BitRate       string            `json:"bit_rate"`
+	Duration      string            `json:"duration"`
+	Channels      int               `json:"channels"`
+	ChannelLayout string            `json:"channel_layout"`
+	SampleRate    string            `json:"sample_rate"`
+	Tags          map[string]string `json:"tags"`
+	Disposition   struct {
+		AttachedPic int `json:"attached_pic"`
+	} `json:"disposition"`
+	SideDataList []struct {
+		Rotation float64 `json:"rotation"`
+	} `json:"side_data_list"`
+}
+
+type ffprobeChapter struct {
+	ID        int64             `json:"id"`
+	StartTime string            `json:"start_time"`
+	EndTime   string            `json:"end_time"`
+	Tags      map[string]string `json:"tags"`
+}
+
+var probeFallbackWarning sync.Once
+
+// probeVideo 使用 ffprobe 读取视频信息；找不到 ffprobe 时退回内置的 MP4/Matroska 头解析器。
+func probeVideo(ctx context.Context, r Runner, path string) (*videoMetadata, error) {
+	var meta *videoMetadata
+	if _, err := exec.LookPath(ffprobePath); err != nil && r == nil {
+		probeFallbackWarning.Do(func() {
+			fmt.Fprintf(os.Stderr, "警告: 未找到 ffprobe (%s)，使用内置解析器读取 MP4/Matroska 头信息，色彩与章节等信息不可用\n", ffprobePath)
+		})
+		if meta, err = probeContainer(path); err != nil {
+			return nil, fmt.Errorf("读取视频信息失败: %w", err)
+		}
+	} else if meta, err = runFFprobe(ctx, r, path); err != nil {
+		return nil, err
+	}
+
+	if meta.width <= 0 || meta.height <= 0 {
+		return nil, fmt.Errorf("未找到可用的视频流 (内嵌的封面图片不计入)")
+	}
+	if meta.duration <= 0 {
+		duration, err := estimateDuration(ctx, r, path, meta.videoIndex)
+		if err != nil {
+			return nil, fmt.Errorf("容器未记录时长，读取数据包估算时长失败: %w", err)
+		}
+		if duration <= 0 {
+			return nil, fmt.Errorf("未能获取视频时长或时长为 0")
+		}
+		fmt.Fprintf(os.Stderr, "警告: 容器未记录时长，已根据最后一个数据包的时间估算为 %s\n", formatTimestamp(duration))
+		meta.duration = duration
+	}
+	return meta, nil
+}
+
+func runFFprobe(ctx context.Context, r Runner, path string) (*videoMetadata, error) {
+	cmd := newCommand(
+		ctx,
+		r,
+		ffprobePath,
+		"-v", "error",
+		"-print_format", "json",
+		"-show_format",
+		"-show_streams",
+		"-show_chapters",
+		toolPath(path),
+	)
+	var stderr stderrTail
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		observeToolFailure("probe")
+		return nil, fmt.Errorf("读取视频信息失败: %w", commandError(err, stderr.Bytes()))
+	}
+
+	var raw ffprobeOutput
+	if err := json.Unmarshal(output, &raw); err != nil {
+		return nil, fmt.Errorf("解析视频信息失败: %w", err)
+	}
+
+	return buildMetadata(&raw), nil
+}
+
+func buildMetadata(raw *ffprobeOutput) *videoMetadata {
+	meta := &videoMetadata{
+		formatName:     raw.Format.FormatName,
+		formatLongName: raw.Format.FormatLongName,
+		duration:       parseFloat(raw.Format.Duration),
+		size:           parseInt(raw.Format.Size),
+		bitRate:        parseInt(raw.Format.BitRate),
+		tags:           raw.Format.Tags,
+	}
+
+	for _, s := range raw.Streams {
+		stream := streamInfo{
+			index:          s.Index,
+			codecType:      s.CodecType,
+			codecName:      s.CodecName,
+			codecLongName:  s.CodecLongName,
+			profile:        s.Profile,
+			width:          s.Width,
+			height:         s.Height,
+			pixelFormat:    s.PixFmt,
+			colorSpace:     s.ColorSpace,
+			colorTransfer:  s.ColorTransfer,
+			colorPrimaries: s.ColorPrim,
+			colorRange:     s.ColorRange,
+			fieldOrder:     s.FieldOrder,
+			sampleAspect:   parseAspect(s.SampleAspect),
+			fps:            parseRate(s.AvgFrameRate),
+			bitRate:        parseInt(s.BitRate),
+			duration:       parseFloat(s.Duration),
+			channels:       s.Channels,
+			channelLayout:  s.ChannelLayout,
+			sampleRate:     int(parseInt(s.SampleRate)),
+			language:       s.Tags["language"],
+			title:          s.Tags["title"],
+			tags:           s.Tags,
+			attachedPic:    s.Disposition.AttachedPic != 0,
+		}
+		if stream.fps == 0 {
+			stream.fps = parseRate(s.RFrameRate)
+		}
+		stream.rotation = streamRotation(s)
+		meta.streams = append(meta.streams, stream)
+	}
+
+	for _, stream := range meta.streams {
+		if stream.codecType == "video" && !stream.attachedPic && meta.videoCodec == "" {
+			meta.useVideoStream(stream)
+		}
+		if stream.codecType == "audio" && meta.audioCodec == "" {
+			meta.audioCodec = stream.codecName
+			meta.audioChannels = stream.channels
+			meta.sampleRate = stream.sampleRate
+		}
+	}
+
+	for _, c := range raw.Chapters {
+		meta.chapters = append(meta.chapters, chapterInfo{
+			id:    c.ID,
+			start: parseFloat(c.StartTime),
+			end:   parseFloat(c.EndTime),
+			title: c.Tags["title"],
+		})
+	}
+
+	return meta
+}
+
+// useVideoStream 以 stream 的参数作为截图所用视频流的信息。
+func (m *videoMetadata) useVideoStream(stream streamInfo) {
+	m.videoIndex = stream.index
+	m.videoCodec = stream.codecName
+	m.width = stream.width
+	m.height = stream.height
+	m.rotation = stream.rotation
+	m.pixelFormat = stream.pixelFormat
+	m.colorSpace = stream.colorSpace
+	m.colorTransfer = stream.colorTransfer
+	m.colorPrimaries = stream.colorPrimaries
+	m.colorRange = stream.colorRange
+	m.fieldOrder = stream.fieldOrder
+	m.sampleAspect = stream.sampleAspect
+	m.fps = stream.fps
+	m.videoBitRate = stream.bitRate
+	if m.duration <= 0 {
+		m.duration = stream.duration
+	}
+}
+
+var streamSpecPattern = regexp.MustCompile(`^(0:)?(v:)?\d+$`)
+
+// selectVideoStream 按 --stream 取值 (v:N 表示第 N 条视频流，纯数字表示流的绝对序号) 选择截图所用的视频流。
+func (m *videoMetadata) selectVideoStream(spec string) error {
+	spec = strings.TrimPrefix(spec, "0:")
+	n, err := strconv.Atoi(strings.TrimPrefix(spec, "v:"))
+	if err != nil || n < 0 {
+		return fmt.Errorf("无效的视频流: %s (应为 v:N 或流序号)", spec)
+	}
+	video := 0
+	for _, stream := range m.streams {
+		if stream.codecType != "video" {
+			continue
+		}
+		if strings.HasPrefix(spec, "v:") && video == n || !strings.HasPrefix(spec, "v:") && stream.index == n {
+			m.useVideoStream(stream)
+			return nil
+		}
+		video++
+	}
+	return fmt.Errorf("未找到视频流 %s (共 %d 条视频流)", spec, video)
+}
+
+// displaySize 返回考虑像素宽高比与旋转信息后的显示尺寸，ffmpeg 截图时会自动按旋转信息转正画面。
+func (m *videoMetadata) displaySize() (int, int) {
+	width := m.width
+	if m.anamorphic() {
+		width = int(math.Round(float64(m.width) * m.sampleAspect))
+	}
+	if m.rotation == 90 || m.rotation == 270 {
+		return m.height, width
+	}
+	return width, m.height
+}
+
+// anamorphic 表示视频的像素不是正方形 (例如 DVD 的 720x480 以 32:27 显示为 16:9)。
+func (m *videoMetadata) anamorphic() bool {
+	return m.sampleAspect > 0 && math.Abs(m.sampleAspect-1) > 0.001
+}
+
+// sampleAspectFilter 把截图按像素宽高比拉伸为显示比例；使用帧自身的 sar，ffmpeg 自动旋转后同样适用。
+const sampleAspectFilter = "scale=trunc(iw*sar/2)*2:ih,setsar=1"
+
+// 新版 ffprobe 通过 displaymatrix 的 rotation (逆时针角度) 表示旋转，旧版使用 rotate 标签 (顺时针角度)。
+func streamRotation(s ffprobeStream) int {
+	degrees := 0.0
+	found := false
+	for _, side := range s.SideDataList {
+		if side.Rotation != 0 {
+			degrees = -side.Rotation
+			found = true
+			break
+		}
+	}
+	if !found {
+		if value, ok := s.Tags["rotate"]; ok {
+			degrees = parseFloat(value)
+		}
+	}
+
+	rotation := int(math.Round(degrees/90)) * 90 % 360
+	if rotation < 0 {
+		rotation += 360
+	}
+	return rotation
+}
+
+func parseFloat(value string) float64 {
+	f, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+		return 0
+	}
+	return f
+}
+
+func parseInt(value string) int64 {
+	n, err := strconv.ParseInt(strings.TrimSpace(value), 10, 64)
+	if err != nil {
+		return 0
+	}
+	return n
+}
+
+// parseAspect 解析 ffprobe 的 sample_aspect_ratio (例如 32:27)，未知时 (0:1、N/A) 返回 0。
+func parseAspect(value string) float64 {
+	num, den, ok := strings.Cut(value, ":")
+	if !ok {
+		return 0
+	}
+	d := parseFloat(den)
+	if d == 0 {
+		return 0
+	}
+	return parseFloat(num) / d
+}
+
+func parseRate(value string) float64 {
+	num, den, ok := strings.Cut(value, "/")
+	if !ok {
+		return parseFloat(value)
+	}
+	d := parseFloat(den)
+	if d == 0 {
+		return 0
+	}
+	return parseFloat(num) / d
+}
@@ -0,0 +1,177 @@
+package preview
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+)
+
+// VideoInfo 为 probe 子命令输出的视频信息，也是 Generator.OnProbe 的参数；时长以秒为单位。
+type VideoInfo struct {
+	Format         string            `json:"format"`
+	FormatLongName string            `json:"format_long_name,omitempty"`
+	Duration       float64           `json:"duration"`
+	Size           int64             `json:"size,omitempty"`
+	BitRate        int64             `json:"bit_rate,omitempty"`
+	Width          int               `json:"width"`
+	Height         int               `json:"height"`
+	DisplayWidth   int               `json:"display_width"`
+	DisplayHeight  int               `json:"display_height"`
+	Rotation       int               `json:"rotation"`
+	VideoCodec     string            `json:"video_codec"`
+	PixelFormat    string            `json:"pixel_format,omitempty"`
+	ColorSpace     string            `json:"color_space,omitempty"`
+	ColorTransfer  string            `json:"color_transfer,omitempty"`
+	ColorPrimaries string            `json:"color_primaries,omitempty"`
+	ColorRange     string            `json:"color_range,omitempty"`
+	FieldOrder     string            `json:"field_order,omitempty"`
+	SampleAspect   float64           `json:"sample_aspect_ratio,omitempty"`
+	FPS            float64           `json:"fps"`
+	VideoBitRate   int64             `json:"video_bit_rate,omitempty"`
+	AudioCodec     string            `json:"audio_codec,omitempty"`
+	AudioChannels  int               `json:"audio_channels,omitempty"`
+	SampleRate     int               `json:"sample_rate,omitempty"`
+	Tags           map[string]string `json:"tags,omitempty"`
+	Streams        []VideoStream     `json:"streams"`
+	Chapters       []VideoChapter    `json:"chapters"`
+}
+
+// VideoStream 为 VideoInfo 中的一路流。
+type VideoStream struct {
+	Index         int               `json:"index"`
+	Type          string            `json:"type"`
+	Codec         string            `json:"codec"`
+	CodecLongName string            `json:"codec_long_name,omitempty"`
+	Profile       string            `json:"profile,omitempty"`
+	Width         int               `json:"width,omitempty"`
+	Height        int               `json:"height,omitempty"`
+	PixelFormat   string            `json:"pixel_format,omitempty"`
+	FPS           float64           `json:"fps,omitempty"`
+	BitRate       int64             `json:"bit_rate,omitempty"`
+	Duration      float64           `json:"duration,omitempty"`
+	Channels      int               `json:"channels,omitempty"`
+	ChannelLayout string            `json:"channel_layout,omitempty"`
+	SampleRate    int               `json:"sample_rate,omitempty"`
+	Rotation      int               `json:"rotation,omitempty"`
+	Language      string            `json:"language,omitempty"`
+	Title         string            `json:"title,omitempty"`
+	AttachedPic   bool              `json:"attached_pic,omitempty"`
+	Tags          map[string]string `json:"tags,omitempty"`
+}
+
+// VideoChapter 为 VideoInfo 中的一个章节，起止时间以秒为单位。
+type VideoChapter struct {
+	ID    int64   `json:"id"`
+	Start float64 `json:"start"`
+	End   float64 `json:"end"`
+	Title string  `json:"title,omitempty"`
+}
+
+type probeFlags struct {
+	input   string
+	compact bool
+}
+
+func newProbeFlagSet() (*flag.FlagSet, *probeFlags) {
+	fs := flag.NewFlagSet("probe", flag.ExitOnError)
+	pf := &probeFlags{}
+	fs.StringVar(&pf.input, "input", "", "输入视频文件路径 (必填，也可直接作为位置参数传入)")
+	fs.BoolVar(&pf.compact, "compact", false, "输出单行 JSON")
+	bindToolFlags(fs)
+	return fs, pf
+}
+
+func runProbe(args []string) error {
+	fs, pf := newProbeFlagSet()
+	if err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	input, compact := pf.input, pf.compact
+	if input == "" && fs.NArg() > 0 {
+		input = fs.Arg(0)
+	}
+	if input == "" {
+		return errors.New("必须指定输入视频路径 --input")
+	}
+
+	resolveManagedTools()
+	meta, err := probeVideo(context.Background(), nil, input)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(os.Stdout)
+	if !compact {
+		encoder.SetIndent("", "  ")
+	}
+	return encoder.Encode(newMetadataReport(meta))
+}
+
+func newMetadataReport(meta *videoMetadata) VideoInfo {
+	displayWidth, displayHeight := meta.displaySize()
+	report := VideoInfo{
+		Format:         meta.formatName,
+		FormatLongName: meta.formatLongName,
+		Duration:       meta.duration,
+		Size:           meta.size,
+		BitRate:        meta.bitRate,
+		Width:          meta.width,
+		Height:         meta.height,
+		DisplayWidth:   displayWidth,
+		DisplayHeight:  displayHeight,
+		Rotation:       meta.rotation,
+		VideoCodec:     meta.videoCodec,
+		PixelFormat:    meta.pixelFormat,
+		ColorSpace:     meta.colorSpace,
+		ColorTransfer:  meta.colorTransfer,
+		ColorPrimaries: meta.colorPrimaries,
+		ColorRange:     meta.colorRange,
+		FieldOrder:     meta.fieldOrder,
+		SampleAspect:   meta.sampleAspect,
+		FPS:            meta.fps,
+		VideoBitRate:   meta.videoBitRate,
+		AudioCodec:     meta.audioCodec,
+		AudioChannels:  meta.audioChannels,
+		SampleRate:     meta.sampleRate,
+		Tags:           meta.tags,
+		Streams:        []VideoStream{},
+		Chapters:       []VideoChapter{},
+	}
+
+	for _, s := range meta.streams {
+		report.Streams = append(report.Streams, VideoStream{
+			Index:         s.index,
+			Type:          s.codecType,
+			Codec:         s.codecName,
+			CodecLongName: s.codecLongName,
+			Profile:       s.profile,
+			Width:         s.width,
+			Height:        s.height,
+			PixelFormat:   s.pixelFormat,
+			FPS:           s.fps,
+			BitRate:       s.bitRate,
+			Duration:      s.duration,
+			Channels:      s.channels,
+			ChannelLayout: s.channelLayout,
+			SampleRate:    s.sampleRate,
+			Rotation:      s.rotation,
+			Language:      s.language,
+			Title:         s.title,
+			AttachedPic:   s.attachedPic,
+			Tags:          s.tags,
+		})
+	}
+
+	for _, c := range meta.chapters {
+		report.Chapters = append(report.Chapters, VideoChapter{
+			ID:    c.id,
+			Start: c.start,
+			End:   c.end,
+			Title: c.title,
+		})
+	}
+
+	return report
+}
@@ -0,0 +1,98 @@
+package preview
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"testing"
+
+	"video-preview-image/previewtest"
+)
+
+const ffprobeJSON = `{
+	"format": {"format_name": "mov,mp4,m4a,3gp,3g2,mj2", "duration": "62.500000", "size": "1048576", "bit_rate": "134217"},
+	"streams": [
+		{"index": 0, "codec_type": "video", "codec_name": "h264", "width": 1920, "height": 1080, "avg_frame_rate": "24000/1001", "pix_fmt": "yuv420p"},
+		{"index": 1, "codec_type": "audio", "codec_name": "aac", "channels": 2, "sample_rate": "48000"}
+	],
+	"chapters": [
+		{"id": 0, "start_time": "0.000000", "end_time": "30.000000", "tags": {"title": "Opening"}}
+	]
+}`
+
+func TestRunFFprobe(t *testing.T) {
+	fake := previewtest.NewRunner(t, func(previewtest.Call) previewtest.Result {
+		return previewtest.Result{Stdout: []byte(ffprobeJSON)}
+	})
+
+	meta, err := runFFprobe(context.Background(), fake, "movie.mp4")
+	if err != nil {
+		t.Fatalf("runFFprobe: %v", err)
+	}
+	if meta.duration != 62.5 || meta.size != 1048576 {
+		t.Errorf("时长与大小为 %v、%d，期望 62.5、1048576", meta.duration, meta.size)
+	}
+	if meta.width != 1920 || meta.height != 1080 || meta.videoCodec != "h264" || meta.videoIndex != 0 {
+		t.Errorf("视频流为 %dx%d %s (#%d)，期望 1920x1080 h264 (#0)", meta.width, meta.height, meta.videoCodec, meta.videoIndex)
+	}
+	if fps := fmt.Sprintf("%.3f", meta.fps); fps != "23.976" {
+		t.Errorf("帧率为 %s，期望 23.976", fps)
+	}
+	if meta.audioCodec != "aac" || meta.audioChannels != 2 || meta.sampleRate != 48000 {
+		t.Errorf("音频流为 %s %d 声道 %d Hz，期望 aac 2 声道 48000 Hz", meta.audioCodec, meta.audioChannels, meta.sampleRate)
+	}
+	if len(meta.chapters) != 1 || meta.chapters[0].title != "Opening" || meta.chapters[0].end != 30 {
+		t.Errorf("章节为 %+v，期望一个 0-30 秒的 Opening", meta.chapters)
+	}
+
+	calls := fake.Calls()
+	if len(calls) != 1 || calls[0].Name != ffprobePath {
+		t.Fatalf("调用为 %+v，期望调用一次 %s", calls, ffprobePath)
+	}
+	args := calls[0].Args
+	if args[len(args)-1] != "movie.mp4" {
+		t.Errorf("最后一个参数为 %q，期望输入路径", args[len(args)-1])
+	}
+	for _, want := range []string{"-show_format", "-show_streams", "-show_chapters"} {
+		if !slices.Contains(args, want) {
+			t.Errorf("参数 %q 中缺少 %s", args, want)
+		}
+	}
+}
+
+func TestRunFFprobeError(t *testing.T) {
+	// 错误中只附加 stderr 的最后几行，ffprobe 通常在最后说明失败原因。
+	var stderr strings.Builder
+	for i := range 10 {
+		fmt.Fprintf(&stderr, "noise %d\n", i)
+	}
+	stderr.WriteString("missing.mp4: No such file or directory\n")
+	fake := previewtest.NewRunner(t, func(previewtest.Call) previewtest.Result {
+		return previewtest.Result{Stderr: stderr.String(), ExitCode: 1}
+	})
+
+	_, err := runFFprobe(context.Background(), fake, "missing.mp4")
+	if err == nil {
+		t.Fatal("ffprobe 失败时 runFFprobe 应返回错误")
+	}
+	msg := err.Error()
+	for _, want := range []string{"读取视频信息失败", "exit status 1", "noise 6; noise 7", "missing.mp4: No such file or directory"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("错误 %q 中缺少 %q", msg, want)
+		}
+	}
+	if strings.Contains(msg, "noise 5") {
+		t.Errorf("错误 %q 应只包含 stderr 的最后 %d 行", msg, stderrTailLines)
+	}
+}
+
+func TestRunFFprobeInvalidJSON(t *testing.T) {
+	fake := previewtest.NewRunner(t, func(previewtest.Call) previewtest.Result {
+		return previewtest.Result{Stdout: []byte("not json")}
+	})
+
+	if _, err := runFFprobe(context.Background(), fake, "movie.mp4"); err == nil || !strings.Contains(err.Error(), "解析视频信息失败") {
+		t.Errorf("ffprobe 输出无效时应返回解析错误，实际为 %v", err)
+	}
+}
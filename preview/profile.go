@@ -0,0 +1,75 @@
+package preview
+
+import (
+	"flag"
+	"fmt"
+	"net/http"
+	"net/http/pprof"
+	"os"
+	"runtime"
+	runtimepprof "runtime/pprof"
+)
+
+var (
+	cpuProfilePath string
+	memProfilePath string
+	// stopProfiling 在程序退出前写出 profile，正常结束与 exitWithError 都会调用。
+	stopProfiling = func() {}
+)
+
+func bindProfileFlags(fs *flag.FlagSet) {
+	fs.StringVar(&cpuProfilePath, "cpuprofile", "", "将 CPU profile 写入该文件，可用 go tool pprof 分析")
+	fs.StringVar(&memProfilePath, "memprofile", "", "退出前将堆内存 profile 写入该文件，可用 go tool pprof 分析")
+}
+
+// startProfiling 按 --cpuprofile 开始 CPU 采样，并设置 stopProfiling 在退出时结束采样、写出堆内存 profile。
+func startProfiling() error {
+	var cpuFile *os.File
+	if cpuProfilePath != "" {
+		file, err := os.Create(cpuProfilePath)
+		if err != nil {
+			return fmt.Errorf("创建 CPU profile 失败: %w", err)
+		}
+		if err := runtimepprof.StartCPUProfile(file); err != nil {
+			file.Close()
+			return fmt.Errorf("开始 CPU 采样失败: %w", err)
+		}
+		cpuFile = file
+	}
+	stopProfiling = func() {
+		stopProfiling = func() {}
+		if cpuFile != nil {
+			runtimepprof.StopCPUProfile()
+			cpuFile.Close()
+		}
+		if memProfilePath != "" {
+			if err := writeHeapProfile(memProfilePath); err != nil {
+				fmt.Fprintln(os.Stderr, "警告:", err)
+			}
+		}
+	}
+	return nil
+}
+
+func writeHeapProfile(path string) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("创建堆内存 profile 失败: %w", err)
+	}
+	defer file.Close()
+	// 先执行一次 GC，使 profile 反映最新的存活对象。
+	runtime.GC()
+	if err := runtimepprof.WriteHeapProfile(file); err != nil {
+		return fmt.Errorf("写入堆内存 profile 失败: %w", err)
+	}
+	return nil
+}
+
+// registerPprof 在 mux 上注册 /debug/pprof/ 下的运行时性能分析接口。
+func registerPprof(mux *http.ServeMux) {
+	mux.HandleFunc("GET /debug/pprof/", pprof.Index)
+	mux.HandleFunc("GET /debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("GET /debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("GET /debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("GET /debug/pprof/trace", pprof.Trace)
+}
@@ -0,0 +1,246 @@
+package preview
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// imageUploader 抽象图床服务。新增图床只需实现该接口并在 imageUploaders 中注册名称。
+type imageUploader interface {
+	upload(ctx context.Context, path string) (*uploadedImage, error)
+}
+
+// uploadedImage 为上传后的图片地址；图床不提供缩略图时 thumbnail 与 url 相同。
+type uploadedImage struct {
+	url       string
+	thumbnail string
+}
+
+type publishOptions struct {
+	snippet       string
+	imgbbKey      string
+	catboxUser    string
+	customURL     string
+	customField   string
+	customToken   string
+	customJSONKey string
+}
+
+var imageUploaders = map[string]func(opts publishOptions) (imageUploader, error){
+	"imgbb":  newImgBBUploader,
+	"catbox": newCatboxUploader,
+	"custom": newCustomUploader,
+}
+
+// 上传整张拼图可能较慢，超时时间比 webhook 更长。
+var publishClient = &http.Client{Timeout: 2 * time.Minute}
+
+func validatePublish(cfg *gridConfig) error {
+	if cfg.publish == "" {
+		return nil
+	}
+	newUploader, ok := imageUploaders[cfg.publish]
+	if !ok {
+		return fmt.Errorf("不支持的图床: %s (可选: imgbb、catbox、custom)", cfg.publish)
+	}
+	switch cfg.publishOpts.snippet {
+	case "bbcode", "markdown":
+	default:
+		return fmt.Errorf("不支持的代码格式: %s (可选: bbcode、markdown)", cfg.publishOpts.snippet)
+	}
+	if cfg.framesOnly {
+		return errors.New("--publish 需要生成拼图，不能与 --frames-only 同时使用")
+	}
+	_, err := newUploader(cfg.publishOpts)
+	return err
+}
+
+// publishSheet 将生成的拼图上传到图床，并在标准输出打印可直接粘贴的 BBCode 或 Markdown 代码。
+func publishSheet(cfg *gridConfig) error {
+	uploader, err := imageUploaders[cfg.publish](cfg.publishOpts)
+	if err != nil {
+		return err
+	}
+	image, err := uploader.upload(cfg.context(), cfg.output)
+	if err != nil {
+		return fmt.Errorf("上传到 %s 失败: %w", cfg.publish, err)
+	}
+	fmt.Println(publishSnippet(cfg.publishOpts.snippet, filepath.Base(cfg.sourceName()), image))
+	return nil
+}
+
+func publishSnippet(format, name string, image *uploadedImage) string {
+	if format == "markdown" {
+		return fmt.Sprintf("[![%s](%s)](%s)", name, image.thumbnail, image.url)
+	}
+	return fmt.Sprintf("[url=%s][img]%s[/img][/url]", image.url, image.thumbnail)
+}
+
+// postMultipart 以 multipart/form-data 上传 path 指向的文件，fields 为附加的表单字段。
+func postMultipart(ctx context.Context, endpoint, fileField, path string, fields map[string]string, header http.Header) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for name, value := range fields {
+		if err := writer.WriteField(name, value); err != nil {
+			return nil, err
+		}
+	}
+	part, err := writer.CreateFormFile(fileField, filepath.Base(path))
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(part, file); err != nil {
+		return nil, err
+	}
+	if err := writer.Close(); err != nil {
+		return nil, err
+	}
+
+	request, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, &body)
+	if err != nil {
+		return nil, err
+	}
+	for name, values := range header {
+		request.Header[name] = values
+	}
+	request.Header.Set("Content-Type", writer.FormDataContentType())
+	request.Header.Set("User-Agent", toolName+"/"+version)
+
+	response, err := publishClient.Do(request)
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+	data, err := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err != nil {
+		return nil, err
+	}
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return nil, fmt.Errorf("服务器返回 %s: %s", response.Status, strings.TrimSpace(string(data)))
+	}
+	return data, nil
+}
+
+type imgbbUploader struct {
+	key string
+}
+
+func newImgBBUploader(opts publishOptions) (imageUploader, error) {
+	if opts.imgbbKey == "" {
+		return nil, errors.New("上传到 imgbb 需要通过 --imgbb-key 或 VPI_IMGBB_KEY 提供 API 密钥")
+	}
+	return &imgbbUploader{key: opts.imgbbKey}, nil
+}
+
+func (u *imgbbUploader) upload(ctx context.Context, path string) (*uploadedImage, error) {
+	data, err := postMultipart(ctx, "https://api.imgbb.com/1/upload?key="+u.key, "image", path, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	var response struct {
+		Data struct {
+			URL   string `json:"url"`
+			Thumb struct {
+				URL string `json:"url"`
+			} `json:"thumb"`
+			Medium struct {
+				URL string `json:"url"`
+			} `json:"medium"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(data, &response); err != nil || response.Data.URL == "" {
+		return nil, fmt.Errorf("无法解析 imgbb 响应: %s", strings.TrimSpace(string(data)))
+	}
+	// 小图不会生成 medium 版本，此时退回 thumb。
+	image := &uploadedImage{url: response.Data.URL, thumbnail: response.Data.Medium.URL}
+	if image.thumbnail == "" {
+		image.thumbnail = response.Data.Thumb.URL
+	}
+	if image.thumbnail == "" {
+		image.thumbnail = image.url
+	}
+	return image, nil
+}
+
+type catboxUploader struct {
+	userhash string
+}
+
+func newCatboxUploader(opts publishOptions) (imageUploader, error) {
+	return &catboxUploader{userhash: opts.catboxUser}, nil
+}
+
+// upload 使用 catbox.moe 的文件接口，未指定 userhash 时匿名上传；响应为纯文本的文件地址。
+func (u *catboxUploader) upload(ctx context.Context, path string) (*uploadedImage, error) {
+	fields := map[string]string{"reqtype": "fileupload"}
+	if u.userhash != "" {
+		fields["userhash"] = u.userhash
+	}
+	data, err := postMultipart(ctx, "https://catbox.moe/user/api.php", "fileToUpload", path, fields, nil)
+	if err != nil {
+		return nil, err
+	}
+	url := strings.TrimSpace(string(data))
+	if !strings.HasPrefix(url, "https://") {
+		return nil, fmt.Errorf("catbox 返回了无效的地址: %s", url)
+	}
+	return &uploadedImage{url: url, thumbnail: url}, nil
+}
+
+// customUploader 将图片以 multipart 表单上传到自建服务，响应可以是纯文本地址，
+// 也可以是 JSON，此时按 --publish-json-key 指定的以点分隔的路径 (例如 data.url) 读取地址。
+type customUploader struct {
+	endpoint string
+	field    string
+	token    string
+	jsonKey  string
+}
+
+func newCustomUploader(opts publishOptions) (imageUploader, error) {
+	if opts.customURL == "" {
+		return nil, errors.New("--publish custom 需要通过 --publish-url 指定上传地址")
+	}
+	return &customUploader{endpoint: opts.customURL, field: opts.customField, token: opts.customToken, jsonKey: opts.customJSONKey}, nil
+}
+
+func (u *customUploader) upload(ctx context.Context, path string) (*uploadedImage, error) {
+	header := http.Header{}
+	if u.token != "" {
+		header.Set("Authorization", "Bearer "+u.token)
+	}
+	data, err := postMultipart(ctx, u.endpoint, u.field, path, nil, header)
+	if err != nil {
+		return nil, err
+	}
+
+	text := strings.TrimSpace(string(data))
+	url := text
+	var value any
+	if json.Unmarshal(data, &value) == nil {
+		for _, key := range strings.Split(u.jsonKey, ".") {
+			object, _ := value.(map[string]any)
+			value = object[key]
+		}
+		url, _ = value.(string)
+	}
+	if !strings.HasPrefix(url, "http://") && !strings.HasPrefix(url, "https://") {
+		return nil, fmt.Errorf("无法从响应中读取图片地址: %s", text)
+	}
+	return &uploadedImage{url: url, thumbnail: url}, nil
+}
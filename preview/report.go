@@ -0,0 +1,87 @@
+package preview
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// batchReportEntry 是批量报告中的一行，status 为 success、failed 或 skipped (上次运行已完成)。
+type batchReportEntry struct {
+	Input    string  `json:"input"`
+	Output   string  `json:"output"`
+	Status   string  `json:"status"`
+	WallTime float64 `json:"wall_time"`
+	Error    string  `json:"error,omitempty"`
+}
+
+func newBatchReportEntry(job manifestJob, cfg *gridConfig, elapsed time.Duration, err error) batchReportEntry {
+	entry := batchReportEntry{
+		Input:    job.Input,
+		Output:   job.Output,
+		Status:   "success",
+		WallTime: elapsed.Seconds(),
+	}
+	if cfg != nil {
+		entry.Output = cfg.outputName()
+	}
+	if err != nil {
+		entry.Status = "failed"
+		entry.Error = err.Error()
+	}
+	return entry
+}
+
+// reportFormat 返回批量报告的扩展名 (.csv 或 .json)，在处理任务清单之前校验，避免批量任务结束后才发现格式错误。
+func reportFormat(path string) (string, error) {
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".csv" && ext != ".json" {
+		return "", fmt.Errorf("不支持的报告格式: %s (仅支持 .csv 与 .json)", path)
+	}
+	return ext, nil
+}
+
+func writeBatchReport(path string, entries []batchReportEntry) error {
+	format, err := reportFormat(path)
+	if err != nil {
+		return err
+	}
+	if err := ensureOutputDir(path); err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("创建批量报告失败: %w", err)
+	}
+	defer file.Close()
+
+	switch format {
+	case ".json":
+		encoder := json.NewEncoder(file)
+		encoder.SetIndent("", "  ")
+		err = encoder.Encode(entries)
+	case ".csv":
+		writer := csv.NewWriter(file)
+		writer.Write([]string{"input", "output", "status", "wall_time", "error"})
+		for _, entry := range entries {
+			writer.Write([]string{
+				entry.Input,
+				entry.Output,
+				entry.Status,
+				strconv.FormatFloat(entry.WallTime, 'f', 3, 64),
+				entry.Error,
+			})
+		}
+		writer.Flush()
+		err = writer.Error()
+	}
+	if err != nil {
+		return fmt.Errorf("写入批量报告失败: %w", err)
+	}
+	return file.Close()
+}
@@ -0,0 +1,43 @@
+package preview
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestParseFlagsReportFormat(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := parseFlags([]string{"--manifest", filepath.Join(dir, "jobs.csv"), "--report", filepath.Join(dir, "out.txt")}); err == nil || !strings.Contains(err.Error(), "不支持的报告格式") {
+		t.Errorf("report 扩展名错误时应在解析参数时报错，实际为 %v", err)
+	}
+	if _, err := parseFlags([]string{"--manifest", filepath.Join(dir, "jobs.csv"), "--report", filepath.Join(dir, "out.JSON")}); err != nil {
+		t.Errorf("parseFlags: %v", err)
+	}
+}
+
+func TestWriteBatchReport(t *testing.T) {
+	dir := t.TempDir()
+	entries := []batchReportEntry{{Input: "a.mp4", Output: "a.png", Status: "ok", WallTime: 1.5}}
+
+	bad := filepath.Join(dir, "report.txt")
+	if err := writeBatchReport(bad, entries); err == nil {
+		t.Error("不支持的报告格式应返回错误")
+	}
+	if _, err := os.Stat(bad); !os.IsNotExist(err) {
+		t.Errorf("格式错误时不应创建 %s", bad)
+	}
+
+	path := filepath.Join(dir, "report.csv")
+	if err := writeBatchReport(path, entries); err != nil {
+		t.Fatalf("writeBatchReport: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "input,output,status,wall_time,error\na.mp4,a.png,ok,1.500,\n"; string(data) != want {
+		t.Errorf("报告内容为 %q，期望 %q", data, want)
+	}
+}
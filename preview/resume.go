@@ -0,0 +1,104 @@
+package preview
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// batchState 记录任务清单中已完成的任务，中断后重新运行同一清单时跳过这些任务。
+// 中途被打断的任务会重新执行，已截取的帧可从截图缓存读取。
+type batchState struct {
+	path string
+	mu   sync.Mutex
+
+	Completed []completedJob `json:"completed"`
+}
+
+type completedJob struct {
+	Job        manifestJob `json:"job"`
+	Output     string      `json:"output"`
+	FinishedAt time.Time   `json:"finished_at"`
+}
+
+func defaultStatePath(manifest string) string {
+	return manifest + ".state.json"
+}
+
+// loadBatchState 读取状态文件，文件不存在时返回空状态；resume 为 false 时忽略已有记录。
+func loadBatchState(path string, resume bool) (*batchState, error) {
+	state := &batchState{path: path}
+	if !resume {
+		return state, nil
+	}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return state, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取进度文件失败: %w", err)
+	}
+	if err := json.Unmarshal(data, state); err != nil {
+		return nil, fmt.Errorf("解析进度文件 %s 失败: %w (可使用 --no-resume 重新开始)", path, err)
+	}
+	return state, nil
+}
+
+// completedOutput 返回任务在之前的运行中生成的输出；本地输出文件已被删除时视为未完成。
+func (s *batchState) completedOutput(job manifestJob) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, entry := range s.Completed {
+		if entry.Job != job {
+			continue
+		}
+		if isRemoteURI(entry.Output) {
+			return entry.Output, true
+		}
+		_, err := os.Stat(entry.Output)
+		return entry.Output, err == nil
+	}
+	return "", false
+}
+
+func (s *batchState) markDone(job manifestJob, output string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Completed = append(s.Completed, completedJob{Job: job, Output: output, FinishedAt: time.Now().UTC()})
+	return s.save()
+}
+
+// save 先写临时文件再重命名，进程在写入过程中崩溃也不会损坏已有的进度。
+func (s *batchState) save() error {
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".state-*.json")
+	if err != nil {
+		return err
+	}
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), s.path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+	}
+	return err
+}
+
+// remove 在整个清单全部成功后删除状态文件，下一次运行会重新处理所有任务。
+func (s *batchState) remove() error {
+	if err := os.Remove(s.path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
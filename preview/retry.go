@@ -0,0 +1,59 @@
+package preview
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// permanentErrorPatterns 为 ffmpeg/ffprobe 标准错误输出中表示重试也不会成功的错误 (文件损坏、编码不受支持等)。
+var permanentErrorPatterns = []string{
+	"Invalid data found when processing input",
+	"moov atom not found",
+	"not found for input stream",
+	"Unknown decoder",
+	"Decoder not found",
+	"does not contain any stream",
+	"No such file or directory",
+	"Permission denied",
+}
+
+// isTransientError 判断错误是否可能是暂时性的：外部命令异常退出 (网络输入中断、NFS 读取超时等)
+// 或读取文件时的 I/O 错误。文件不存在、无权限以及 permanentErrorPatterns 中的错误视为永久错误。
+func isTransientError(err error) bool {
+	if errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrPermission) || errors.Is(err, exec.ErrNotFound) {
+		return false
+	}
+	msg := err.Error()
+	for _, pattern := range permanentErrorPatterns {
+		if strings.Contains(msg, pattern) {
+			return false
+		}
+	}
+	var exitErr *exec.ExitError
+	var pathErr *fs.PathError
+	return errors.As(err, &exitErr) || errors.As(err, &pathErr)
+}
+
+// withRetry 执行 fn，遇到暂时性错误时从 --retry-delay 起按倍增的间隔重试，最多重试 --retries 次；
+// 永久错误与任务取消时立即返回。
+func withRetry(cfg *gridConfig, what string, fn func() error) error {
+	delay := cfg.retryDelay
+	for attempt := 1; ; attempt++ {
+		err := fn()
+		if err == nil || attempt > cfg.retries || cfg.context().Err() != nil || !isTransientError(err) {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "警告: %s失败，%s 后重试 (%d/%d): %v\n", what, delay, attempt, cfg.retries, err)
+		select {
+		case <-cfg.context().Done():
+			return err
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
@@ -0,0 +1,240 @@
+package preview
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+)
+
+// JSON-RPC 2.0 错误码，-32000 为本程序处理请求失败。
+const (
+	rpcParseError     = -32700
+	rpcInvalidRequest = -32600
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+	rpcFailed         = -32000
+)
+
+// maxRPCMessage 为单条请求的最大字节数。
+const maxRPCMessage = 4 << 20
+
+var errRPCStdioRequested = errors.New("rpc-stdio requested")
+
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+type rpcMessage struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method,omitempty"`
+	Params  any             `json:"params,omitempty"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// rpcGenerateParams 与 gRPC 请求、队列任务相同，options 以参数名为键。
+type rpcGenerateParams struct {
+	Input   string            `json:"input"`
+	Output  string            `json:"output"`
+	Options map[string]string `json:"options"`
+}
+
+type rpcGenerateResult struct {
+	Input      string    `json:"input"`
+	Output     string    `json:"output,omitempty"`
+	Animation  string    `json:"animation,omitempty"`
+	FramesDir  string    `json:"frames_dir,omitempty"`
+	Duration   float64   `json:"duration"`
+	Timestamps []float64 `json:"timestamps"`
+}
+
+// rpcProgress 为 generate 请求的进度通知，id 为对应请求的 id。
+type rpcProgress struct {
+	ID          json.RawMessage `json:"id"`
+	FramesDone  int             `json:"frames_done"`
+	FramesTotal int             `json:"frames_total"`
+}
+
+// rpcServer 在标准输入输出上处理按行分隔的 JSON-RPC 2.0 消息：每行一条请求，响应与通知同样每行一条。
+// 请求并发处理，响应的顺序与请求不一定相同，以 id 对应。
+type rpcServer struct {
+	mu      sync.Mutex
+	enc     *json.Encoder
+	running map[string]context.CancelFunc
+	wg      sync.WaitGroup
+}
+
+// runRPCStdio 供 GUI 前端或编辑器插件作为子进程驱动：支持 generate、probe 与 cancel，
+// 输入结束 (前端关闭标准输入) 后等待进行中的请求完成再退出，收到中断信号时取消全部请求。
+func runRPCStdio() error {
+	if err := ensureExecutables(); err != nil {
+		return err
+	}
+	// 标准输出只用于 JSON-RPC 消息，生成过程中的提示信息改走标准错误。
+	out := os.Stdout
+	os.Stdout = os.Stderr
+
+	s := &rpcServer{enc: json.NewEncoder(out), running: make(map[string]context.CancelFunc)}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	scanner := bufio.NewScanner(os.Stdin)
+	scanner.Buffer(make([]byte, 64<<10), maxRPCMessage)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var req rpcRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			s.reply(nil, nil, &rpcError{Code: rpcParseError, Message: err.Error()})
+			continue
+		}
+		if req.JSONRPC != "2.0" || req.Method == "" {
+			s.reply(req.ID, nil, &rpcError{Code: rpcInvalidRequest, Message: "需要 jsonrpc 为 2.0 且指定 method"})
+			continue
+		}
+		s.wg.Go(func() { s.handle(ctx, req) })
+	}
+	s.wg.Wait()
+	if err := scanner.Err(); err != nil && err != io.EOF {
+		return fmt.Errorf("读取 JSON-RPC 请求失败: %w", err)
+	}
+	return nil
+}
+
+func (s *rpcServer) handle(ctx context.Context, req rpcRequest) {
+	var result any
+	var rerr *rpcError
+	switch req.Method {
+	case "generate":
+		result, rerr = s.generate(ctx, req)
+	case "probe":
+		result, rerr = s.probe(ctx, req)
+	case "cancel":
+		result, rerr = s.cancel(req)
+	default:
+		rerr = &rpcError{Code: rpcMethodNotFound, Message: "未知方法: " + req.Method}
+	}
+	// 没有 id 的请求为通知，不回复。
+	if req.ID != nil {
+		s.reply(req.ID, result, rerr)
+	}
+}
+
+func (s *rpcServer) generate(ctx context.Context, req rpcRequest) (any, *rpcError) {
+	var params rpcGenerateParams
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+	}
+	cfg, err := jobConfig(params.Input, params.Output, params.Options)
+	if err != nil {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	if req.ID != nil {
+		key := string(req.ID)
+		s.mu.Lock()
+		s.running[key] = cancel
+		s.mu.Unlock()
+		defer func() {
+			s.mu.Lock()
+			delete(s.running, key)
+			s.mu.Unlock()
+		}()
+		cfg.progress = func(done, total int) {
+			s.notify("progress", rpcProgress{ID: req.ID, FramesDone: done, FramesTotal: total})
+		}
+	}
+	cfg.ctx = ctx
+
+	result, err := runPreview(cfg)
+	if err != nil {
+		return nil, &rpcError{Code: rpcFailed, Message: err.Error()}
+	}
+	response := rpcGenerateResult{
+		Input:      cfg.sourceName(),
+		Animation:  cfg.animOutput,
+		FramesDir:  cfg.saveFramesDir,
+		Duration:   result.duration,
+		Timestamps: result.timestamps,
+	}
+	if !cfg.framesOnly {
+		response.Output = cfg.outputName()
+	}
+	return response, nil
+}
+
+func (s *rpcServer) probe(ctx context.Context, req rpcRequest) (any, *rpcError) {
+	var params struct {
+		Input string `json:"input"`
+	}
+	if err := json.Unmarshal(req.Params, &params); err != nil {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: err.Error()}
+	}
+	if params.Input == "" {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: "必须指定输入视频路径 input"}
+	}
+	meta, err := probeVideo(ctx, nil, params.Input)
+	if err != nil {
+		return nil, &rpcError{Code: rpcFailed, Message: err.Error()}
+	}
+	return newMetadataReport(meta), nil
+}
+
+// cancel 取消 id 对应的 generate 请求，被取消的请求以错误响应结束。
+func (s *rpcServer) cancel(req rpcRequest) (any, *rpcError) {
+	var params struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(req.Params, &params); err != nil || params.ID == nil {
+		return nil, &rpcError{Code: rpcInvalidParams, Message: "必须指定要取消的请求 id"}
+	}
+	s.mu.Lock()
+	cancel, ok := s.running[string(params.ID)]
+	s.mu.Unlock()
+	if ok {
+		cancel()
+	}
+	return map[string]bool{"cancelled": ok}, nil
+}
+
+func (s *rpcServer) reply(id json.RawMessage, result any, rerr *rpcError) {
+	if id == nil {
+		id = json.RawMessage("null")
+	}
+	if rerr == nil && result == nil {
+		result = struct{}{}
+	}
+	s.write(rpcMessage{JSONRPC: "2.0", ID: id, Result: result, Error: rerr})
+}
+
+func (s *rpcServer) notify(method string, params any) {
+	s.write(rpcMessage{JSONRPC: "2.0", Method: method, Params: params})
+}
+
+func (s *rpcServer) write(msg rpcMessage) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err := s.enc.Encode(msg); err != nil {
+		fmt.Fprintln(os.Stderr, "警告: 写入 JSON-RPC 消息失败:", err)
+	}
+}
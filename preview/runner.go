@@ -0,0 +1,64 @@
+package preview
+
+import (
+	"context"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Runner 创建调用 ffmpeg、ffprobe 等外部程序的命令，生成过程中的所有外部调用都经过 Generator.Runner。
+// 测试时可替换为记录参数、改为启动假程序的实现 (例如 previewtest.Runner)，在没有安装 ffmpeg 的环境中运行完整流程。
+type Runner interface {
+	Command(ctx context.Context, name string, args ...string) *exec.Cmd
+}
+
+type execRunner struct{}
+
+func (execRunner) Command(ctx context.Context, name string, args ...string) *exec.Cmd {
+	return exec.CommandContext(ctx, name, args...)
+}
+
+// newCommand 以 r 创建命令，r 为 nil 时直接启动外部程序。
+func newCommand(ctx context.Context, r Runner, name string, args ...string) *exec.Cmd {
+	if r == nil {
+		r = execRunner{}
+	}
+	return r.Command(ctx, name, args...)
+}
+
+const (
+	// 错误信息中附加的标准错误输出行数，ffmpeg 与 ffprobe 通常在最后几行说明失败原因。
+	stderrTailLines = 5
+	maxStderrBytes  = 16 << 10
+)
+
+// stderrTail 收集外部命令的标准错误输出，只保留最后 maxStderrBytes 字节，info 级别的长日志也不会占用过多内存。
+type stderrTail struct {
+	data []byte
+}
+
+func (t *stderrTail) Write(p []byte) (int, error) {
+	t.data = append(t.data, p...)
+	if over := len(t.data) - maxStderrBytes; over > 0 {
+		t.data = append(t.data[:0], t.data[over:]...)
+	}
+	return len(p), nil
+}
+
+func (t *stderrTail) Bytes() []byte {
+	return t.data
+}
+
+// commandError 在外部命令的错误后附加其标准错误输出的最后几行；exec.ExitError 本身只有 "exit status 1"。
+func commandError(err error, stderr []byte) error {
+	lines := strings.Split(strings.TrimSpace(string(stderr)), "\n")
+	if len(lines) > stderrTailLines {
+		lines = lines[len(lines)-stderrTailLines:]
+	}
+	msg := strings.TrimSpace(strings.Join(lines, "; "))
+	if msg == "" {
+		return err
+	}
+	return fmt.Errorf("%w: %s", err, msg)
+}
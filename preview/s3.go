@@ -0,0 +1,94 @@
+package preview
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/feature/s3/manager"
+	"github.com/aws/aws-sdk-go-v2/service/s3"
+)
+
+type s3Backend struct {
+	client *s3.Client
+}
+
+// newS3Backend 使用 AWS 标准配置链 (环境变量、~/.aws/config、实例角色等)；自定义端点通常是 MinIO 等兼容服务，需要路径风格地址。
+func newS3Backend(ctx context.Context, opts storageOptions) (storageBackend, error) {
+	var loadOptions []func(*config.LoadOptions) error
+	if opts.s3Region != "" {
+		loadOptions = append(loadOptions, config.WithRegion(opts.s3Region))
+	}
+	awsConfig, err := config.LoadDefaultConfig(ctx, loadOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("加载 AWS 凭证失败: %w", err)
+	}
+	client := s3.NewFromConfig(awsConfig, func(o *s3.Options) {
+		if opts.s3Endpoint != "" {
+			o.BaseEndpoint = aws.String(opts.s3Endpoint)
+			o.UsePathStyle = true
+		}
+	})
+	return &s3Backend{client: client}, nil
+}
+
+func (b *s3Backend) presign(ctx context.Context, uri string, expiry time.Duration) (string, error) {
+	bucket, key, err := splitStorageURI(uri)
+	if err != nil {
+		return "", err
+	}
+	request, err := s3.NewPresignClient(b.client).PresignGetObject(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	}, s3.WithPresignExpires(expiry))
+	if err != nil {
+		return "", err
+	}
+	return request.URL, nil
+}
+
+func (b *s3Backend) download(ctx context.Context, uri, path string) error {
+	bucket, key, err := splitStorageURI(uri)
+	if err != nil {
+		return err
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = manager.NewDownloader(b.client).Download(ctx, file, &s3.GetObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+	})
+	return err
+}
+
+func (b *s3Backend) upload(ctx context.Context, path, uri string) error {
+	bucket, key, err := splitStorageURI(uri)
+	if err != nil {
+		return err
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	input := &s3.PutObjectInput{
+		Bucket: aws.String(bucket),
+		Key:    aws.String(key),
+		Body:   file,
+	}
+	if contentType := mime.TypeByExtension(filepath.Ext(key)); contentType != "" {
+		input.ContentType = aws.String(contentType)
+	}
+	_, err = manager.NewUploader(b.client).Upload(ctx, input)
+	return err
+}
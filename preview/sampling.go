@@ -0,0 +1,50 @@
+package preview
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+)
+
+func validateSampleMode(mode string) error {
+	switch mode {
+	case "uniform", "random":
+		return nil
+	default:
+		return fmt.Errorf("不支持的采样方式: %s (可选: uniform、random)", mode)
+	}
+}
+
+// sampleTimestamps 按 --sample 在长度为 duration 的范围内生成 count 个采样时间点。
+func (cfg *gridConfig) sampleTimestamps(duration float64, count int) []float64 {
+	if cfg.sample != "random" {
+		return sampleTimestamps(duration, count)
+	}
+	rng := styleRand(cfg.sourceName())
+	if cfg.seed != 0 {
+		rng = rand.New(rand.NewPCG(uint64(cfg.seed), uint64(cfg.seed)>>1))
+	}
+	return randomTimestamps(duration, count, rng)
+}
+
+// randomTimestamps 在去掉首尾各半个均匀间隔的范围内随机取 count 个时间点，相邻两点至少相隔平均间隔的一半，
+// 避免取到几乎相同的画面：先在扣除最小间隔后的剩余长度内均匀取点并排序，再依次加回最小间隔。
+func randomTimestamps(duration float64, count int, rng *rand.Rand) []float64 {
+	if count <= 0 {
+		return nil
+	}
+	margin := duration / float64(2*(count+1))
+	span := duration - 2*margin
+	gap := span / float64(2*count)
+	free := span - gap*float64(count-1)
+
+	timestamps := make([]float64, count)
+	for i := range timestamps {
+		timestamps[i] = rng.Float64() * free
+	}
+	slices.Sort(timestamps)
+	for i := range timestamps {
+		timestamps[i] += margin + gap*float64(i)
+	}
+	return timestamps
+}
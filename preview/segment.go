@@ -0,0 +1,125 @@
+package preview
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"path/filepath"
+	"strings"
+)
+
+// 章节标题用于文件名时最多保留的字符数，避免超出文件系统的文件名长度限制。
+const maxChapterFileName = 64
+
+type timeSpan struct {
+	start, end float64
+	// label 显示在信息栏中，例如 "Segment 2/6"。
+	label string
+	// suffix 追加到输出文件名中，用于区分各张拼图。
+	suffix string
+}
+
+func (s *timeSpan) headerLine() string {
+	return fmt.Sprintf("%s: %s - %s", s.label, formatTimestamp(s.start), formatTimestamp(s.end))
+}
+
+// splitSheets 报告是否将视频拆分为多张拼图分别输出。
+func (cfg *gridConfig) splitSheets() bool {
+	return cfg.segment > 0 || cfg.sheetPerChapter || cfg.paged
+}
+
+// sampleRange 返回采样范围的起点与长度，未拆分时为整个视频。
+func (cfg *gridConfig) sampleRange(meta *videoMetadata) (float64, float64) {
+	if cfg.span == nil {
+		return 0, meta.duration
+	}
+	return cfg.span.start, cfg.span.end - cfg.span.start
+}
+
+// segmentSpans 将视频按 length 秒切分，最后一段不足 length 时保留实际长度。
+func segmentSpans(duration, length float64) []timeSpan {
+	count := max(int(math.Ceil(duration/length-1e-9)), 1)
+	digits := max(len(fmt.Sprint(count)), 3)
+	spans := make([]timeSpan, count)
+	for i := range spans {
+		spans[i] = timeSpan{
+			start:  float64(i) * length,
+			end:    math.Min(float64(i+1)*length, duration),
+			label:  fmt.Sprintf("Segment %d/%d", i+1, count),
+			suffix: fmt.Sprintf("%0*d", digits, i+1),
+		}
+	}
+	return spans
+}
+
+// chapterSpans 为每个章节生成一个时间范围，输出文件名使用章节序号与标题，例如 concert_03_Encore.jpg。
+func chapterSpans(meta *videoMetadata) []timeSpan {
+	digits := max(len(fmt.Sprint(len(meta.chapters))), 2)
+	var spans []timeSpan
+	for i, chapter := range meta.chapters {
+		end := math.Min(chapter.end, meta.duration)
+		if end <= chapter.start {
+			continue
+		}
+		label := fmt.Sprintf("Chapter %d/%d", i+1, len(meta.chapters))
+		suffix := fmt.Sprintf("%0*d", digits, i+1)
+		if title := sanitizeFileName(chapter.title); title != "" {
+			label += " " + chapter.title
+			suffix += "_" + title
+		}
+		spans = append(spans, timeSpan{start: chapter.start, end: end, label: label, suffix: suffix})
+	}
+	return spans
+}
+
+// sanitizeFileName 将标题中不能用于文件名的字符替换为下划线，并去掉首尾的空白与点号。
+func sanitizeFileName(name string) string {
+	name = strings.Map(func(r rune) rune {
+		switch {
+		case r < 0x20, strings.ContainsRune(`<>:"/\|?*`, r):
+			return '_'
+		}
+		return r
+	}, name)
+	if runes := []rune(name); len(runes) > maxChapterFileName {
+		name = string(runes[:maxChapterFileName])
+	}
+	return strings.Trim(name, " .")
+}
+
+// spanOutput 在扩展名之前追加后缀，例如 sheet.jpg 对应 sheet_001.jpg。
+func spanOutput(path, suffix string) string {
+	if path == "" {
+		return ""
+	}
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + "_" + suffix + ext
+}
+
+// generateSpans 为每个时间范围分别生成一张拼图；动态预览与单帧截图同样按范围分别输出，互不覆盖。
+func generateSpans(cfg *gridConfig, meta *videoMetadata, result *previewResult, spans []timeSpan) error {
+	if cfg.destination != "" {
+		return errors.New("拆分生成多张拼图时不支持输出到对象存储")
+	}
+	for i := range spans {
+		job := *cfg
+		job.span = &spans[i]
+		job.output = spanOutput(cfg.output, spans[i].suffix)
+		job.extraOutputs = make([]string, len(cfg.extraOutputs))
+		for j, path := range cfg.extraOutputs {
+			job.extraOutputs[j] = spanOutput(path, spans[i].suffix)
+		}
+		job.animOutput = spanOutput(cfg.animOutput, spans[i].suffix)
+		if cfg.saveFramesDir != "" {
+			job.saveFramesDir = filepath.Join(cfg.saveFramesDir, spans[i].suffix)
+		}
+		if err := renderPreview(&job, meta, result); err != nil {
+			return fmt.Errorf("%s: %w", spans[i].label, err)
+		}
+		if !cfg.framesOnly && !cfg.dryRun {
+			fmt.Printf("已生成 %s (%s - %s): %s\n", spans[i].label,
+				formatTimestamp(spans[i].start), formatTimestamp(spans[i].end), job.outputNames())
+		}
+	}
+	return nil
+}
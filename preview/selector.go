@@ -0,0 +1,125 @@
+package preview
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+const (
+	// thumbnail 滤镜每个分析批次最多使用的帧数，窗口更长时只分析窗口开头的这些帧。
+	maxThumbnailBatch = 300
+	sceneThreshold    = 0.3
+)
+
+var showinfoPTSPattern = regexp.MustCompile(`pts_time:\s*(-?[0-9.]+)`)
+
+func validateSelector(name string) error {
+	switch name {
+	case "uniform", "thumbnail", "scene":
+		return nil
+	default:
+		return fmt.Errorf("不支持的选帧方式: %s (可选: uniform、thumbnail、scene)", name)
+	}
+}
+
+// sampleFrame 返回第 index 个采样帧及其实际时间点。uniform 直接截取 timestamp 处的帧；
+// thumbnail 与 scene 把视频均分为 total 个窗口，在第 index 个窗口内挑选代表帧或镜头切换后的第一帧。
+func sampleFrame(cfg *gridConfig, meta *videoMetadata, index, total int, timestamp float64, filters []string) (image.Image, float64, error) {
+	if cfg.selector == "uniform" || cfg.selector == "" {
+		frame, err := captureAt(cfg, timestamp, filters)
+		return frame, timestamp, err
+	}
+
+	start, window, selectFilter := selectorWindow(cfg, meta, index, total)
+	frame, offset, err := captureSelected(cfg.context(), cfg, start, window, append([]string{selectFilter, "showinfo"}, filters...))
+	if err != nil {
+		return nil, 0, err
+	}
+	// 窗口内没有镜头切换时退回窗口内的均匀采样点。
+	if frame == nil {
+		frame, err = captureAt(cfg, timestamp, filters)
+		return frame, timestamp, err
+	}
+	return frame, start + offset, nil
+}
+
+// selectorWindow 返回 thumbnail/scene 选帧时第 index 个窗口的起点、时长与选帧滤镜。
+func selectorWindow(cfg *gridConfig, meta *videoMetadata, index, total int) (float64, float64, string) {
+	rangeStart, length := cfg.sampleRange(meta)
+	window := length / float64(total)
+	start := rangeStart + window*float64(index)
+	var selectFilter string
+	switch cfg.selector {
+	case "thumbnail":
+		fps := meta.fps
+		if fps <= 0 {
+			fps = 25
+		}
+		batch := min(max(int(window*fps), 1), maxThumbnailBatch)
+		selectFilter = fmt.Sprintf("thumbnail=%d", batch)
+	case "scene":
+		selectFilter = fmt.Sprintf("select=gt(scene\\,%g)", sceneThreshold)
+	}
+	return start, window, selectFilter
+}
+
+// captureAt 截取 timestamp 处的一帧，--backend libav 时在进程内解码，不启动 ffmpeg。
+func captureAt(cfg *gridConfig, timestamp float64, filters []string) (image.Image, error) {
+	if cfg.backend == "libav" {
+		return captureFrameLibav(cfg.context(), cfg.input, cfg.streamIndex, timestamp, filters, cfg.keyframesOnly)
+	}
+	return captureFrame(cfg.context(), cfg.runner, cfg.input, cfg.streamIndex, timestamp, filters, cfg.keyframesOnly, cfg.pipeCodec)
+}
+
+// captureSelected 从 start 起读取 window 秒，输出滤镜链选出的第一帧，并从 showinfo 日志中解析该帧相对 start 的时间。
+// 滤镜未选出任何帧时返回 nil 图像。
+func captureSelected(ctx context.Context, cfg *gridConfig, start, window float64, filters []string) (image.Image, float64, error) {
+	cmd := newCommand(ctx, cfg.runner, ffmpegPath, selectedFrameArgs(cfg, start, window, filters)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		observeToolFailure("capture")
+		return nil, 0, commandError(err, stderr.Bytes())
+	}
+	if stdout.Len() == 0 {
+		return nil, 0, nil
+	}
+
+	img, err := decodePipeFrame(bufio.NewReader(&stdout), cfg.pipeCodec)
+	if err != nil {
+		observeToolFailure("capture")
+		return nil, 0, err
+	}
+	framesCaptured.Inc()
+
+	var offset float64
+	if match := showinfoPTSPattern.FindSubmatch(stderr.Bytes()); match != nil {
+		offset, _ = strconv.ParseFloat(string(match[1]), 64)
+	}
+	return img, offset, nil
+}
+
+func selectedFrameArgs(cfg *gridConfig, start, window float64, filters []string) []string {
+	args := []string{"-hide_banner", "-nostats", "-loglevel", "info"}
+	if cfg.keyframesOnly {
+		args = append(args, "-skip_frame", "nokey")
+	}
+	args = append(args,
+		"-ss", fmt.Sprintf("%.3f", start),
+		"-t", fmt.Sprintf("%.3f", window),
+		"-i", toolPath(cfg.input),
+		"-map", fmt.Sprintf("0:%d", cfg.streamIndex),
+		"-vf", strings.Join(filters, ","),
+		"-frames:v", "1",
+		"-f", "image2pipe",
+	)
+	args = append(args, pipeCodecArgs(cfg.pipeCodec)...)
+	return append(args, "-")
+}
@@ -0,0 +1,269 @@
+package preview
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type serverConfig struct {
+	listen        string
+	maxConcurrent int
+	queueSize     int
+	timeout       time.Duration
+	maxUploadMB   int64
+	pprof         bool
+}
+
+var (
+	httpRejected = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "vpi_http_rejected_total",
+		Help: "队列已满被拒绝 (429) 的请求数。",
+	})
+	httpTimeouts = prometheus.NewCounter(prometheus.CounterOpts{
+		Name: "vpi_http_timeouts_total",
+		Help: "排队或处理超过 --timeout 的请求数。",
+	})
+)
+
+func newServeFlagSet() (*flag.FlagSet, *serverConfig) {
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	sc := &serverConfig{}
+	fs.StringVar(&sc.listen, "listen", ":8080", "HTTP 服务监听地址，/metrics 也在该地址提供")
+	fs.IntVar(&sc.maxConcurrent, "max-concurrent", 2, "同时运行的 ffmpeg 处理流程数")
+	fs.IntVar(&sc.queueSize, "queue-size", 8, "等待处理的请求数上限，超过后返回 429")
+	fs.DurationVar(&sc.timeout, "timeout", 5*time.Minute, "单个请求从排队到生成完成的超时时间")
+	fs.Int64Var(&sc.maxUploadMB, "max-upload-mb", 2048, "上传视频的大小上限 (MB)")
+	fs.BoolVar(&sc.pprof, "pprof", false, "在 /debug/pprof/ 提供 Go 运行时性能分析接口，仅应在可信网络中开启")
+	bindToolFlags(fs)
+	bindFontFlags(fs)
+	return fs, sc
+}
+
+func parseServeFlags(args []string) (*serverConfig, error) {
+	fs, sc := newServeFlagSet()
+	if err := parseArgs(fs, args); err != nil {
+		return nil, err
+	}
+	if sc.maxConcurrent < 1 {
+		return nil, errors.New("max-concurrent 必须为正整数")
+	}
+	if sc.queueSize < 0 {
+		return nil, errors.New("queue-size 不能为负数")
+	}
+	if sc.timeout <= 0 {
+		return nil, errors.New("timeout 必须大于 0")
+	}
+	if sc.maxUploadMB <= 0 {
+		return nil, errors.New("max-upload-mb 必须为正整数")
+	}
+	return sc, nil
+}
+
+func runServe(args []string) error {
+	sc, err := parseServeFlags(args)
+	if err != nil {
+		return err
+	}
+
+	if err := ensureExecutables(); err != nil {
+		return err
+	}
+
+	ps := newPreviewHTTPServer(sc)
+	metricsRegistry.MustRegister(httpRejected, httpTimeouts, prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "vpi_http_queued_requests",
+		Help: "已进入队列但尚未开始处理的请求数。",
+	}, func() float64 {
+		return float64(len(ps.admitted) - len(ps.running))
+	}))
+
+	listener, err := net.Listen("tcp", sc.listen)
+	if err != nil {
+		return fmt.Errorf("监听 %s 失败: %w", sc.listen, err)
+	}
+
+	server := &http.Server{Handler: ps.routes(), ReadHeaderTimeout: 10 * time.Second}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), sc.timeout)
+		defer cancel()
+		_ = server.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Printf("HTTP 服务已启动: http://%s (并发 %d，队列 %d，按 Ctrl+C 退出)\n", listener.Addr(), sc.maxConcurrent, sc.queueSize)
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// previewHTTPServer 用两个信号量限制负载: admitted 容纳正在处理与排队中的请求，满时直接返回 429；
+// running 限制同时运行的 ffmpeg 处理流程数。
+type previewHTTPServer struct {
+	cfg      *serverConfig
+	admitted chan struct{}
+	running  chan struct{}
+}
+
+func newPreviewHTTPServer(sc *serverConfig) *previewHTTPServer {
+	return &previewHTTPServer{
+		cfg:      sc,
+		admitted: make(chan struct{}, sc.maxConcurrent+sc.queueSize),
+		running:  make(chan struct{}, sc.maxConcurrent),
+	}
+}
+
+func (s *previewHTTPServer) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /preview", s.handlePreview)
+	mux.HandleFunc("GET /healthz", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	})
+	mux.Handle("GET /metrics", metricsHandler())
+	if s.cfg.pprof {
+		registerPprof(mux)
+	}
+	return mux
+}
+
+// handlePreview 接收请求体中的视频 (或查询参数 input 指定的远程地址)，生成拼图后直接在响应中返回图片。
+// 其余查询参数与命令行参数同名，例如 /preview?rows=4&format=jpeg。
+func (s *previewHTTPServer) handlePreview(w http.ResponseWriter, r *http.Request) {
+	select {
+	case s.admitted <- struct{}{}:
+		defer func() { <-s.admitted }()
+	default:
+		httpRejected.Inc()
+		w.Header().Set("Retry-After", "5")
+		http.Error(w, "服务繁忙，请稍后重试", http.StatusTooManyRequests)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.cfg.timeout)
+	defer cancel()
+
+	options := make(map[string]string)
+	for name, values := range r.URL.Query() {
+		if name == "input" || len(values) == 0 {
+			continue
+		}
+		options[name] = values[len(values)-1]
+	}
+	for name := range options {
+		if !slices.Contains(remoteOptions, name) {
+			http.Error(w, fmt.Sprintf("参数 %s 在 HTTP 服务中不可用", name), http.StatusBadRequest)
+			return
+		}
+	}
+
+	workDir, err := os.MkdirTemp("", "video-preview-serve-*")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer os.RemoveAll(workDir)
+
+	input := r.URL.Query().Get("input")
+	uploadName := ""
+	if input == "" {
+		uploadName = r.Header.Get("X-Filename")
+		if input, err = s.saveUpload(w, r, workDir, uploadName); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	} else if !isRemoteURI(input) && !strings.HasPrefix(input, "http://") && !strings.HasPrefix(input, "https://") {
+		http.Error(w, "input 只能是 http(s)、s3://、gs:// 或 az:// 地址", http.StatusBadRequest)
+		return
+	}
+
+	cfg, err := jobConfig(input, filepath.Join(workDir, "preview.png"), options)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	ext := responseExtension(cfg)
+	cfg.output = filepath.Join(workDir, "preview"+ext)
+	cfg.ctx = ctx
+	if uploadName != "" {
+		cfg.source = filepath.Base(uploadName)
+	}
+
+	select {
+	case s.running <- struct{}{}:
+	case <-ctx.Done():
+		httpTimeouts.Inc()
+		http.Error(w, "排队等待超时", http.StatusServiceUnavailable)
+		return
+	}
+	_, err = runPreview(cfg)
+	<-s.running
+
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			httpTimeouts.Inc()
+			http.Error(w, "生成预览超时", http.StatusGatewayTimeout)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	// 按间隔采样的截图超过 max-cells 时会分页输出多张拼图，无法在一个响应中返回。
+	if cfg.paged {
+		http.Error(w, "按间隔采样需要输出多张拼图，请调大 max-cells 或 interval", http.StatusBadRequest)
+		return
+	}
+
+	file, err := os.Open(cfg.output)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+	if contentType := mime.TypeByExtension(ext); contentType != "" {
+		w.Header().Set("Content-Type", contentType)
+	}
+	if info, err := file.Stat(); err == nil {
+		w.Header().Set("Content-Length", strconv.FormatInt(info.Size(), 10))
+	}
+	_, _ = io.Copy(w, file)
+}
+
+// responseExtension 返回响应图片的扩展名；格式可能来自 format 参数、预设或 VPI_FORMAT，未指定时为 PNG。
+func responseExtension(cfg *gridConfig) string {
+	if cfg.format == "" {
+		return ".png"
+	}
+	return formatExtension(cfg.format)
+}
+
+// saveUpload 将请求体保存到临时目录；文件名只保留 X-Filename 的扩展名，原始名称仅用于信息栏与元数据。
+func (s *previewHTTPServer) saveUpload(w http.ResponseWriter, r *http.Request, dir, filename string) (string, error) {
+	body := http.MaxBytesReader(w, r.Body, s.cfg.maxUploadMB<<20)
+	path, err := spoolInput(body, dir, filepath.Ext(filename))
+	if errors.Is(err, errEmptyInput) {
+		return "", errors.New("请求体为空，请上传视频或通过 input 指定地址")
+	}
+	if err != nil {
+		return "", fmt.Errorf("接收上传视频失败: %w", err)
+	}
+	return path, nil
+}
@@ -0,0 +1,37 @@
+package preview
+
+import "testing"
+
+func TestResponseExtension(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		options map[string]string
+		want    string
+	}{
+		{"default", nil, ".png"},
+		{"format", map[string]string{"format": "tiff"}, ".tif"},
+		{"preset torrent", map[string]string{"preset": "torrent"}, ".jpg"},
+		{"preset web", map[string]string{"preset": "web"}, ".webp"},
+		{"format overrides preset", map[string]string{"preset": "web", "format": "png"}, ".png"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg, err := jobConfig("in.mp4", "preview.png", tc.options)
+			if err != nil {
+				t.Fatalf("jobConfig: %v", err)
+			}
+			if got := responseExtension(cfg); got != tc.want {
+				t.Errorf("扩展名为 %s，期望 %s", got, tc.want)
+			}
+		})
+	}
+	t.Run("env", func(t *testing.T) {
+		t.Setenv("VPI_FORMAT", "jpeg")
+		cfg, err := jobConfig("in.mp4", "preview.png", nil)
+		if err != nil {
+			t.Fatalf("jobConfig: %v", err)
+		}
+		if got := responseExtension(cfg); got != ".jpg" {
+			t.Errorf("VPI_FORMAT=jpeg 时扩展名为 %s，期望 .jpg", got)
+		}
+	})
+}
@@ -0,0 +1,247 @@
+package preview
+
+import (
+	"bytes"
+	"cmp"
+	"flag"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/go-text/typesetting/di"
+	tsfont "github.com/go-text/typesetting/font"
+	ot "github.com/go-text/typesetting/font/opentype"
+	"github.com/go-text/typesetting/fontscan"
+	"github.com/go-text/typesetting/language"
+	"github.com/go-text/typesetting/shaping"
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/math/fixed"
+	"golang.org/x/image/vector"
+)
+
+// 含非 ASCII 字符的文字 (文件名中的中日韩文字、emoji、组合附加符号、阿拉伯文等) 经 HarfBuzz 整形后绘制：
+// 按字符依次从内置 Go Regular、--font 指定的字体与系统字体中选择含有该字形的字体，并处理双向文字的视觉顺序。
+// 整形器、分段器、系统字体表与字体 (读取字形数据时会复用内部缓冲区) 都不能并发使用，由 shapeMu 保护。
+var (
+	shapeMu     sync.Mutex
+	builtinFace *tsfont.Face
+	userFaces   []*tsfont.Face
+	fontPaths   = map[string]bool{}
+	systemOnce  sync.Once
+	systemFonts *fontscan.FontMap
+	shaper      shaping.HarfbuzzShaper
+	segmenter   shaping.Segmenter
+	wrapper     shaping.LineWrapper
+)
+
+// fontFlag 实现可重复的 --font，每个值可以是用系统路径分隔符分隔的多个字体文件 (.ttf/.otf/.ttc)。
+type fontFlag struct{}
+
+func (fontFlag) String() string { return "" }
+
+func (fontFlag) Set(value string) error {
+	shapeMu.Lock()
+	defer shapeMu.Unlock()
+	for _, path := range filepath.SplitList(value) {
+		if fontPaths[path] {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		faces, err := tsfont.ParseTTC(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("无法解析字体 %s: %w", path, err)
+		}
+		userFaces = append(userFaces, faces...)
+		fontPaths[path] = true
+	}
+	return nil
+}
+
+func bindFontFlags(fs *flag.FlagSet) {
+	fs.Var(fontFlag{}, "font", "内置字体缺少字形时优先使用的字体文件 (.ttf/.otf/.ttc)，可重复指定；之后再从系统字体中查找")
+}
+
+// needsShaping 判断文字是否含有非 ASCII 字符；纯 ASCII 文字沿用 x/image/font 的绘制方式。
+func needsShaping(text string) bool {
+	return strings.IndexFunc(text, func(r rune) bool { return r > unicode.MaxASCII }) >= 0
+}
+
+// fallbackFonts 实现 shaping.Fontmap：依次返回内置字体、--font 字体与系统字体中第一个含有该字符的字体，都没有时返回内置字体。
+type fallbackFonts struct{}
+
+func (fallbackFonts) ResolveFace(r rune) *tsfont.Face {
+	for _, face := range append([]*tsfont.Face{builtinFace}, userFaces...) {
+		if _, ok := face.NominalGlyph(r); ok {
+			return face
+		}
+	}
+	systemOnce.Do(func() {
+		fm := fontscan.NewFontMap(log.New(io.Discard, "", 0))
+		if err := fm.UseSystemFonts(""); err == nil {
+			fm.SetQuery(fontscan.Query{Families: []string{fontscan.SansSerif, fontscan.Emoji}})
+			systemFonts = fm
+		}
+	})
+	if systemFonts != nil {
+		systemFonts.SetScript(language.LookupScript(r))
+		if face := systemFonts.ResolveFace(r); face != nil {
+			if _, ok := face.NominalGlyph(r); ok {
+				return face
+			}
+		}
+	}
+	return builtinFace
+}
+
+// shapeText 整形单行文字，maxWidth 大于 0 时在该宽度内截断；返回按视觉顺序排列的各段与总宽度。
+func shapeText(text string, size float64, maxWidth fixed.Int26_6) (shaping.Line, fixed.Int26_6, error) {
+	shapeMu.Lock()
+	defer shapeMu.Unlock()
+	if builtinFace == nil {
+		face, err := tsfont.ParseTTF(bytes.NewReader(goregular.TTF))
+		if err != nil {
+			return nil, 0, err
+		}
+		builtinFace = face
+	}
+
+	runes := []rune(text)
+	input := shaping.Input{Text: runes, RunEnd: len(runes), Direction: di.DirectionLTR, Size: fixed.Int26_6(math.Round(size * 64))}
+	// 分段时空格归入前一个字符的字体，emoji 字体的空格接近一个字宽，改用内置字体的空格宽度。
+	gid, _ := builtinFace.NominalGlyph(' ')
+	space := fixed.Int26_6(math.Round(float64(builtinFace.HorizontalAdvance(gid)) * size * 64 / float64(builtinFace.Upem())))
+	var outputs []shaping.Output
+	for _, run := range segmenter.Split(input, fallbackFonts{}) {
+		out := shaper.Shape(run)
+		if out.Face != builtinFace {
+			for i, g := range out.Glyphs {
+				if runes[g.TextIndex()] == ' ' {
+					out.Glyphs[i].Advance = space
+				}
+			}
+			out.RecomputeAdvance()
+		}
+		outputs = append(outputs, out)
+	}
+	if maxWidth <= 0 {
+		maxWidth = fixed.Int26_6(math.MaxInt32)
+	}
+	lines, _ := wrapper.WrapParagraphF(shaping.WrapConfig{TruncateAfterLines: 1}, maxWidth, runes, shaping.NewSliceIterator(outputs))
+	if len(lines) == 0 {
+		return nil, 0, nil
+	}
+	// 截断时行尾会附加一段空的截断符 (未设置 Truncator)，没有字体，需要去掉。
+	line := slices.DeleteFunc(slices.Clone(lines[0]), func(run shaping.Output) bool { return run.Face == nil })
+	slices.SortFunc(line, func(a, b shaping.Output) int { return cmp.Compare(a.VisualIndex, b.VisualIndex) })
+	var width fixed.Int26_6
+	for _, run := range line {
+		width += run.Advance
+	}
+	return line, width, nil
+}
+
+// drawShaped 从基线上的 dot 起绘制整形后的文字：轮廓字形以 style.fill 填充 (style.outline 大于 0 时先绘制描边)，
+// 彩色位图字形 (emoji) 按原色缩放绘制，彩色矢量字形退回单色轮廓。
+func drawShaped(dst draw.Image, dot fixed.Point26_6, line shaping.Line, width fixed.Int26_6, style overlayStyle) {
+	var ascent, descent fixed.Int26_6
+	for _, run := range line {
+		ascent, descent = max(ascent, run.LineBounds.Ascent), min(descent, run.LineBounds.Descent)
+	}
+	r := image.Rect(dot.X.Floor(), (dot.Y - ascent).Floor(), (dot.X + width).Ceil(), (dot.Y - descent).Ceil()).Inset(-style.outline)
+	if r.Empty() {
+		return
+	}
+
+	type bitmapGlyph struct {
+		img  image.Image
+		rect image.Rectangle
+	}
+	var bitmaps []bitmapGlyph
+	raster := vector.NewRasterizer(r.Dx(), r.Dy())
+	pen := dot.X
+	shapeMu.Lock()
+	for _, run := range line {
+		scale := float32(run.Size) / 64 / float32(run.Face.Upem())
+		for _, g := range run.Glyphs {
+			x := float32(pen+g.XOffset)/64 - float32(r.Min.X)
+			y := float32(dot.Y-g.YOffset)/64 - float32(r.Min.Y)
+			var outline *tsfont.GlyphOutline
+			switch data := run.Face.GlyphData(g.GlyphID).(type) {
+			case tsfont.GlyphOutline:
+				outline = &data
+			case tsfont.GlyphSVG:
+				outline = &data.Outline
+			case tsfont.GlyphColor:
+				if o, ok := run.Face.GlyphDataOutline(g.GlyphID); ok {
+					outline = &o
+				}
+			case tsfont.GlyphBitmap:
+				outline = data.Outline
+				if data.Format != tsfont.PNG && data.Format != tsfont.JPG {
+					break
+				}
+				if img, _, err := image.Decode(bytes.NewReader(data.Data)); err == nil {
+					x0 := pen + g.XOffset + g.XBearing
+					y0 := dot.Y - g.YOffset - g.YBearing
+					bitmaps = append(bitmaps, bitmapGlyph{img, image.Rect(x0.Round(), y0.Round(), (x0 + g.Width).Round(), (y0 - g.Height).Round())})
+					outline = nil
+				}
+			}
+			if outline != nil {
+				addGlyphPath(raster, outline, x, y, scale)
+			}
+			pen += g.Advance
+		}
+	}
+	shapeMu.Unlock()
+
+	mask := image.NewAlpha(r)
+	raster.Draw(mask, r, image.Opaque, image.Point{})
+	if style.outline > 0 {
+		draw.DrawMask(dst, r, &image.Uniform{C: style.outlineColor}, image.Point{}, dilate(mask, style.outline), r.Min, draw.Over)
+	}
+	draw.DrawMask(dst, r, &image.Uniform{C: style.fill}, image.Point{}, mask, r.Min, draw.Over)
+	for _, b := range bitmaps {
+		xdraw.ApproxBiLinear.Scale(dst, b.rect, b.img, b.img.Bounds(), draw.Over, nil)
+	}
+}
+
+// addGlyphPath 把字形轮廓 (字体单位，Y 轴向上) 按 scale 缩放后添加到 raster 中 (x, y) 处。
+func addGlyphPath(raster *vector.Rasterizer, outline *tsfont.GlyphOutline, x, y, scale float32) {
+	pt := func(p tsfont.SegmentPoint) (float32, float32) { return x + p.X*scale, y - p.Y*scale }
+	for _, seg := range outline.Segments {
+		switch seg.Op {
+		case ot.SegmentOpMoveTo:
+			// MoveTo 不会闭合上一段轮廓，需要先闭合。
+			raster.ClosePath()
+			raster.MoveTo(pt(seg.Args[0]))
+		case ot.SegmentOpLineTo:
+			raster.LineTo(pt(seg.Args[0]))
+		case ot.SegmentOpQuadTo:
+			bx, by := pt(seg.Args[0])
+			cx, cy := pt(seg.Args[1])
+			raster.QuadTo(bx, by, cx, cy)
+		case ot.SegmentOpCubeTo:
+			bx, by := pt(seg.Args[0])
+			cx, cy := pt(seg.Args[1])
+			dx, dy := pt(seg.Args[2])
+			raster.CubeTo(bx, by, cx, cy, dx, dy)
+		}
+	}
+	raster.ClosePath()
+}
@@ -0,0 +1,45 @@
+package preview
+
+import (
+	"fmt"
+	"math"
+	"os"
+)
+
+// minFittedCellWidth 为按 --max-width/--max-height 缩小单格时的下限，再小的截图已难以辨认。
+const minFittedCellWidth = 32
+
+// fitSheetSize 在拼图 (连同信息栏、页脚与面板) 超过 --max-width/--max-height 时按比例缩小单格尺寸，
+// 以百分比指定的间距与外边距随之缩小；信息栏字号随拼图宽度变化，因此逐次计算直到满足限制。
+func fitSheetSize(cfg *gridConfig, meta *videoMetadata, layout *sheetLayout) error {
+	if cfg.maxWidth <= 0 && cfg.maxHeight <= 0 || cfg.framesOnly {
+		return nil
+	}
+	cellWidth, cellHeight := cfg.cellWidth, cfg.cellHeight
+	for {
+		width, height, _, err := plannedSheetSize(cfg, meta, layout)
+		if err != nil {
+			return err
+		}
+		scale := 1.0
+		if cfg.maxWidth > 0 && width > cfg.maxWidth {
+			scale = float64(cfg.maxWidth) / float64(width)
+		}
+		if cfg.maxHeight > 0 && height > cfg.maxHeight {
+			scale = min(scale, float64(cfg.maxHeight)/float64(height))
+		}
+		if scale == 1 {
+			break
+		}
+		fitted := min(int(float64(cfg.cellWidth)*scale), cfg.cellWidth-1)
+		if fitted < minFittedCellWidth {
+			return fmt.Errorf("拼图为 %dx%d，单格缩小到 %d 像素宽仍无法满足 --max-width/--max-height 限制，请减少行列数", width, height, minFittedCellWidth)
+		}
+		cfg.cellWidth = fitted
+		cfg.cellHeight = max(int(math.Round(float64(cellHeight)*float64(fitted)/float64(cellWidth))), 1)
+	}
+	if cfg.cellWidth != cellWidth {
+		fmt.Fprintf(os.Stderr, "警告: 拼图超过尺寸限制，单格已从 %dx%d 缩小为 %dx%d\n", cellWidth, cellHeight, cfg.cellWidth, cfg.cellHeight)
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package preview
+
+import (
+	"encoding/json"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type sidecarReport struct {
+	Tool       string          `json:"tool"`
+	Version    string          `json:"version"`
+	Source     string          `json:"source"`
+	Output     string          `json:"output"`
+	Created    *time.Time      `json:"created,omitempty"`
+	Rows       int             `json:"rows"`
+	Cols       int             `json:"cols"`
+	Timestamps []float64       `json:"timestamps"`
+	Checksum   *checksumReport `json:"checksum,omitempty"`
+	Sheet      *sheetHitMap    `json:"sheet,omitempty"`
+	Video      VideoInfo       `json:"video"`
+}
+
+// sheetHitMap 记录拼图尺寸与每张截图所在的矩形 (像素，相对拼图左上角，已计入顶部信息栏)，
+// 审阅工具可据此把图片上的点击位置换算为时间点。
+type sheetHitMap struct {
+	Width  int            `json:"width"`
+	Height int            `json:"height"`
+	Cells  []sheetCellHit `json:"cells"`
+}
+
+type sheetCellHit struct {
+	Index     int     `json:"index"`
+	Timestamp float64 `json:"timestamp"`
+	X         int     `json:"x"`
+	Y         int     `json:"y"`
+	Width     int     `json:"width"`
+	Height    int     `json:"height"`
+}
+
+// newSheetHitMap 按布局计算各截图位置；top 为拼接在网格上方的信息栏高度。
+func newSheetHitMap(layout *sheetLayout, cfg *gridConfig, timestamps []float64, size image.Point, top int) *sheetHitMap {
+	hits := &sheetHitMap{Width: size.X, Height: size.Y, Cells: []sheetCellHit{}}
+	g := cfg.geometry()
+	for _, cell := range layout.cells {
+		if cell.frame >= len(timestamps) {
+			continue
+		}
+		rect := layout.cellRect(cell, g).Add(image.Pt(0, top))
+		hits.Cells = append(hits.Cells, sheetCellHit{
+			Index:     cell.frame,
+			Timestamp: timestamps[cell.frame],
+			X:         rect.Min.X,
+			Y:         rect.Min.Y,
+			Width:     rect.Dx(),
+			Height:    rect.Dy(),
+		})
+	}
+	return hits
+}
+
+type checksumReport struct {
+	Algorithm string `json:"algorithm"`
+	Value     string `json:"value"`
+}
+
+// sidecarPath 将输出文件的扩展名替换为 .json，例如 sheet.png 对应 sheet.json。
+func sidecarPath(output string) string {
+	return strings.TrimSuffix(output, filepath.Ext(output)) + ".json"
+}
+
+func writeSidecar(cfg *gridConfig, meta *videoMetadata, timestamps []float64, hits *sheetHitMap) error {
+	report := sidecarReport{
+		Tool:       toolName,
+		Version:    version,
+		Source:     cfg.sourceName(),
+		Output:     cfg.outputName(),
+		Rows:       cfg.rows,
+		Cols:       cfg.cols,
+		Timestamps: timestamps,
+		Sheet:      hits,
+		Video:      newMetadataReport(meta),
+	}
+	if !cfg.deterministic {
+		created := time.Now()
+		report.Created = &created
+	}
+	if meta.checksum != nil {
+		report.Checksum = &checksumReport{Algorithm: meta.checksum.algorithm, Value: meta.checksum.value}
+	}
+	data, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(sidecarPath(cfg.output), append(data, '\n'), 0o644); err != nil {
+		return fmt.Errorf("写入元数据文件失败: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,39 @@
+package preview
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// spacing 表示间距，可以是固定像素，也可以是相对单格尺寸的百分比 (例如 5%)。
+type spacing struct {
+	value   float64
+	percent bool
+}
+
+func pixelSpacing(px int) spacing {
+	return spacing{value: float64(px)}
+}
+
+func parseSpacing(name, value string) (spacing, error) {
+	text := strings.TrimSpace(value)
+	percent := strings.HasSuffix(text, "%")
+	number, err := strconv.ParseFloat(strings.TrimSuffix(text, "%"), 64)
+	if err != nil || math.IsNaN(number) || math.IsInf(number, 0) {
+		return spacing{}, fmt.Errorf("%s 必须为像素值或百分比 (例如 12 或 5%%): %s", name, value)
+	}
+	if number < 0 {
+		return spacing{}, fmt.Errorf("%s 不能为负数", name)
+	}
+	return spacing{value: number, percent: percent}, nil
+}
+
+// pixels 将间距换算为像素，百分比相对于给定的单格边长。
+func (s spacing) pixels(cellSize int) int {
+	if s.percent {
+		return int(math.Round(s.value * float64(cellSize) / 100))
+	}
+	return int(math.Round(s.value))
+}
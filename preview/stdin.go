@@ -0,0 +1,44 @@
+package preview
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+var errEmptyInput = errors.New("输入为空")
+
+// spoolInput 把不可定位的视频流 (标准输入、上传的请求体) 完整写入 dir 下的临时文件：ffprobe 探测与
+// 每个采样点的精确定位都需要可随机读取的文件。dir 为空时使用系统临时目录，调用方负责删除返回的文件。
+func spoolInput(r io.Reader, dir, ext string) (string, error) {
+	file, err := os.CreateTemp(dir, "input-*"+ext)
+	if err != nil {
+		return "", err
+	}
+	written, err := io.Copy(file, r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && written == 0 {
+		err = errEmptyInput
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+	return file.Name(), nil
+}
+
+// spoolStdin 处理 --input -：先把标准输入中的视频写入临时文件，返回用于清理的函数。
+func spoolStdin(cfg *gridConfig) (func(), error) {
+	path, err := spoolInput(os.Stdin, "", "")
+	if err != nil {
+		return nil, fmt.Errorf("读取标准输入中的视频失败: %w", err)
+	}
+	cfg.input = path
+	if cfg.source == "" {
+		cfg.source = "stdin"
+	}
+	return func() { os.Remove(path) }, nil
+}
@@ -0,0 +1,152 @@
+package preview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// storageBackend 抽象远程对象存储。新增服务商只需实现该接口并在 storageBackends 中注册 URI 前缀。
+type storageBackend interface {
+	// presign 返回 ffmpeg 可直接读取的限时 HTTPS 地址。
+	presign(ctx context.Context, uri string, expiry time.Duration) (string, error)
+	download(ctx context.Context, uri, path string) error
+	upload(ctx context.Context, path, uri string) error
+}
+
+type storageOptions struct {
+	s3Region      string
+	s3Endpoint    string
+	downloadInput bool
+}
+
+var storageBackends = map[string]func(ctx context.Context, opts storageOptions) (storageBackend, error){
+	"s3": newS3Backend,
+	"gs": newGCSBackend,
+	"az": newAzureBackend,
+}
+
+const presignExpiry = 6 * time.Hour
+
+func storageScheme(uri string) string {
+	scheme, _, ok := strings.Cut(uri, "://")
+	if !ok {
+		return ""
+	}
+	if _, registered := storageBackends[scheme]; !registered {
+		return ""
+	}
+	return scheme
+}
+
+func isRemoteURI(value string) bool {
+	return storageScheme(value) != ""
+}
+
+// splitStorageURI 将 scheme://bucket/key 拆分为存储桶与对象键。
+func splitStorageURI(uri string) (bucket, key string, err error) {
+	_, rest, _ := strings.Cut(uri, "://")
+	bucket, key, _ = strings.Cut(rest, "/")
+	if bucket == "" || key == "" {
+		return "", "", fmt.Errorf("无效的存储地址: %s (应为 scheme://bucket/key)", uri)
+	}
+	return bucket, key, nil
+}
+
+type storageClients struct {
+	opts     storageOptions
+	backends map[string]storageBackend
+}
+
+func (c *storageClients) backend(ctx context.Context, uri string) (storageBackend, error) {
+	scheme := storageScheme(uri)
+	if backend, ok := c.backends[scheme]; ok {
+		return backend, nil
+	}
+	backend, err := storageBackends[scheme](ctx, c.opts)
+	if err != nil {
+		return nil, err
+	}
+	if c.backends == nil {
+		c.backends = make(map[string]storageBackend)
+	}
+	c.backends[scheme] = backend
+	return backend, nil
+}
+
+// generateRemote 将远程输入替换为预签名地址 (无法签名或指定 --download-input 时下载到临时文件)，
+// 输出先写入临时文件，成功后再上传。
+func generateRemote(cfg *gridConfig, result *previewResult) error {
+	ctx := cfg.context()
+	clients := &storageClients{opts: cfg.storage}
+
+	job := *cfg
+	job.source = cfg.sourceName()
+	job.destination = cfg.outputName()
+
+	if isRemoteURI(cfg.input) {
+		backend, err := clients.backend(ctx, cfg.input)
+		if err != nil {
+			return err
+		}
+		url := ""
+		// 计算校验值需要读取完整的源文件，此时总是先下载。
+		if !cfg.storage.downloadInput && cfg.checksum == "" {
+			url, err = backend.presign(ctx, cfg.input, presignExpiry)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "警告: 无法生成预签名地址，改为下载输入文件: %v\n", err)
+			}
+		}
+		if url != "" {
+			job.input = url
+		} else {
+			dir, err := os.MkdirTemp("", toolName+"-input-")
+			if err != nil {
+				return err
+			}
+			defer os.RemoveAll(dir)
+			job.input = filepath.Join(dir, filepath.Base(cfg.input))
+			if err := backend.download(ctx, cfg.input, job.input); err != nil {
+				return fmt.Errorf("下载 %s 失败: %w", cfg.input, err)
+			}
+		}
+	}
+
+	if !isRemoteURI(cfg.output) {
+		return buildPreview(&job, result)
+	}
+	if _, _, err := splitStorageURI(cfg.output); err != nil {
+		return err
+	}
+	backend, err := clients.backend(ctx, cfg.output)
+	if err != nil {
+		return err
+	}
+
+	dir, err := os.MkdirTemp("", toolName+"-output-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	job.output = filepath.Join(dir, filepath.Base(cfg.output))
+	if filepath.Ext(job.output) == "" && cfg.format == "" {
+		return errors.New("输出到对象存储且对象键没有扩展名时，需要通过 --format 指定格式")
+	}
+
+	if err := buildPreview(&job, result); err != nil {
+		return err
+	}
+	if err := backend.upload(ctx, job.output, cfg.output); err != nil {
+		return fmt.Errorf("上传到 %s 失败: %w", cfg.output, err)
+	}
+	if cfg.sidecar {
+		if err := backend.upload(ctx, sidecarPath(job.output), sidecarPath(cfg.output)); err != nil {
+			return fmt.Errorf("上传到 %s 失败: %w", sidecarPath(cfg.output), err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,158 @@
+package preview
+
+import (
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"math/rand/v2"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/math/f64"
+)
+
+func validateStyle(name string) error {
+	switch name {
+	case "plain", "", "polaroid":
+		return nil
+	default:
+		return fmt.Errorf("不支持的样式: %s", name)
+	}
+}
+
+// styleRand 以输入文件名作为随机种子，同一视频多次生成的倾斜角度保持一致。
+func styleRand(input string) *rand.Rand {
+	h := fnv.New64a()
+	h.Write([]byte(input))
+	seed := h.Sum64()
+	return rand.New(rand.NewPCG(seed, seed>>1))
+}
+
+// drawPolaroid 将截图渲染为带白边与说明栏的相纸，随机倾斜后连同投影居中绘制到单格区域内。
+func drawPolaroid(canvas draw.Image, rect image.Rectangle, frame image.Image, caption string, rng *rand.Rand) error {
+	// 预留约 12% 的空间给旋转后的边角与投影。
+	maxWidth := float64(rect.Dx()) * 0.88
+	maxHeight := float64(rect.Dy()) * 0.88
+
+	aspect := float64(frame.Bounds().Dy()) / float64(frame.Bounds().Dx())
+	// 相纸宽 = 照片宽 * 1.1，高 = 照片高 + 照片宽 * (0.05 + 0.2)。
+	photoWidth := math.Min(maxWidth/1.1, maxHeight/(aspect+0.25))
+	if photoWidth < 8 {
+		return nil
+	}
+	border := math.Max(2, photoWidth*0.05)
+	captionHeight := photoWidth * 0.2
+	photoHeight := photoWidth * aspect
+
+	cardWidth := int(math.Round(photoWidth + 2*border))
+	cardHeight := int(math.Round(photoHeight + border + captionHeight))
+	card := image.NewRGBA(image.Rect(0, 0, cardWidth, cardHeight))
+	draw.Draw(card, card.Bounds(), &image.Uniform{C: color.NRGBA{250, 250, 246, 255}}, image.Point{}, draw.Src)
+
+	photoRect := image.Rect(
+		int(math.Round(border)), int(math.Round(border)),
+		int(math.Round(border+photoWidth)), int(math.Round(border+photoHeight)),
+	)
+	xdraw.ApproxBiLinear.Scale(card, photoRect, frame, frame.Bounds(), draw.Over, nil)
+
+	if caption != "" {
+		captionRect := image.Rect(0, photoRect.Max.Y, cardWidth, cardHeight)
+		size := math.Max(8, captionHeight*0.45)
+		if err := drawText(card, captionRect, caption, size, color.NRGBA{40, 40, 40, 255}); err != nil {
+			return fmt.Errorf("绘制说明文字失败: %w", err)
+		}
+	}
+
+	angle := (rng.Float64()*2 - 1) * 4 * math.Pi / 180
+	rotated := rotateImage(card, angle)
+
+	shadowOffset := int(math.Max(2, border*0.6))
+	shadow := blurredShadow(rotated, int(math.Max(2, border)), 0.45)
+
+	center := image.Pt(rect.Min.X+rect.Dx()/2, rect.Min.Y+rect.Dy()/2)
+	origin := center.Sub(image.Pt(rotated.Bounds().Dx()/2, rotated.Bounds().Dy()/2))
+	shadowBounds := shadow.Bounds()
+	shadowOrigin := origin.Add(image.Pt(shadowOffset, shadowOffset)).Sub(image.Pt((shadowBounds.Dx()-rotated.Bounds().Dx())/2, (shadowBounds.Dy()-rotated.Bounds().Dy())/2))
+
+	draw.Draw(canvas, shadowBounds.Sub(shadowBounds.Min).Add(shadowOrigin), shadow, shadowBounds.Min, draw.Over)
+	draw.Draw(canvas, rotated.Bounds().Add(origin), rotated, image.Point{}, draw.Over)
+	return nil
+}
+
+// rotateImage 以图片中心为原点旋转 angle 弧度，输出画布扩展为旋转后的外接矩形，空白处透明。
+func rotateImage(src image.Image, angle float64) *image.RGBA {
+	bounds := src.Bounds()
+	w, h := float64(bounds.Dx()), float64(bounds.Dy())
+	sin, cos := math.Sin(angle), math.Cos(angle)
+	outWidth := int(math.Ceil(math.Abs(w*cos) + math.Abs(h*sin)))
+	outHeight := int(math.Ceil(math.Abs(w*sin) + math.Abs(h*cos)))
+
+	dst := image.NewRGBA(image.Rect(0, 0, outWidth, outHeight))
+	cx, cy := w/2, h/2
+	ox, oy := float64(outWidth)/2, float64(outHeight)/2
+	// 仿射矩阵将源坐标映射到目标坐标: 先平移到中心，再旋转，最后移到输出画布中心。
+	m := f64.Aff3{
+		cos, -sin, ox - cos*cx + sin*cy,
+		sin, cos, oy - sin*cx - cos*cy,
+	}
+	xdraw.BiLinear.Transform(dst, m, src, bounds, draw.Over, nil)
+	return dst
+}
+
+// blurredShadow 根据图片的 alpha 通道生成模糊后的黑色投影，四周向外扩展 radius 像素。
+func blurredShadow(src *image.RGBA, radius int, opacity float64) *image.RGBA {
+	bounds := src.Bounds()
+	width, height := bounds.Dx()+2*radius, bounds.Dy()+2*radius
+	alpha := make([]float64, width*height)
+	for y := 0; y < bounds.Dy(); y++ {
+		for x := 0; x < bounds.Dx(); x++ {
+			alpha[(y+radius)*width+x+radius] = float64(src.Pix[y*src.Stride+x*4+3]) / 255
+		}
+	}
+
+	// 三次盒式模糊近似高斯模糊。
+	tmp := make([]float64, len(alpha))
+	for pass := 0; pass < 3; pass++ {
+		boxBlur(alpha, tmp, width, height, radius/2+1, true)
+		boxBlur(tmp, alpha, width, height, radius/2+1, false)
+	}
+
+	shadow := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i, a := range alpha {
+		shadow.Pix[i*4+3] = uint8(math.Round(math.Min(1, a*opacity) * 255))
+	}
+	return shadow
+}
+
+func boxBlur(src, dst []float64, width, height, radius int, horizontal bool) {
+	lines, length := height, width
+	if !horizontal {
+		lines, length = width, height
+	}
+	at := func(line, i int) int {
+		if horizontal {
+			return line*width + i
+		}
+		return i*width + line
+	}
+	window := float64(2*radius + 1)
+	for line := 0; line < lines; line++ {
+		sum := 0.0
+		for i := -radius; i <= radius; i++ {
+			if i >= 0 && i < length {
+				sum += src[at(line, i)]
+			}
+		}
+		for i := 0; i < length; i++ {
+			dst[at(line, i)] = sum / window
+			if out := i - radius; out >= 0 {
+				sum -= src[at(line, out)]
+			}
+			if in := i + radius + 1; in < length {
+				sum += src[at(line, in)]
+			}
+		}
+	}
+}
@@ -0,0 +1,190 @@
+package preview
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"sync"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/opentype"
+	"golang.org/x/image/math/fixed"
+)
+
+var (
+	fontOnce  sync.Once
+	fontData  *opentype.Font
+	fontErr   error
+	faceMu    sync.Mutex
+	faceCache = map[float64]font.Face{}
+	// faceSizes 记录 fontFace 返回的字形对应的字号，供需要整形的文字使用。
+	faceSizes = map[font.Face]float64{}
+)
+
+// fontFace 返回内置 Go Regular 字体指定字号的字形，不同字号的字形会被缓存复用。
+func fontFace(size float64) (font.Face, error) {
+	fontOnce.Do(func() {
+		fontData, fontErr = opentype.Parse(goregular.TTF)
+	})
+	if fontErr != nil {
+		return nil, fontErr
+	}
+
+	faceMu.Lock()
+	defer faceMu.Unlock()
+	if face, ok := faceCache[size]; ok {
+		return face, nil
+	}
+	face, err := opentype.NewFace(fontData, &opentype.FaceOptions{Size: size, DPI: 72, Hinting: font.HintingFull})
+	if err != nil {
+		return nil, err
+	}
+	faceCache[size] = face
+	faceSizes[face] = size
+	return face, nil
+}
+
+func faceSize(face font.Face) float64 {
+	faceMu.Lock()
+	defer faceMu.Unlock()
+	return faceSizes[face]
+}
+
+// drawLabel 在单格底部绘制一条半透明底栏，并将文字居中写在其上，超出宽度的文字会被截断；
+// style.smart 时按底栏处画面的亮度加深底色，带描边时不绘制底栏。
+func drawLabel(dst draw.Image, rect image.Rectangle, text string, size float64, style overlayStyle) error {
+	face, err := fontFace(size)
+	if err != nil {
+		return err
+	}
+
+	metrics := face.Metrics()
+	lineHeight := (metrics.Ascent + metrics.Descent).Ceil()
+	barHeight := lineHeight + lineHeight/2
+	bar := image.Rect(rect.Min.X, rect.Max.Y-barHeight, rect.Max.X, rect.Max.Y).Intersect(rect)
+	if style.outline == 0 {
+		alpha := uint8(160)
+		if style.smart {
+			alpha = plateAlpha(dst, bar, alpha)
+		}
+		draw.Draw(dst, bar, &image.Uniform{C: color.NRGBA{0, 0, 0, alpha}}, image.Point{}, draw.Over)
+	}
+
+	drawStyledText(dst, bar, text, face, style)
+	return nil
+}
+
+// drawText 将单行文字水平、垂直居中绘制在 rect 内。
+func drawText(dst draw.Image, rect image.Rectangle, text string, size float64, c color.Color) error {
+	face, err := fontFace(size)
+	if err != nil {
+		return err
+	}
+	drawCenteredText(dst, rect, text, face, c)
+	return nil
+}
+
+func drawCenteredText(dst draw.Image, rect image.Rectangle, text string, face font.Face, c color.Color) {
+	drawStyledText(dst, rect, text, face, overlayStyle{fill: c})
+}
+
+// drawStyledText 将单行文字居中绘制在 rect 内，style.outline 大于 0 时先绘制描边。
+func drawStyledText(dst draw.Image, rect image.Rectangle, text string, face font.Face, style overlayStyle) {
+	metrics := face.Metrics()
+	lineHeight := (metrics.Ascent + metrics.Descent).Ceil()
+
+	maxWidth := fixed.I(rect.Dx() - lineHeight/2)
+	if needsShaping(text) {
+		if line, width, err := shapeText(text, faceSize(face), maxWidth); err == nil {
+			dot := fixed.Point26_6{
+				X: fixed.I(rect.Min.X) + (fixed.I(rect.Dx())-width)/2,
+				Y: fixed.I(rect.Min.Y+(rect.Dy()-lineHeight)/2) + metrics.Ascent,
+			}
+			drawShaped(dst, dot, line, width, style)
+			return
+		}
+	}
+	runes := []rune(text)
+	for len(runes) > 0 && font.MeasureString(face, string(runes)) > maxWidth {
+		runes = runes[:len(runes)-1]
+	}
+	text = string(runes)
+
+	width := font.MeasureString(face, text)
+	drawer := font.Drawer{
+		Dst:  dst,
+		Src:  &image.Uniform{C: style.fill},
+		Face: face,
+		Dot: fixed.Point26_6{
+			X: fixed.I(rect.Min.X) + (fixed.I(rect.Dx())-width)/2,
+			Y: fixed.I(rect.Min.Y+(rect.Dy()-lineHeight)/2) + metrics.Ascent,
+		},
+	}
+	if style.outline > 0 {
+		drawOutline(dst, drawer.Dot, text, face, style.outline, style.outlineColor)
+	}
+	drawer.DrawString(text)
+}
+
+// drawTextLeft 将单行文字左对齐、垂直居中绘制在 rect 内。
+func drawTextLeft(dst draw.Image, rect image.Rectangle, text string, face font.Face, c color.Color) {
+	metrics := face.Metrics()
+	lineHeight := (metrics.Ascent + metrics.Descent).Ceil()
+	drawer := font.Drawer{
+		Dst:  dst,
+		Src:  &image.Uniform{C: c},
+		Face: face,
+		Dot: fixed.Point26_6{
+			X: fixed.I(rect.Min.X),
+			Y: fixed.I(rect.Min.Y+(rect.Dy()-lineHeight)/2) + metrics.Ascent,
+		},
+	}
+	if needsShaping(text) {
+		if line, width, err := shapeText(text, faceSize(face), 0); err == nil {
+			drawShaped(dst, drawer.Dot, line, width, overlayStyle{fill: c})
+			return
+		}
+	}
+	drawer.DrawString(text)
+}
+
+// drawBadge 在 rect 的一个角落绘制带半透明底色 (带描边时不绘制) 的小字，用于时间戳与序号。style.smart 为 false 时
+// 使用 corners 中的第一个角落，否则选择画面最暗、最平坦的角落并按其亮度加深底色。返回实际使用的角落。
+func drawBadge(dst draw.Image, rect image.Rectangle, text string, size float64, corners []badgeCorner, style overlayStyle) (badgeCorner, error) {
+	face, err := fontFace(size)
+	if err != nil {
+		return badgeCorner{}, err
+	}
+	metrics := face.Metrics()
+	lineHeight := (metrics.Ascent + metrics.Descent).Ceil()
+	pad := max(2, lineHeight/4)
+	width := font.MeasureString(face, text).Ceil() + lineHeight
+	height := lineHeight + pad
+
+	rectFor := func(c badgeCorner) image.Rectangle {
+		x := rect.Max.X - width - pad
+		if c.left {
+			x = rect.Min.X + pad
+		}
+		y := rect.Max.Y - height - pad
+		if c.top {
+			y = rect.Min.Y + pad
+		}
+		return image.Rect(x, y, x+width, y+height).Intersect(rect)
+	}
+	corner := corners[0]
+	if style.smart {
+		corner = pickCorner(dst, corners, rectFor)
+	}
+	badge := rectFor(corner)
+	if style.outline == 0 {
+		alpha := uint8(150)
+		if style.smart {
+			alpha = plateAlpha(dst, badge, alpha)
+		}
+		draw.Draw(dst, badge, &image.Uniform{C: color.NRGBA{0, 0, 0, alpha}}, image.Point{}, draw.Over)
+	}
+	drawStyledText(dst, badge, text, face, style)
+	return corner, nil
+}
@@ -0,0 +1,132 @@
+package preview
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// thumbnailUnsupported 为只对拼图有意义的参数，--at 只输出单张截图，不支持这些参数。
+var thumbnailUnsupported = []string{
+	"rows", "cols", "layout", "layout-file", "style", "timestamps", "number-cells", "header", "header-template",
+	"footer", "waveform", "bitrate-graph", "loudness", "sidecar", "anim-output", "save-frames", "frames-only",
+	"frame-numbers", "percentages", "interval", "segment", "sheet-per-chapter", "selector", "sample", "avoid-freeze", "snap-to-keyframe",
+}
+
+// thumbnailPosition 为 --at 指定的截图位置：percent 为 true 时 value 为视频时长的百分比，否则为秒数。
+type thumbnailPosition struct {
+	value   float64
+	percent bool
+}
+
+// parseThumbnailPosition 解析 --at 的位置：百分比 (37%)、时间码 (00:12:30、12:30.5)、秒数 (750) 或 Go 时长 (12m30s)。
+func parseThumbnailPosition(value string) (*thumbnailPosition, error) {
+	text := strings.TrimSpace(value)
+	invalid := fmt.Errorf("无效的截图位置: %s (例如 37%%、00:12:30 或 750)", value)
+	if number, ok := strings.CutSuffix(text, "%"); ok {
+		percent, err := strconv.ParseFloat(number, 64)
+		if err != nil || percent < 0 || percent > 100 {
+			return nil, invalid
+		}
+		return &thumbnailPosition{value: percent, percent: true}, nil
+	}
+	if strings.Contains(text, ":") {
+		fields := strings.Split(text, ":")
+		if len(fields) > 3 {
+			return nil, invalid
+		}
+		var seconds float64
+		for i, field := range fields {
+			n, err := strconv.ParseFloat(field, 64)
+			if err != nil || n < 0 || i < len(fields)-1 && strings.Contains(field, ".") {
+				return nil, invalid
+			}
+			seconds = seconds*60 + n
+		}
+		return &thumbnailPosition{value: seconds}, nil
+	}
+	if seconds, err := strconv.ParseFloat(text, 64); err == nil && seconds >= 0 {
+		return &thumbnailPosition{value: seconds}, nil
+	}
+	if d, err := time.ParseDuration(text); err == nil && d >= 0 {
+		return &thumbnailPosition{value: d.Seconds()}, nil
+	}
+	return nil, invalid
+}
+
+// seconds 返回截图时间点；视频末尾附近没有可解码的帧，位于结尾的位置向前留出一帧。
+func (p *thumbnailPosition) seconds(meta *videoMetadata) (float64, error) {
+	ts := p.value
+	if p.percent {
+		ts = meta.duration * p.value / 100
+	} else if ts > meta.duration {
+		return 0, fmt.Errorf("截图位置 %s 超出视频时长 %s", formatTimestamp(ts), formatTimestamp(meta.duration))
+	}
+	frame := 0.1
+	if meta.fps > 0 {
+		frame = 1 / meta.fps
+	}
+	return math.Max(math.Min(ts, meta.duration-frame), 0), nil
+}
+
+// renderThumbnail 在 --at 指定的位置截取一张截图，按 --cell-width/--cell-height 缩放后直接输出，不拼接网格。
+func renderThumbnail(cfg *gridConfig, meta *videoMetadata, result *previewResult) error {
+	ts, err := cfg.at.seconds(meta)
+	if err != nil {
+		return err
+	}
+	filters := captureFilters(cfg, meta)
+	if cfg.prescale {
+		filters = append(filters, prescaleFilter([]image.Point{{cfg.cellWidth, cfg.cellHeight}}, 0, 0))
+	}
+
+	var thumbnail image.Image
+	for captured, err := range captureFrames(cfg, meta, []float64{ts}, filters) {
+		if err != nil {
+			return err
+		}
+		frame := captured.image
+		if cfg.frameHook != nil {
+			if frame, err = cfg.frameHook(0, captured.timestamp, frame); err != nil {
+				return err
+			}
+		}
+		thumbnail = scaleToFit(frame, cfg.cellWidth, cfg.cellHeight)
+		if cfg.autoLevels {
+			thumbnail = autoLevels(thumbnail)
+		}
+	}
+	result.timestamps = append(result.timestamps, ts)
+
+	if cfg.mediaInfo != "" {
+		if err := writeMediaInfo(cfg, meta); err != nil {
+			return err
+		}
+	}
+	opts := cfg.encodeOptions()
+	if cfg.embedMetadata {
+		opts.metadata = newSheetMetadata(cfg, meta, []float64{ts})
+	}
+	if err := saveVariants(thumbnail, cfg, opts); err != nil {
+		return err
+	}
+	opts.maxBytes = cfg.maxBytes
+	if err := saveImage(thumbnail, cfg.output, opts); err != nil {
+		return err
+	}
+	if err := saveExtraOutputs(thumbnail, cfg, opts); err != nil {
+		return err
+	}
+	if cfg.embedCover {
+		if err := embedCover(cfg, meta); err != nil {
+			return err
+		}
+	}
+	if cfg.publish != "" {
+		return publishSheet(cfg)
+	}
+	return nil
+}
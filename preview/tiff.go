@@ -0,0 +1,250 @@
+package preview
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+)
+
+const (
+	tiffCompressionNone    = 1
+	tiffCompressionLZW     = 5
+	tiffCompressionDeflate = 8
+)
+
+type tiffOptions struct {
+	compression string
+	iccProfile  []byte
+	// dpi 为写入 XResolution/YResolution 的分辨率，为 0 时使用惯例的 72。
+	dpi int
+}
+
+type tiffEntry struct {
+	tag    uint16
+	typ    uint16
+	count  uint32
+	values []uint32
+}
+
+const (
+	tiffShort     = 3
+	tiffLong      = 4
+	tiffRational  = 5
+	tiffUndefined = 7
+)
+
+// golang.org/x/image/tiff 无法写出 LZW 压缩，因此这里自行实现一个只输出 8 位 RGB(A) 单条带的编码器。
+func encodeTIFF(w io.Writer, img image.Image, opts tiffOptions) error {
+	var compression uint32
+	switch opts.compression {
+	case "none":
+		compression = tiffCompressionNone
+	case "lzw", "":
+		compression = tiffCompressionLZW
+	case "deflate":
+		compression = tiffCompressionDeflate
+	default:
+		return fmt.Errorf("不支持的 TIFF 压缩方式: %s", opts.compression)
+	}
+
+	bounds := img.Bounds()
+	width, height := bounds.Dx(), bounds.Dy()
+	nrgba := image.NewNRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(nrgba, nrgba.Bounds(), img, bounds.Min, draw.Src)
+
+	samples := 4
+	if opaque, ok := img.(interface{ Opaque() bool }); ok && opaque.Opaque() {
+		samples = 3
+	}
+
+	rowLen := width * samples
+	raw := make([]byte, rowLen*height)
+	for y := 0; y < height; y++ {
+		src := nrgba.Pix[y*nrgba.Stride : y*nrgba.Stride+width*4]
+		dst := raw[y*rowLen : (y+1)*rowLen]
+		for x := 0; x < width; x++ {
+			copy(dst[x*samples:x*samples+samples], src[x*4:x*4+samples])
+		}
+		if compression != tiffCompressionNone {
+			for i := rowLen - 1; i >= samples; i-- {
+				dst[i] -= dst[i-samples]
+			}
+		}
+	}
+
+	var data []byte
+	switch compression {
+	case tiffCompressionNone:
+		data = raw
+	case tiffCompressionLZW:
+		data = tiffLZW(raw)
+	case tiffCompressionDeflate:
+		var buf bytes.Buffer
+		zw := zlib.NewWriter(&buf)
+		if _, err := zw.Write(raw); err != nil {
+			return err
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		data = buf.Bytes()
+	}
+
+	dataOffset := uint32(8)
+	extraOffset := dataOffset + uint32(len(data))
+	if extraOffset%2 != 0 {
+		extraOffset++
+	}
+
+	var extra bytes.Buffer
+	bitsOffset := extraOffset
+	for i := 0; i < samples; i++ {
+		binary.Write(&extra, binary.LittleEndian, uint16(8))
+	}
+	resolutionOffset := extraOffset + uint32(extra.Len())
+	dpi := opts.dpi
+	if dpi <= 0 {
+		dpi = 72
+	}
+	binary.Write(&extra, binary.LittleEndian, []uint32{uint32(dpi), 1})
+	iccOffset := extraOffset + uint32(extra.Len())
+	extra.Write(opts.iccProfile)
+
+	entries := []tiffEntry{
+		{256, tiffLong, 1, []uint32{uint32(width)}},
+		{257, tiffLong, 1, []uint32{uint32(height)}},
+		{258, tiffShort, uint32(samples), []uint32{bitsOffset}},
+		{259, tiffShort, 1, []uint32{compression}},
+		{262, tiffShort, 1, []uint32{2}},
+		{273, tiffLong, 1, []uint32{dataOffset}},
+		{277, tiffShort, 1, []uint32{uint32(samples)}},
+		{278, tiffLong, 1, []uint32{uint32(height)}},
+		{279, tiffLong, 1, []uint32{uint32(len(data))}},
+		{282, tiffRational, 1, []uint32{resolutionOffset}},
+		{283, tiffRational, 1, []uint32{resolutionOffset}},
+		{284, tiffShort, 1, []uint32{1}},
+		{296, tiffShort, 1, []uint32{2}},
+	}
+	if compression != tiffCompressionNone {
+		entries = append(entries, tiffEntry{317, tiffShort, 1, []uint32{2}})
+	}
+	if samples == 4 {
+		// 2 表示未预乘的 alpha 通道。
+		entries = append(entries, tiffEntry{338, tiffShort, 1, []uint32{2}})
+	}
+	if len(opts.iccProfile) > 0 {
+		entries = append(entries, tiffEntry{34675, tiffUndefined, uint32(len(opts.iccProfile)), []uint32{iccOffset}})
+	}
+
+	ifdOffset := extraOffset + uint32(extra.Len())
+	if ifdOffset%2 != 0 {
+		ifdOffset++
+	}
+
+	bw := bufio.NewWriter(w)
+	bw.WriteString("II*\x00")
+	binary.Write(bw, binary.LittleEndian, ifdOffset)
+	bw.Write(data)
+	if len(data)%2 != 0 {
+		bw.WriteByte(0)
+	}
+	bw.Write(extra.Bytes())
+	if extra.Len()%2 != 0 {
+		bw.WriteByte(0)
+	}
+
+	binary.Write(bw, binary.LittleEndian, uint16(len(entries)))
+	for _, e := range entries {
+		binary.Write(bw, binary.LittleEndian, e.tag)
+		binary.Write(bw, binary.LittleEndian, e.typ)
+		binary.Write(bw, binary.LittleEndian, e.count)
+		if e.typ == tiffShort && e.count == 1 {
+			binary.Write(bw, binary.LittleEndian, []uint16{uint16(e.values[0]), 0})
+		} else {
+			binary.Write(bw, binary.LittleEndian, e.values[0])
+		}
+	}
+	binary.Write(bw, binary.LittleEndian, uint32(0))
+
+	return bw.Flush()
+}
+
+// tiffLZW 按 TIFF 规范的 LZW 变体编码: 高位优先，码宽比 GIF 早一个码字增长，码表接近 4096 项时输出清除码。
+func tiffLZW(data []byte) []byte {
+	const (
+		clearCode = 256
+		eofCode   = 257
+		firstCode = 258
+		maxWidth  = 12
+	)
+
+	var out bytes.Buffer
+	var bits uint32
+	var nBits uint
+	width := uint(9)
+	emit := func(code uint32) {
+		bits |= code << (32 - width - nBits)
+		nBits += width
+		for nBits >= 8 {
+			out.WriteByte(byte(bits >> 24))
+			bits <<= 8
+			nBits -= 8
+		}
+	}
+
+	table := make(map[uint32]uint32)
+	next := uint32(firstCode)
+	overflow := uint32(1) << width
+	reset := func() {
+		clear(table)
+		width = 9
+		next = firstCode
+		overflow = 1 << width
+	}
+
+	emit(clearCode)
+	if len(data) == 0 {
+		emit(eofCode)
+		if nBits > 0 {
+			out.WriteByte(byte(bits >> 24))
+		}
+		return out.Bytes()
+	}
+
+	prefix := uint32(data[0])
+	for _, c := range data[1:] {
+		key := prefix<<8 | uint32(c)
+		if code, ok := table[key]; ok {
+			prefix = code
+			continue
+		}
+		emit(prefix)
+		table[key] = next
+		next++
+		if next >= overflow && width < maxWidth {
+			width++
+			overflow <<= 1
+		}
+		if next >= 1<<maxWidth-2 {
+			emit(clearCode)
+			reset()
+		}
+		prefix = uint32(c)
+	}
+
+	emit(prefix)
+	next++
+	if next >= overflow && width < maxWidth {
+		width++
+	}
+	emit(eofCode)
+	if nBits > 0 {
+		out.WriteByte(byte(bits >> 24))
+	}
+	return out.Bytes()
+}
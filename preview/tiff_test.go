@@ -0,0 +1,61 @@
+package preview
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"golang.org/x/image/tiff"
+)
+
+func TestEncodeTIFFRoundTrip(t *testing.T) {
+	// 300x200 的输出超过 4096 个 LZW 码，覆盖码表写满后清空重建的情况。
+	for _, size := range []image.Point{{1, 1}, {17, 9}, {64, 48}, {300, 200}} {
+		opaque := testPattern(size.X, size.Y)
+		// 带透明度的图像写出 4 个采样 (非预乘 alpha)，也应无损还原。
+		translucent := image.NewNRGBA(opaque.Bounds())
+		draw.Draw(translucent, translucent.Bounds(), opaque, image.Point{}, draw.Src)
+		for i := 3; i < len(translucent.Pix); i += 4 {
+			translucent.Pix[i] = uint8(255 - i%200)
+		}
+		for _, src := range []image.Image{opaque, translucent} {
+			for _, compression := range []string{"none", "lzw", "deflate"} {
+				var buf bytes.Buffer
+				if err := encodeTIFF(&buf, src, tiffOptions{compression: compression, dpi: 300}); err != nil {
+					t.Fatalf("%v %s: encodeTIFF: %v", size, compression, err)
+				}
+				decoded, err := tiff.Decode(&buf)
+				if err != nil {
+					t.Fatalf("%v %s: x/image/tiff 无法解码: %v", size, compression, err)
+				}
+				if decoded.Bounds().Size() != size {
+					t.Fatalf("%v %s: 解码尺寸为 %v", size, compression, decoded.Bounds().Size())
+				}
+				for y := range size.Y {
+					for x := range size.X {
+						want := color.NRGBAModel.Convert(src.At(x, y))
+						if got := color.NRGBAModel.Convert(decoded.At(x, y)); got != want {
+							t.Fatalf("%v %s: (%d, %d) 为 %v，期望 %v", size, compression, x, y, got, want)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestTIFFLZWCompresses(t *testing.T) {
+	// 大面积纯色应被 LZW 显著压缩。
+	data := bytes.Repeat([]byte{12, 34, 56}, 64*64)
+	if compressed := tiffLZW(data); len(compressed) > len(data)/10 {
+		t.Errorf("LZW 输出 %d 字节，输入 %d 字节", len(compressed), len(data))
+	}
+}
+
+func TestEncodeTIFFInvalidCompression(t *testing.T) {
+	if err := encodeTIFF(&bytes.Buffer{}, testPattern(4, 4), tiffOptions{compression: "zstd"}); err == nil {
+		t.Error("不支持的压缩方式应返回错误")
+	}
+}
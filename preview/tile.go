@@ -0,0 +1,146 @@
+package preview
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+// validateBackend 检查 --backend 取值；ffmpeg-tile 由单条 ffmpeg 命令完成采样、缩放与拼接，不支持需要在 Go 中绘制的功能。
+func validateBackend(cfg *gridConfig) error {
+	switch cfg.backend {
+	case "go":
+		return nil
+	case "libav":
+		if !libavAvailable {
+			return errors.New("当前程序未包含 libav 后端，请安装 FFmpeg 6.1 及以上版本的开发库后使用 go build -tags libav 重新构建")
+		}
+		return nil
+	case "persistent":
+		if cfg.selector != "uniform" {
+			return fmt.Errorf("--backend persistent 不支持 --selector %s，请改用默认的 go 后端", cfg.selector)
+		}
+		return nil
+	case "ffmpeg-tile":
+	default:
+		return fmt.Errorf("不支持的处理后端: %s (可选: go、libav、persistent、ffmpeg-tile)", cfg.backend)
+	}
+
+	unsupported := []struct {
+		enabled bool
+		option  string
+	}{
+		{cfg.layout != "grid", "--layout " + cfg.layout},
+		{cfg.layoutFile != "", "--layout-file"},
+		{cfg.style != "plain", "--style " + cfg.style},
+		{cfg.header, "--header"},
+		{len(cfg.footer) > 0, "--footer"},
+		{cfg.timestamps, "--timestamps"},
+		{cfg.numberCells, "--number-cells"},
+		{cfg.smartLabels, "--smart-labels"},
+		{cfg.waveform, "--waveform"},
+		{cfg.bitrateGraph, "--bitrate-graph"},
+		{cfg.selector != "uniform", "--selector " + cfg.selector},
+		{cfg.saveFramesDir != "", "--save-frames"},
+		{cfg.animOutput != "", "--anim-output"},
+		{cfg.autoLevels, "--auto-levels"},
+		{len(cfg.variants) > 0, "--variants"},
+		{cfg.maxBytes > 0, "--max-bytes"},
+	}
+	for _, u := range unsupported {
+		if u.enabled {
+			return fmt.Errorf("--backend ffmpeg-tile 不支持 %s，请改用默认的 go 后端", u.option)
+		}
+	}
+	return nil
+}
+
+// generateTileSheet 为每个采样点添加一路快速定位的输入，各取一帧缩放并居中补边后，由 tile 滤镜直接拼成整张图片。
+func generateTileSheet(cfg *gridConfig, layout *sheetLayout, timestamps []float64, filters []string) error {
+	args, err := tileSheetArgs(cfg, layout, timestamps, filters)
+	if err != nil {
+		return err
+	}
+	if cfg.output != "-" {
+		if err := ensureOutputDir(cfg.output); err != nil {
+			return err
+		}
+	}
+
+	cmd := newCommand(cfg.context(), cfg.runner, ffmpegPath, args...)
+	if cfg.output == "-" {
+		cmd.Stdout = os.Stdout
+	}
+	var stderr stderrTail
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		observeToolFailure("tile")
+		return fmt.Errorf("ffmpeg 拼接截图失败: %w", commandError(err, stderr.Bytes()))
+	}
+	framesCaptured.Add(float64(len(timestamps)))
+	return nil
+}
+
+func tileSheetArgs(cfg *gridConfig, layout *sheetLayout, timestamps []float64, filters []string) ([]string, error) {
+	if isMontageOutput(cfg.output) {
+		return nil, errors.New("--backend ffmpeg-tile 只能输出图片")
+	}
+	g := cfg.geometry()
+	if g.gapX != g.gapY || g.padX != g.padY {
+		return nil, errors.New("--backend ffmpeg-tile 要求水平与垂直间距相同、四周外边距相同")
+	}
+	format, err := outputFormat(cfg.output, cfg.format)
+	if err != nil {
+		return nil, err
+	}
+
+	pad := ffmpegColor(cfg.background)
+	args := []string{"-loglevel", "error", "-y"}
+	var chains []string
+	var labels []string
+	for i, ts := range timestamps {
+		if cfg.keyframesOnly {
+			args = append(args, "-skip_frame", "nokey", "-noaccurate_seek")
+		}
+		args = append(args, "-ss", fmt.Sprintf("%.3f", ts), "-i", toolPath(cfg.input))
+
+		chain := append([]string{"trim=end_frame=1", "setpts=PTS-STARTPTS"}, filters...)
+		chain = append(chain,
+			fmt.Sprintf("scale=%d:%d:force_original_aspect_ratio=decrease", g.cellWidth, g.cellHeight),
+			fmt.Sprintf("pad=%d:%d:(ow-iw)/2:(oh-ih)/2:color=%s", g.cellWidth, g.cellHeight, pad),
+			"setsar=1",
+		)
+		label := fmt.Sprintf("v%d", i)
+		chains = append(chains, fmt.Sprintf("[%d:%d]%s[%s]", i, cfg.streamIndex, strings.Join(chain, ","), label))
+		labels = append(labels, "["+label+"]")
+	}
+	tile := fmt.Sprintf("%sconcat=n=%d:v=1:a=0,tile=%dx%d:margin=%d:padding=%d:color=%s",
+		strings.Join(labels, ""), len(timestamps), layout.cols, layout.rows, g.padX, g.gapX, pad)
+	if format == "jpeg" {
+		tile += ",format=yuvj420p"
+	}
+	chains = append(chains, tile+"[out]")
+
+	args = append(args, "-filter_complex", strings.Join(chains, ";"), "-map", "[out]", "-frames:v", "1")
+	args = append(args, tileEncoderArgs(format, cfg.jpegQuality)...)
+	args = append(args, bitexactArgs(cfg.deterministic)...)
+	if cfg.output == "-" {
+		return append(args, "-f", "image2pipe", "-"), nil
+	}
+	return append(args, "-f", "image2", "-update", "1", toolPath(cfg.output)), nil
+}
+
+// tileEncoderArgs 将 --quality 换算为各编码器的质量参数，其余编码选项只在 go 后端中生效。
+func tileEncoderArgs(format string, quality int) []string {
+	switch format {
+	case "jpeg":
+		// mjpeg 的 qscale 取值 2 (最好) 到 31 (最差)。
+		return []string{"-c:v", "mjpeg", "-q:v", fmt.Sprint(int(math.Round(31 - float64(quality-1)*29/99)))}
+	case "webp":
+		return []string{"-c:v", "libwebp", "-quality", fmt.Sprint(quality)}
+	default:
+		return []string{"-c:v", format}
+	}
+}
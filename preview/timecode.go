@@ -0,0 +1,117 @@
+package preview
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+func validateTimestampFormat(format string) error {
+	switch format {
+	case "clock", "seconds", "frames", "smpte":
+		return nil
+	default:
+		return fmt.Errorf("不支持的时间标注格式: %s (可选: clock、seconds、frames、smpte)", format)
+	}
+}
+
+// startTimecode 返回文件记录的起始时间码：优先取视频流或 tmcd 数据流的 timecode 标签，其次为容器标签 (MXF 等)。
+func startTimecode(meta *videoMetadata) string {
+	for _, stream := range meta.streams {
+		if stream.index == meta.videoIndex && stream.tags["timecode"] != "" {
+			return stream.tags["timecode"]
+		}
+	}
+	for _, stream := range meta.streams {
+		if stream.tags["timecode"] != "" {
+			return stream.tags["timecode"]
+		}
+	}
+	return meta.tags["timecode"]
+}
+
+// smpteClock 按 SMPTE 12M 规则在帧序号与时间码之间换算；29.97/59.94 fps 的丢帧时间码以分号分隔帧数。
+type smpteClock struct {
+	rate  int // 每秒标称帧数，例如 29.97 fps 为 30
+	drop  bool
+	start int // 起始时间码对应的帧序号
+}
+
+func newSMPTEClock(fps float64, start string) smpteClock {
+	rate := max(int(math.Round(fps)), 1)
+	// 未记录起始时间码时，NTSC 帧率按惯例使用丢帧时间码。
+	drop := strings.Contains(start, ";") || start == "" && rate%30 == 0 && math.Abs(fps-float64(rate)) > 0.001
+	clock := smpteClock{rate: rate, drop: drop && rate%30 == 0}
+	if frames, ok := clock.parse(start); ok {
+		clock.start = frames
+	}
+	return clock
+}
+
+func (c smpteClock) dropFrames() int {
+	if !c.drop {
+		return 0
+	}
+	return c.rate / 30 * 2
+}
+
+func (c smpteClock) parse(timecode string) (int, bool) {
+	fields := strings.FieldsFunc(timecode, func(r rune) bool { return r == ':' || r == ';' || r == '.' })
+	if len(fields) != 4 {
+		return 0, false
+	}
+	var v [4]int
+	for i, field := range fields {
+		n, err := strconv.Atoi(field)
+		if err != nil || n < 0 {
+			return 0, false
+		}
+		v[i] = n
+	}
+	minutes := v[0]*60 + v[1]
+	frames := (minutes*60+v[2])*c.rate + v[3]
+	return frames - c.dropFrames()*(minutes-minutes/10), true
+}
+
+// format 与 ffmpeg 的 av_timecode_adjust_ntsc_framenum2 一致：每分钟跳过开头的若干帧号，逢 10 分钟不跳。
+func (c smpteClock) format(frame int) string {
+	if drop := c.dropFrames(); drop > 0 {
+		per10Min := c.rate*600 - drop*9
+		d, m := frame/per10Min, frame%per10Min
+		frame += 9*drop*d + drop*((m-drop)/(per10Min/10))
+	}
+	separator := ":"
+	if c.drop {
+		separator = ";"
+	}
+	ff := frame % c.rate
+	seconds := frame / c.rate
+	return fmt.Sprintf("%02d:%02d:%02d%s%02d", seconds/3600%24, seconds/60%60, seconds%60, separator, ff)
+}
+
+func (cfg *gridConfig) timestampLabel(ts float64) string {
+	if cfg.formatLabel == nil {
+		return formatTimestamp(ts)
+	}
+	return cfg.formatLabel(ts)
+}
+
+// timestampLabel 按 --timestamp-format 将采样时间格式化为截图上的标注文字。
+func timestampLabel(format string, meta *videoMetadata) func(float64) string {
+	fps := meta.fps
+	if fps <= 0 {
+		fps = 25
+	}
+	switch format {
+	case "seconds":
+		return func(ts float64) string { return strconv.FormatFloat(ts, 'f', 3, 64) + "s" }
+	case "frames":
+		return func(ts float64) string { return strconv.Itoa(int(math.Round(ts * fps))) }
+	case "smpte":
+		clock := newSMPTEClock(fps, startTimecode(meta))
+		return func(ts float64) string { return clock.format(clock.start + int(math.Round(ts*fps))) }
+	default:
+		return formatTimestamp
+	}
+}
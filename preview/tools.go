@@ -0,0 +1,27 @@
+package preview
+
+import (
+	"flag"
+	"fmt"
+	"os/exec"
+)
+
+// ffmpegPath 与 ffprobePath 默认从 PATH 中查找，可通过 --ffmpeg/--ffprobe 或 VPI_FFMPEG/VPI_FFPROBE 指定。
+var (
+	ffmpegPath  = "ffmpeg"
+	ffprobePath = "ffprobe"
+)
+
+func bindToolFlags(fs *flag.FlagSet) {
+	fs.StringVar(&ffmpegPath, "ffmpeg", ffmpegPath, "ffmpeg 可执行文件路径")
+	fs.StringVar(&ffprobePath, "ffprobe", ffprobePath, "ffprobe 可执行文件路径")
+}
+
+// ensureExecutables 只要求 ffmpeg；缺少 ffprobe 时 probeVideo 会退回内置解析器。
+func ensureExecutables() error {
+	resolveManagedTools()
+	if _, err := exec.LookPath(ffmpegPath); err != nil {
+		return fmt.Errorf("未找到 ffmpeg (%s)，请先安装并确保其在 PATH 中，或通过 --ffmpeg 指定路径；也可运行 install-ffmpeg 下载预编译版本", ffmpegPath)
+	}
+	return nil
+}
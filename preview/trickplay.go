@@ -0,0 +1,163 @@
+package preview
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// 默认值与 Jellyfin 的拖动预览设置相同：每 10 秒一张、宽 320 像素、每张拼图 10x10。
+	defaultTrickplayInterval = 10 * time.Second
+	defaultTrickplayTiles    = 10
+)
+
+// bifMagic 为 BIF (Base Index Frames) 文件头，Roku、Emby 与 Plex 使用该格式保存拖动预览。
+var bifMagic = []byte{0x89, 'B', 'I', 'F', '\r', '\n', 0x1a, '\n'}
+
+// trickplayUnsupported 为 --trickplay 不支持的参数；截图间隔、宽度与每张拼图的行列数分别取 --interval、--cell-width、--rows/--cols。
+var trickplayUnsupported = []string{
+	"layout", "layout-file", "style", "timestamps", "number-cells", "header", "header-template", "footer", "waveform",
+	"bitrate-graph", "loudness", "sidecar", "anim-output", "save-frames", "frames-only", "frame-numbers", "percentages", "segment",
+	"sheet-per-chapter", "selector", "sample", "avoid-freeze", "snap-to-keyframe", "at", "artwork", "variants",
+	"max-bytes", "embed-cover", "publish",
+}
+
+// trickplayInterval 返回截图间隔，未指定 --interval 时为 defaultTrickplayInterval。
+func (cfg *gridConfig) trickplayInterval() time.Duration {
+	if cfg.interval > 0 {
+		return cfg.interval
+	}
+	return defaultTrickplayInterval
+}
+
+// trickplayPath 返回 --trickplay 的输出位置，与输入视频位于同一目录：
+// jellyfin 为 <视频名>.trickplay/<宽度> - <列>x<行>/ 目录 (需开启“将拖动预览图保存在媒体文件夹中”)，
+// bif 为 Emby 识别的 <视频名>-<宽度>-<间隔秒数>.bif。
+func trickplayPath(cfg *gridConfig) string {
+	base := strings.TrimSuffix(cfg.input, filepath.Ext(cfg.input))
+	if cfg.trickplay == "bif" {
+		return fmt.Sprintf("%s-%d-%s.bif", base, cfg.cellWidth, strconv.FormatFloat(cfg.trickplayInterval().Seconds(), 'f', -1, 64))
+	}
+	return filepath.Join(base+".trickplay", fmt.Sprintf("%d - %dx%d", cfg.cellWidth, cfg.cols, cfg.rows))
+}
+
+// trickplayTimestamps 从 0 开始按间隔采样整个视频，与 Jellyfin 用 fps=1/间隔 截图的时间点一致。
+func trickplayTimestamps(cfg *gridConfig, meta *videoMetadata) []float64 {
+	interval := cfg.trickplayInterval().Seconds()
+	count := max(int(math.Ceil(meta.duration/interval)), 1)
+	last := thumbnailPosition{value: 100, percent: true}
+	end, _ := last.seconds(meta)
+	timestamps := make([]float64, count)
+	for i := range timestamps {
+		timestamps[i] = math.Min(float64(i)*interval, end)
+	}
+	return timestamps
+}
+
+// renderTrickplay 生成媒体服务器的拖动预览图：jellyfin 把缩略图按 --cols x --rows 拼成编号为 0.jpg、1.jpg… 的拼图，
+// 最后一张只保留用到的行；bif 把每张缩略图作为单独的 JPEG 写入一个 BIF 文件。
+func renderTrickplay(cfg *gridConfig, meta *videoMetadata, result *previewResult) error {
+	timestamps := trickplayTimestamps(cfg, meta)
+	filters := captureFilters(cfg, meta)
+	if cfg.prescale {
+		filters = append(filters, prescaleFilter([]image.Point{{cfg.cellWidth, cfg.cellHeight}}, 0, 0))
+	}
+	opts := cfg.encodeOptions()
+	opts.format = "jpeg"
+
+	path := trickplayPath(cfg)
+	if cfg.trickplay == "jellyfin" {
+		// 清除上次生成的拼图，视频变短后编号更大的旧拼图不会残留。
+		if err := os.RemoveAll(path); err != nil {
+			return err
+		}
+	}
+	perSheet := cfg.rows * cfg.cols
+	var sheet *image.RGBA
+	var thumbnails [][]byte
+	for captured, err := range captureFrames(cfg, meta, timestamps, filters) {
+		if err != nil {
+			return err
+		}
+		i, frame := captured.index, captured.image
+		if cfg.frameHook != nil {
+			if frame, err = cfg.frameHook(i, captured.timestamp, frame); err != nil {
+				return err
+			}
+		}
+		thumbnail := fillCrop(frame, cfg.cellWidth, cfg.cellHeight)
+		if cfg.autoLevels {
+			thumbnail = autoLevels(thumbnail)
+		}
+
+		if cfg.trickplay == "bif" {
+			var buf bytes.Buffer
+			if err := encodeImage(&buf, thumbnail, "jpeg", opts); err != nil {
+				return err
+			}
+			thumbnails = append(thumbnails, buf.Bytes())
+		} else {
+			slot := i % perSheet
+			if slot == 0 {
+				rows := min((len(timestamps)-i+cfg.cols-1)/cfg.cols, cfg.rows)
+				sheet = image.NewRGBA(image.Rect(0, 0, cfg.cols*cfg.cellWidth, rows*cfg.cellHeight))
+			}
+			at := image.Pt(slot%cfg.cols*cfg.cellWidth, slot/cfg.cols*cfg.cellHeight)
+			draw.Draw(sheet, thumbnail.Bounds().Add(at), thumbnail, image.Point{}, draw.Src)
+			if slot == perSheet-1 || i == len(timestamps)-1 {
+				if err := saveImage(sheet, filepath.Join(path, strconv.Itoa(i/perSheet)+".jpg"), opts); err != nil {
+					return err
+				}
+			}
+		}
+		if cfg.progress != nil {
+			cfg.progress(i+1, len(timestamps))
+		}
+	}
+	result.timestamps = append(result.timestamps, timestamps...)
+
+	if cfg.trickplay == "bif" {
+		return writeBIF(path, thumbnails, cfg.trickplayInterval())
+	}
+	return nil
+}
+
+// writeBIF 按 BIF 格式写入缩略图：64 字节文件头 (魔数、版本、图片数、时间间隔毫秒数)，
+// 每张图片一条 (序号, 偏移) 索引并以 0xffffffff 与文件末尾偏移结束，之后依次为 JPEG 数据。
+func writeBIF(path string, thumbnails [][]byte, interval time.Duration) error {
+	var buf bytes.Buffer
+	buf.Write(bifMagic)
+	header := make([]byte, 56)
+	binary.LittleEndian.PutUint32(header[4:], uint32(len(thumbnails)))
+	binary.LittleEndian.PutUint32(header[8:], uint32(interval.Milliseconds()))
+	buf.Write(header)
+
+	offset := uint32(64 + (len(thumbnails)+1)*8)
+	entry := make([]byte, 8)
+	for i, data := range thumbnails {
+		binary.LittleEndian.PutUint32(entry, uint32(i))
+		binary.LittleEndian.PutUint32(entry[4:], offset)
+		buf.Write(entry)
+		offset += uint32(len(data))
+	}
+	binary.LittleEndian.PutUint32(entry, math.MaxUint32)
+	binary.LittleEndian.PutUint32(entry[4:], offset)
+	buf.Write(entry)
+	for _, data := range thumbnails {
+		buf.Write(data)
+	}
+
+	if err := ensureOutputDir(path); err != nil {
+		return err
+	}
+	return os.WriteFile(path, buf.Bytes(), 0o644)
+}
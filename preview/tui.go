@@ -0,0 +1,174 @@
+package preview
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// tuiTimelineWidth 与 tuiProgressWidth 为时间轴和进度条的字符宽度。
+	tuiTimelineWidth = 60
+	tuiProgressWidth = 30
+)
+
+// tuiSession 为 --tui 的交互会话：视频信息只读取一次，每次生成使用 base 的副本，调整参数后可反复生成。
+type tuiSession struct {
+	base *gridConfig
+	meta *videoMetadata
+	in   *bufio.Scanner
+	out  io.Writer
+}
+
+// runTUI 在终端中交互地调整行数、列数、质量与输出路径，预览采样时间点在时间轴上的分布，
+// 生成时显示逐帧进度。只使用 ANSI 转义序列与按行输入，不依赖终端原始模式。
+func runTUI(base *gridConfig) error {
+	var meta *videoMetadata
+	err := withRetry(base, "读取视频信息", func() (err error) {
+		meta, err = probeVideo(base.context(), base.runner, base.input)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	if base.stream != "" {
+		if err := meta.selectVideoStream(base.stream); err != nil {
+			return err
+		}
+	}
+
+	s := &tuiSession{base: base, meta: meta, in: bufio.NewScanner(os.Stdin), out: os.Stdout}
+	for {
+		s.draw()
+		choice, ok := s.prompt("选择操作 [g]: ")
+		if !ok {
+			return nil
+		}
+		switch strings.ToLower(choice) {
+		case "r":
+			s.base.rows = s.askInt("行数", s.base.rows, 1, 100)
+		case "c":
+			s.base.cols = s.askInt("列数", s.base.cols, 1, 100)
+		case "q":
+			s.base.jpegQuality = s.askInt("质量", s.base.jpegQuality, 1, 100)
+		case "o":
+			if output, ok := s.prompt(fmt.Sprintf("输出路径 [%s]: ", s.base.output)); ok && output != "" {
+				s.base.output = output
+			}
+		case "g", "":
+			s.generate()
+		case "x":
+			return nil
+		}
+	}
+}
+
+func (s *tuiSession) draw() {
+	fmt.Fprint(s.out, "\033[H\033[2J")
+	width, height := s.meta.displaySize()
+	fmt.Fprintf(s.out, "视频: %s (%s，%dx%d，%s)\n", s.base.sourceName(), formatTimestamp(s.meta.duration), width, height, s.meta.videoCodec)
+	fmt.Fprintf(s.out, "输出: %s\n", s.base.output)
+	fmt.Fprintf(s.out, "布局: %d 行 x %d 列    质量: %d\n\n", s.base.rows, s.base.cols, s.base.jpegQuality)
+
+	timestamps, err := s.timestamps()
+	if err != nil {
+		fmt.Fprintf(s.out, "错误: %v\n\n", err)
+	} else {
+		fmt.Fprintf(s.out, "采样时间点 (%d):\n", len(timestamps))
+		fmt.Fprintln(s.out, s.timeline(timestamps))
+		for i, ts := range timestamps {
+			fmt.Fprintf(s.out, "%4d %s", i+1, formatTimestamp(ts))
+			if (i+1)%6 == 0 || i == len(timestamps)-1 {
+				fmt.Fprintln(s.out)
+			}
+		}
+		fmt.Fprintln(s.out)
+	}
+	fmt.Fprintln(s.out, "[r] 行数  [c] 列数  [q] 质量  [o] 输出路径  [g] 生成  [x] 退出")
+}
+
+// timestamps 按当前参数计算采样时间点，与生成时使用同一函数。
+func (s *tuiSession) timestamps() ([]float64, error) {
+	cfg := *s.base
+	cfg.streamIndex = s.meta.videoIndex
+	layout, err := cfg.sheetLayout()
+	if err != nil {
+		return nil, err
+	}
+	return planTimestamps(&cfg, s.meta, layout)
+}
+
+// timeline 把采样时间点画在一条横向时间轴上，两端标注开始与结束时间。
+func (s *tuiSession) timeline(timestamps []float64) string {
+	line := []rune(strings.Repeat("─", tuiTimelineWidth))
+	line[0], line[len(line)-1] = '├', '┤'
+	for _, ts := range timestamps {
+		pos := 0
+		if s.meta.duration > 0 {
+			pos = int(ts / s.meta.duration * float64(tuiTimelineWidth-1))
+		}
+		line[min(max(pos, 0), tuiTimelineWidth-1)] = '●'
+	}
+	return fmt.Sprintf("%s %s %s", formatTimestamp(0), string(line), formatTimestamp(s.meta.duration))
+}
+
+func (s *tuiSession) generate() {
+	cfg := *s.base
+	done := 0
+	cfg.progress = func(_, total int) {
+		if done++; done > total {
+			done = 1
+		}
+		filled := done * tuiProgressWidth / total
+		fmt.Fprintf(s.out, "\r截图 [%s%s] %d/%d", strings.Repeat("#", filled), strings.Repeat(" ", tuiProgressWidth-filled), done, total)
+	}
+
+	fmt.Fprintln(s.out)
+	start := time.Now()
+	err := generatePreview(&cfg)
+	fmt.Fprintln(s.out)
+	if err != nil {
+		fmt.Fprintln(s.out, "错误:", err)
+	} else {
+		fmt.Fprintf(s.out, "%s (耗时 %s)\n", resultMessage(&cfg), time.Since(start).Round(100*time.Millisecond))
+		if cfg.copyPath || cfg.copyImage {
+			if err := copyResult(&cfg); err != nil {
+				fmt.Fprintln(os.Stderr, "警告:", err)
+			}
+		}
+		if cfg.open {
+			if err := openResult(&cfg); err != nil {
+				fmt.Fprintln(os.Stderr, "警告:", err)
+			}
+		}
+	}
+	s.prompt("按回车返回，调整参数后可重新生成...")
+}
+
+// prompt 输出提示并读取一行输入；输入结束 (EOF) 时 ok 为 false。
+func (s *tuiSession) prompt(text string) (string, bool) {
+	fmt.Fprint(s.out, text)
+	if !s.in.Scan() {
+		fmt.Fprintln(s.out)
+		return "", false
+	}
+	return strings.TrimSpace(s.in.Text()), true
+}
+
+// askInt 读取 [low, high] 范围内的整数，直接回车保留当前值，输入无效时重新提示。
+func (s *tuiSession) askInt(label string, current, low, high int) int {
+	for {
+		text, ok := s.prompt(fmt.Sprintf("%s (%d-%d) [%d]: ", label, low, high, current))
+		if !ok || text == "" {
+			return current
+		}
+		if n, err := strconv.Atoi(text); err == nil && n >= low && n <= high {
+			return n
+		}
+		fmt.Fprintf(s.out, "%s 需要 %d-%d 之间的整数\n", label, low, high)
+	}
+}
@@ -0,0 +1,56 @@
+package preview
+
+import (
+	"fmt"
+	"image"
+	"image/draw"
+	"sort"
+	"strconv"
+	"strings"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// parseVariants 解析 --variants 的逗号分隔宽度列表 (像素)，去重后从小到大排列。
+func parseVariants(value string) ([]int, error) {
+	seen := map[int]bool{}
+	var widths []int
+	for _, field := range strings.Split(value, ",") {
+		field = strings.TrimSuffix(strings.TrimSpace(field), "px")
+		if field == "" {
+			continue
+		}
+		width, err := strconv.Atoi(field)
+		if err != nil || width <= 0 {
+			return nil, fmt.Errorf("无效的输出宽度: %s", field)
+		}
+		if !seen[width] {
+			seen[width] = true
+			widths = append(widths, width)
+		}
+	}
+	sort.Ints(widths)
+	return widths, nil
+}
+
+func variantOutput(path string, width int) string {
+	return spanOutput(path, strconv.Itoa(width))
+}
+
+// saveVariants 把已合成的拼图缩小到 --variants 指定的各个宽度另存，所有尺寸共用同一次截图。
+// 只缩小不放大：宽度大于拼图时报错，需要增大 --cell-width 让主图足够大。
+func saveVariants(collage image.Image, cfg *gridConfig, opts encodeOptions) error {
+	bounds := collage.Bounds()
+	if len(cfg.variants) > 0 && cfg.variants[len(cfg.variants)-1] > bounds.Dx() {
+		return fmt.Errorf("输出宽度 %d 大于拼图宽度 %d，请增大 --cell-width", cfg.variants[len(cfg.variants)-1], bounds.Dx())
+	}
+	for _, width := range cfg.variants {
+		height := max(bounds.Dy()*width/bounds.Dx(), 1)
+		dst := image.NewRGBA(image.Rect(0, 0, width, height))
+		xdraw.CatmullRom.Scale(dst, dst.Bounds(), collage, bounds, draw.Src, nil)
+		if err := saveImage(dst, variantOutput(cfg.output, width), opts); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package preview
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"runtime"
+	"runtime/debug"
+	"strings"
+)
+
+const toolName = "video-preview-image"
+
+// version、commit 与 buildDate 在发布构建时通过 -ldflags "-X video-preview-image/preview.version=..." 覆盖。
+var (
+	version   = "dev"
+	commit    = ""
+	buildDate = ""
+)
+
+// BuildInfo 为本工具的版本与构建信息，以及当前使用的 ffmpeg、ffprobe 版本 (未找到时为空)，与 --version 的输出相同。
+type BuildInfo struct {
+	Version        string `json:"version"`
+	Commit         string `json:"commit,omitempty"`
+	BuildDate      string `json:"build_date,omitempty"`
+	GoVersion      string `json:"go_version"`
+	Platform       string `json:"platform"`
+	FFmpegVersion  string `json:"ffmpeg_version,omitempty"`
+	FFprobeVersion string `json:"ffprobe_version,omitempty"`
+}
+
+// Version 汇总版本信息，便于在问题报告或自动化环境中记录实际使用的版本。未通过 ldflags 注入时，
+// 尝试从 Go 工具链记录的 VCS 信息中补全提交与时间；ffmpeg 与 ffprobe 的版本通过执行 -version 获取。
+func Version() BuildInfo {
+	info := BuildInfo{
+		Version:   version,
+		Commit:    commit,
+		BuildDate: buildDate,
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range bi.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if info.Commit == "" {
+					info.Commit = setting.Value
+				}
+			case "vcs.time":
+				if info.BuildDate == "" {
+					info.BuildDate = setting.Value
+				}
+			}
+		}
+	}
+	resolveManagedTools()
+	info.FFmpegVersion = toolVersion(ffmpegPath)
+	info.FFprobeVersion = toolVersion(ffprobePath)
+	return info
+}
+
+// toolVersion 返回 "ffmpeg -version" 输出首行中的版本号，无法执行时返回空字符串。
+func toolVersion(path string) string {
+	output, err := newCommand(context.Background(), nil, path, "-version").Output()
+	if err != nil {
+		return ""
+	}
+	line, _, _ := strings.Cut(string(output), "\n")
+	fields := strings.Fields(line)
+	for i, field := range fields {
+		if field == "version" && i+1 < len(fields) {
+			return fields[i+1]
+		}
+	}
+	return strings.TrimSpace(line)
+}
+
+func printVersion(w io.Writer) {
+	info := Version()
+	fmt.Fprintf(w, "%s %s\n", toolName, info.Version)
+	if info.Commit != "" {
+		fmt.Fprintf(w, "  commit:     %s\n", info.Commit)
+	}
+	if info.BuildDate != "" {
+		fmt.Fprintf(w, "  built:      %s\n", info.BuildDate)
+	}
+	fmt.Fprintf(w, "  go:         %s %s\n", info.GoVersion, info.Platform)
+	fmt.Fprintf(w, "  ffmpeg:     %s\n", valueOrMissing(info.FFmpegVersion))
+	fmt.Fprintf(w, "  ffprobe:    %s\n", valueOrMissing(info.FFprobeVersion))
+}
+
+func valueOrMissing(value string) string {
+	if value == "" {
+		return "未找到"
+	}
+	return value
+}
@@ -0,0 +1,29 @@
+package preview
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestVersion(t *testing.T) {
+	saved := ffmpegPath
+	ffmpegPath = "video-preview-image-missing-ffmpeg"
+	t.Cleanup(func() { ffmpegPath = saved })
+
+	info := Version()
+	if info.Version != version || info.GoVersion != runtime.Version() {
+		t.Errorf("版本为 %q (Go %q)，期望 %q (Go %q)", info.Version, info.GoVersion, version, runtime.Version())
+	}
+	if info.FFmpegVersion != "" {
+		t.Errorf("找不到 ffmpeg 时版本应为空，实际为 %q", info.FFmpegVersion)
+	}
+
+	var out strings.Builder
+	printVersion(&out)
+	for _, want := range []string{toolName + " " + version, "go:         " + runtime.Version(), "ffmpeg:     未找到"} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("--version 输出 %q 中缺少 %q", out.String(), want)
+		}
+	}
+}
@@ -0,0 +1,330 @@
+package preview
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+type watchConfig struct {
+	dir             string
+	outputDir       string
+	outputExt       string
+	extensions      map[string]bool
+	settle          time.Duration
+	recursive       bool
+	processExisting bool
+	workers         int
+	grid            *gridConfig
+}
+
+type pendingFile struct {
+	size       int64
+	modTime    time.Time
+	lastChange time.Time
+}
+
+func runWatch(args []string) error {
+	cfg, err := parseWatchFlags(args)
+	if err != nil {
+		return err
+	}
+
+	if err := ensureExecutables(); err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("创建目录监听失败: %w", err)
+	}
+	defer watcher.Close()
+
+	if err := addWatchDirs(watcher, cfg); err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	jobs := make(chan string)
+	var workers sync.WaitGroup
+	for i := 0; i < cfg.workers; i++ {
+		workers.Go(func() {
+			for path := range jobs {
+				processWatchedFile(ctx, path, cfg)
+			}
+		})
+	}
+	// ready 为已写入完成、等待空闲 worker 的视频；只在 select 中发送，worker 都在忙时也不阻塞事件处理与退出。
+	// 同一路径排队期间再次变化不会重复加入。
+	var ready []string
+	queued := make(map[string]bool)
+
+	pending := make(map[string]*pendingFile)
+	if cfg.processExisting {
+		for _, path := range listVideos(cfg) {
+			trackPending(pending, path)
+		}
+	}
+
+	fmt.Printf("正在监听目录: %s (按 Ctrl+C 退出)\n", cfg.dir)
+
+	ticker := time.NewTicker(500 * time.Millisecond)
+	defer ticker.Stop()
+
+loop:
+	for {
+		var send chan<- string
+		var next string
+		if len(ready) > 0 {
+			send, next = jobs, ready[0]
+		}
+		select {
+		case <-ctx.Done():
+			break loop
+		case send <- next:
+			ready = ready[1:]
+			delete(queued, next)
+		case event, ok := <-watcher.Events:
+			if !ok {
+				break loop
+			}
+			handleWatchEvent(watcher, cfg, pending, event)
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				break loop
+			}
+			fmt.Fprintln(os.Stderr, "监听出错:", err)
+		case now := <-ticker.C:
+			for path, state := range pending {
+				stable, exists := checkStable(path, state, now, cfg.settle)
+				if !exists {
+					delete(pending, path)
+					continue
+				}
+				if stable {
+					delete(pending, path)
+					if !queued[path] {
+						queued[path] = true
+						ready = append(ready, path)
+					}
+				}
+			}
+		}
+	}
+
+	close(jobs)
+	workers.Wait()
+	return nil
+}
+
+type watchFlags struct {
+	cfg     watchConfig
+	extList string
+	grid    *gridFlags
+}
+
+func newWatchFlagSet() (*flag.FlagSet, *watchFlags) {
+	fs := flag.NewFlagSet("watch", flag.ExitOnError)
+	wf := &watchFlags{}
+	cfg := &wf.cfg
+
+	fs.StringVar(&cfg.dir, "dir", "", "需要监听的视频目录 (必填)")
+	fs.StringVar(&cfg.outputDir, "output-dir", "", "预览图输出目录，为空时写入视频所在目录")
+	fs.StringVar(&wf.extList, "ext", "mp4,mkv,mov,avi,webm,m4v,ts,flv,wmv", "需要处理的视频扩展名，逗号分隔")
+	fs.DurationVar(&cfg.settle, "settle", 3*time.Second, "文件大小保持不变多久后才视为写入完成")
+	fs.BoolVar(&cfg.recursive, "recursive", false, "同时监听子目录")
+	fs.BoolVar(&cfg.processExisting, "process-existing", false, "启动时处理目录中已存在且尚无预览图的视频")
+	fs.IntVar(&cfg.workers, "workers", 1, "并行处理视频的数量")
+	bindToolFlags(fs)
+	bindFontFlags(fs)
+	wf.grid = bindGridFlags(fs)
+	return fs, wf
+}
+
+func parseWatchFlags(args []string) (*watchConfig, error) {
+	fs, wf := newWatchFlagSet()
+	if err := parseArgs(fs, args); err != nil {
+		return nil, err
+	}
+	cfg := &wf.cfg
+	extList := wf.extList
+	gf := wf.grid
+
+	if cfg.dir == "" {
+		return nil, errors.New("必须指定监听目录 --dir")
+	}
+	info, err := os.Stat(cfg.dir)
+	if err != nil {
+		return nil, fmt.Errorf("无法访问监听目录: %w", err)
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("监听路径不是目录: %s", cfg.dir)
+	}
+
+	if cfg.settle <= 0 {
+		return nil, errors.New("settle 必须大于 0")
+	}
+
+	if cfg.workers < 1 {
+		return nil, errors.New("workers 必须为正整数")
+	}
+
+	cfg.extensions = make(map[string]bool)
+	for _, ext := range strings.Split(extList, ",") {
+		ext = strings.ToLower(strings.TrimPrefix(strings.TrimSpace(ext), "."))
+		if ext != "" {
+			cfg.extensions["."+ext] = true
+		}
+	}
+	if len(cfg.extensions) == 0 {
+		return nil, errors.New("ext 至少需要包含一个扩展名")
+	}
+
+	grid, err := gf.config()
+	if err != nil {
+		return nil, err
+	}
+	cfg.grid = grid
+
+	cfg.outputExt = ".png"
+	if grid.format != "" {
+		cfg.outputExt = formatExtension(grid.format)
+	}
+
+	return cfg, nil
+}
+
+func addWatchDirs(watcher *fsnotify.Watcher, cfg *watchConfig) error {
+	if !cfg.recursive {
+		if err := watcher.Add(cfg.dir); err != nil {
+			return fmt.Errorf("监听目录失败: %w", err)
+		}
+		return nil
+	}
+
+	return filepath.WalkDir(cfg.dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			return nil
+		}
+		if err := watcher.Add(path); err != nil {
+			return fmt.Errorf("监听目录失败: %w", err)
+		}
+		return nil
+	})
+}
+
+func handleWatchEvent(watcher *fsnotify.Watcher, cfg *watchConfig, pending map[string]*pendingFile, event fsnotify.Event) {
+	if event.Has(fsnotify.Remove) || event.Has(fsnotify.Rename) {
+		delete(pending, event.Name)
+		return
+	}
+	if !event.Has(fsnotify.Create) && !event.Has(fsnotify.Write) {
+		return
+	}
+
+	if event.Has(fsnotify.Create) && cfg.recursive {
+		if info, err := os.Stat(event.Name); err == nil && info.IsDir() {
+			if err := watcher.Add(event.Name); err != nil {
+				fmt.Fprintln(os.Stderr, "监听子目录失败:", err)
+			}
+			return
+		}
+	}
+
+	if !cfg.extensions[strings.ToLower(filepath.Ext(event.Name))] {
+		return
+	}
+	trackPending(pending, event.Name)
+}
+
+func trackPending(pending map[string]*pendingFile, path string) {
+	if state, ok := pending[path]; ok {
+		state.lastChange = time.Now()
+		return
+	}
+	pending[path] = &pendingFile{size: -1, lastChange: time.Now()}
+}
+
+// 文件可能仍在复制中，只有大小和修改时间在 settle 时长内都未变化才认为写入完成。
+func checkStable(path string, state *pendingFile, now time.Time, settle time.Duration) (bool, bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, false
+	}
+
+	if info.Size() != state.size || !info.ModTime().Equal(state.modTime) {
+		state.size = info.Size()
+		state.modTime = info.ModTime()
+		state.lastChange = now
+		return false, true
+	}
+
+	return info.Size() > 0 && now.Sub(state.lastChange) >= settle, true
+}
+
+func listVideos(cfg *watchConfig) []string {
+	var videos []string
+	_ = filepath.WalkDir(cfg.dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != cfg.dir && !cfg.recursive {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !cfg.extensions[strings.ToLower(filepath.Ext(path))] {
+			return nil
+		}
+		if _, err := os.Stat(previewPathFor(path, cfg.outputDir, cfg.outputExt)); err == nil {
+			return nil
+		}
+		videos = append(videos, path)
+		return nil
+	})
+	return videos
+}
+
+func previewPathFor(video, outputDir, ext string) string {
+	dir := filepath.Dir(video)
+	if outputDir != "" {
+		dir = outputDir
+	}
+	name := strings.TrimSuffix(filepath.Base(video), filepath.Ext(video))
+	return filepath.Join(dir, name+"_preview"+ext)
+}
+
+// processWatchedFile 生成 path 的预览图；ctx 结束 (Ctrl+C) 时终止正在运行的 ffmpeg。
+func processWatchedFile(ctx context.Context, path string, cfg *watchConfig) {
+	job := *cfg.grid
+	job.ctx = ctx
+	job.input = path
+	job.output = previewPathFor(path, cfg.outputDir, cfg.outputExt)
+
+	fmt.Printf("检测到新视频: %s\n", path)
+	if err := generatePreview(&job); err != nil {
+		if ctx.Err() != nil {
+			fmt.Fprintf(os.Stderr, "已取消处理 %s\n", path)
+			return
+		}
+		fmt.Fprintf(os.Stderr, "错误: 处理 %s 失败: %v\n", path, err)
+		return
+	}
+	fmt.Println(resultMessage(&job))
+}
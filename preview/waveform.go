@@ -0,0 +1,98 @@
+package preview
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+	"math"
+)
+
+// 波形只用于目视检查，以 8 kHz 单声道解码即可，显著减少长视频的解码与传输量。
+const waveformSampleRate = 8000
+
+type waveformPeak struct {
+	min, max float64
+}
+
+// extractWaveform 将第一条音轨解码为单声道 PCM，并按 columns 个时间段统计每段的最小与最大振幅 (-1 到 1)。
+func extractWaveform(ctx context.Context, r Runner, path string, duration float64, columns int) ([]waveformPeak, error) {
+	cmd := newCommand(ctx, r, ffmpegPath,
+		"-loglevel", "error",
+		"-i", toolPath(path),
+		"-map", "0:a:0", "-vn",
+		"-ac", "1", "-ar", fmt.Sprint(waveformSampleRate),
+		"-f", "s16le", "-",
+	)
+	var stderr stderrTail
+	cmd.Stderr = &stderr
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		observeToolFailure("waveform")
+		return nil, err
+	}
+
+	peaks := make([]waveformPeak, columns)
+	total := max(duration*waveformSampleRate, 1)
+	buf := make([]byte, 64<<10)
+	index := 0
+	for {
+		n, err := io.ReadFull(stdout, buf)
+		for i := 0; i+1 < n; i += 2 {
+			column := min(int(float64(index)/total*float64(columns)), columns-1)
+			value := float64(int16(binary.LittleEndian.Uint16(buf[i:]))) / 32768
+			peaks[column].min = math.Min(peaks[column].min, value)
+			peaks[column].max = math.Max(peaks[column].max, value)
+			index++
+		}
+		if errors.Is(err, io.EOF) || errors.Is(err, io.ErrUnexpectedEOF) {
+			break
+		}
+		if err != nil {
+			_ = cmd.Wait()
+			return nil, err
+		}
+	}
+	if err := cmd.Wait(); err != nil {
+		observeToolFailure("waveform")
+		return nil, fmt.Errorf("解码音轨失败: %w", commandError(err, stderr.Bytes()))
+	}
+	return peaks, nil
+}
+
+func waveformHeight(width int) int {
+	return max(48, width/12)
+}
+
+// addWaveform 在拼图下方拼接音频波形条，并在每个采样时间点处画出标记线。
+func addWaveform(sheet image.Image, cfg *gridConfig, meta *videoMetadata, timestamps []float64) (image.Image, error) {
+	height := waveformHeight(sheet.Bounds().Dx())
+	canvas, area := appendPanel(sheet, cfg, height)
+	if area.Empty() {
+		return sheet, nil
+	}
+
+	peaks, err := extractWaveform(cfg.context(), cfg.runner, cfg.input, meta.duration, area.Dx())
+	if err != nil {
+		return nil, err
+	}
+
+	center := area.Min.Y + height/2
+	half := float64(height) / 2
+	ink := contrastColor(cfg.background)
+	waveColor := &image.Uniform{C: withAlpha(ink, 200)}
+	draw.Draw(canvas, image.Rect(area.Min.X, center, area.Max.X, center+1), &image.Uniform{C: withAlpha(ink, 60)}, image.Point{}, draw.Over)
+	for x, peak := range peaks {
+		y0 := center - int(math.Round(peak.max*half))
+		y1 := center - int(math.Round(peak.min*half)) + 1
+		draw.Draw(canvas, image.Rect(area.Min.X+x, y0, area.Min.X+x+1, y1), waveColor, image.Point{}, draw.Over)
+	}
+	drawSampleMarkers(canvas, area, timestamps, meta.duration)
+	return canvas, nil
+}
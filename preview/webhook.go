@@ -0,0 +1,89 @@
+package preview
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+type previewResult struct {
+	duration   float64
+	timestamps []float64
+}
+
+// completionReport 描述一个任务的结束状态，用于 webhook 通知与队列结果消息。
+type completionReport struct {
+	ID         string    `json:"id,omitempty"`
+	Status     string    `json:"status"`
+	Input      string    `json:"input"`
+	Output     string    `json:"output,omitempty"`
+	Animation  string    `json:"animation,omitempty"`
+	FramesDir  string    `json:"frames_dir,omitempty"`
+	Duration   float64   `json:"duration,omitempty"`
+	Timestamps []float64 `json:"timestamps,omitempty"`
+	Error      string    `json:"error,omitempty"`
+	FinishedAt time.Time `json:"finished_at"`
+}
+
+var webhookClient = &http.Client{Timeout: 15 * time.Second}
+
+func newCompletionReport(cfg *gridConfig, result *previewResult, jobErr error) completionReport {
+	report := completionReport{
+		Status:     "success",
+		Input:      cfg.sourceName(),
+		Duration:   result.duration,
+		Timestamps: result.timestamps,
+		FinishedAt: time.Now().UTC(),
+	}
+	if !cfg.framesOnly {
+		report.Output = cfg.outputName()
+	}
+	report.Animation = cfg.animOutput
+	report.FramesDir = cfg.saveFramesDir
+	if jobErr != nil {
+		report.Status = "failed"
+		report.Error = jobErr.Error()
+	}
+	return report
+}
+
+// notifyWebhook 在任务结束后 POST JSON 通知，失败时按 1s、2s 间隔重试两次；通知失败只输出警告，不影响任务结果。
+func notifyWebhook(cfg *gridConfig, result *previewResult, jobErr error) {
+	body, err := json.Marshal(newCompletionReport(cfg, result, jobErr))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "警告: 序列化 webhook 数据失败:", err)
+		return
+	}
+
+	for attempt := 0; attempt < 3; attempt++ {
+		if attempt > 0 {
+			time.Sleep(time.Duration(attempt) * time.Second)
+		}
+		if err = postWebhook(cfg.webhook, body); err == nil {
+			return
+		}
+	}
+	fmt.Fprintf(os.Stderr, "警告: 发送 webhook 通知失败: %v\n", err)
+}
+
+func postWebhook(url string, body []byte) error {
+	request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set("User-Agent", toolName+"/"+version)
+
+	response, err := webhookClient.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+	if response.StatusCode < 200 || response.StatusCode >= 300 {
+		return fmt.Errorf("服务器返回 %s", response.Status)
+	}
+	return nil
+}
@@ -0,0 +1,14 @@
+//go:build !windows
+
+package preview
+
+func setupConsole() {}
+
+func restoreConsole() {}
+
+func waitIfOwnConsole() {}
+
+// toolPath 返回传给 ffmpeg、ffprobe 的本地路径，只有 Windows 需要转换。
+func toolPath(path string) string {
+	return path
+}
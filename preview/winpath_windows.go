@@ -0,0 +1,88 @@
+//go:build windows
+
+package preview
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+const (
+	// maxShortPath 为不加 \\?\ 前缀时可用的路径长度；MAX_PATH 为 260，目录还需留出 8.3 文件名的位置。
+	maxShortPath = 248
+	utf8CodePage = 65001
+)
+
+// x/sys/windows 未封装 GetConsoleProcessList。
+var procGetConsoleProcessList = windows.NewLazySystemDLL("kernel32.dll").NewProc("GetConsoleProcessList")
+
+// consoleCodePage 为启动时控制台的输出代码页，退出时恢复，不影响同一控制台中之后运行的程序。
+var consoleCodePage uint32
+
+// setupConsole 把控制台输出代码页切换为 UTF-8。Go 写入控制台时已使用 WriteConsoleW，
+// 但 ffmpeg 等子进程继承同一控制台输出 UTF-8 文本，中文文件名与消息在默认的 GBK 代码页下会乱码。
+func setupConsole() {
+	cp, err := windows.GetConsoleOutputCP()
+	if err != nil || cp == utf8CodePage {
+		return
+	}
+	if windows.SetConsoleOutputCP(utf8CodePage) == nil {
+		consoleCodePage = cp
+	}
+}
+
+func restoreConsole() {
+	if consoleCodePage != 0 {
+		windows.SetConsoleOutputCP(consoleCodePage)
+		consoleCodePage = 0
+	}
+}
+
+// waitIfOwnConsole 在控制台只属于本进程时 (双击或拖放文件到程序上启动) 等待回车再退出，
+// 否则窗口会随进程退出立即关闭，看不到结果与错误信息。
+func waitIfOwnConsole() {
+	processes := make([]uint32, 2)
+	n, _, _ := procGetConsoleProcessList.Call(uintptr(unsafe.Pointer(&processes[0])), uintptr(len(processes)))
+	if n != 1 {
+		return
+	}
+	fmt.Fprint(os.Stderr, "按回车键退出...")
+	bufio.NewReader(os.Stdin).ReadString('\n')
+}
+
+// toolPath 返回传给 ffmpeg、ffprobe 的本地路径。Go 的 os 包会自动处理长路径，外部程序不会：
+// 超过 maxShortPath 的路径改为 \\?\ 扩展长度形式，UNC 路径 (\\server\share) 改为 \\?\UNC\server\share。
+// 标准输入输出 "-"、URL 与 ffmpeg 协议 (pipe:、concat: 等) 原样返回。
+func toolPath(path string) string {
+	if path == "-" || strings.Contains(path, "://") || strings.HasPrefix(path, `\\?\`) || isProtocolPath(path) {
+		return path
+	}
+	abs, err := filepath.Abs(path)
+	if err != nil || len(abs) < maxShortPath {
+		return path
+	}
+	if unc, ok := strings.CutPrefix(abs, `\\`); ok {
+		return `\\?\UNC\` + unc
+	}
+	return `\\?\` + abs
+}
+
+// isProtocolPath 判断路径是否以 ffmpeg 协议名开头；单个字母后跟冒号为盘符。
+func isProtocolPath(path string) bool {
+	name, _, ok := strings.Cut(path, ":")
+	if !ok || len(name) < 2 {
+		return false
+	}
+	for _, r := range name {
+		if (r < 'a' || r > 'z') && (r < '0' || r > '9') {
+			return false
+		}
+	}
+	return true
+}
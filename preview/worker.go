@@ -0,0 +1,238 @@
+package preview
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type workerConfig struct {
+	natsURL       string
+	stream        string
+	subject       string
+	consumer      string
+	resultSubject string
+	workers       int
+	maxDeliver    int
+	ackWait       time.Duration
+	retryDelay    time.Duration
+	metricsListen string
+}
+
+// queueJob 是队列中一条任务消息的内容，options 与 gRPC 请求相同，以参数名为键。
+type queueJob struct {
+	ID      string            `json:"id"`
+	Input   string            `json:"input"`
+	Output  string            `json:"output"`
+	Options map[string]string `json:"options"`
+}
+
+func newWorkerFlagSet() (*flag.FlagSet, *workerConfig) {
+	fs := flag.NewFlagSet("worker", flag.ExitOnError)
+	wc := &workerConfig{}
+	fs.StringVar(&wc.natsURL, "nats-url", nats.DefaultURL, "NATS 服务地址，多个地址以逗号分隔")
+	fs.StringVar(&wc.stream, "stream", "PREVIEWS", "保存任务的 JetStream 流名称，不存在时按 --subject 自动创建")
+	fs.StringVar(&wc.subject, "subject", "previews.jobs", "任务消息的主题")
+	fs.StringVar(&wc.consumer, "consumer", "video-preview-image", "持久化消费者名称，多台机器使用相同名称共同分担任务")
+	fs.StringVar(&wc.resultSubject, "result-subject", "previews.results", "发布任务结果的主题")
+	fs.IntVar(&wc.workers, "workers", 1, "本机同时处理的任务数")
+	fs.IntVar(&wc.maxDeliver, "max-deliver", 3, "单个任务最多尝试的次数，超过后发布失败结果")
+	fs.DurationVar(&wc.ackWait, "ack-wait", 5*time.Minute, "任务未确认时重新投递的超时时间，处理期间会定期续期")
+	fs.DurationVar(&wc.retryDelay, "retry-delay", 10*time.Second, "任务失败后重新投递前的等待时间")
+	fs.StringVar(&wc.metricsListen, "metrics-listen", ":9090", "Prometheus /metrics 监听地址，为空时不启动")
+	bindToolFlags(fs)
+	bindFontFlags(fs)
+	return fs, wc
+}
+
+func parseWorkerFlags(args []string) (*workerConfig, error) {
+	fs, wc := newWorkerFlagSet()
+	if err := parseArgs(fs, args); err != nil {
+		return nil, err
+	}
+	if wc.workers < 1 {
+		return nil, errors.New("workers 必须为正整数")
+	}
+	if wc.maxDeliver < 1 {
+		return nil, errors.New("max-deliver 必须为正整数")
+	}
+	if wc.ackWait < time.Second {
+		return nil, errors.New("ack-wait 不能小于 1s")
+	}
+	return wc, nil
+}
+
+func runWorker(args []string) error {
+	wc, err := parseWorkerFlags(args)
+	if err != nil {
+		return err
+	}
+
+	if err := ensureExecutables(); err != nil {
+		return err
+	}
+
+	nc, err := nats.Connect(wc.natsURL, nats.Name(toolName))
+	if err != nil {
+		return fmt.Errorf("连接 NATS 失败: %w", err)
+	}
+	defer nc.Drain()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	consumer, err := setupConsumer(ctx, nc, wc)
+	if err != nil {
+		return err
+	}
+
+	metricsRegistry.MustRegister(prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Name: "vpi_queue_pending_jobs",
+		Help: "队列中尚未投递给任何 worker 的任务数。",
+	}, func() float64 {
+		infoCtx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		defer cancel()
+		info, err := consumer.Info(infoCtx)
+		if err != nil {
+			return math.NaN()
+		}
+		return float64(info.NumPending)
+	}))
+	if err := serveMetrics(wc.metricsListen); err != nil {
+		return err
+	}
+
+	fmt.Printf("正在消费任务: %s (流 %s，并发 %d，按 Ctrl+C 退出)\n", wc.subject, wc.stream, wc.workers)
+
+	// 每个 worker 空闲时才拉取一条消息，未处理的任务留在服务端，由其他机器上的 worker 分担。
+	var workers sync.WaitGroup
+	for i := 0; i < wc.workers; i++ {
+		workers.Go(func() {
+			for ctx.Err() == nil {
+				msg, err := consumer.Next(jetstream.FetchMaxWait(5 * time.Second))
+				if err != nil {
+					if errors.Is(err, nats.ErrTimeout) || errors.Is(err, jetstream.ErrNoMessages) || ctx.Err() != nil {
+						continue
+					}
+					fmt.Fprintln(os.Stderr, "警告: 拉取任务失败:", err)
+					time.Sleep(time.Second)
+					continue
+				}
+				handleQueueMessage(nc, msg, wc)
+			}
+		})
+	}
+	workers.Wait()
+	return nil
+}
+
+func setupConsumer(ctx context.Context, nc *nats.Conn, wc *workerConfig) (jetstream.Consumer, error) {
+	js, err := jetstream.New(nc)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := js.Stream(ctx, wc.stream); errors.Is(err, jetstream.ErrStreamNotFound) {
+		_, err = js.CreateStream(ctx, jetstream.StreamConfig{
+			Name:      wc.stream,
+			Subjects:  []string{wc.subject},
+			Retention: jetstream.WorkQueuePolicy,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("创建流 %s 失败: %w", wc.stream, err)
+		}
+	} else if err != nil {
+		return nil, fmt.Errorf("读取流 %s 失败: %w", wc.stream, err)
+	}
+
+	consumer, err := js.CreateOrUpdateConsumer(ctx, wc.stream, jetstream.ConsumerConfig{
+		Durable:       wc.consumer,
+		FilterSubject: wc.subject,
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       wc.ackWait,
+		MaxDeliver:    wc.maxDeliver,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("创建消费者 %s 失败: %w", wc.consumer, err)
+	}
+	return consumer, nil
+}
+
+func handleQueueMessage(nc *nats.Conn, msg jetstream.Msg, wc *workerConfig) {
+	var job queueJob
+	if err := json.Unmarshal(msg.Data(), &job); err != nil {
+		publishJobResult(nc, wc, completionReport{Status: "failed", Error: "解析任务消息失败: " + err.Error(), FinishedAt: time.Now().UTC()})
+		_ = msg.Term()
+		return
+	}
+
+	cfg, err := jobConfig(job.Input, job.Output, job.Options)
+	if err != nil {
+		publishJobResult(nc, wc, completionReport{ID: job.ID, Status: "failed", Input: job.Input, Error: err.Error(), FinishedAt: time.Now().UTC()})
+		_ = msg.Term()
+		return
+	}
+
+	fmt.Printf("开始处理任务: %s\n", job.Input)
+	stopProgress := keepInProgress(msg, wc.ackWait/2)
+	result, err := runPreview(cfg)
+	stopProgress()
+
+	if err != nil {
+		if meta, metaErr := msg.Metadata(); metaErr == nil && int(meta.NumDelivered) < wc.maxDeliver {
+			fmt.Fprintf(os.Stderr, "错误: 处理 %s 失败 (第 %d/%d 次)，稍后重试: %v\n", job.Input, meta.NumDelivered, wc.maxDeliver, err)
+			_ = msg.NakWithDelay(wc.retryDelay)
+			return
+		}
+		fmt.Fprintf(os.Stderr, "错误: 处理 %s 失败: %v\n", job.Input, err)
+	} else {
+		fmt.Println(resultMessage(cfg))
+	}
+
+	report := newCompletionReport(cfg, result, err)
+	report.ID = job.ID
+	publishJobResult(nc, wc, report)
+	if ackErr := msg.Ack(); ackErr != nil {
+		fmt.Fprintln(os.Stderr, "警告: 确认任务失败:", ackErr)
+	}
+}
+
+// keepInProgress 在处理期间定期通知服务端任务仍在进行，避免长视频超过 ack-wait 后被重复投递。
+func keepInProgress(msg jetstream.Msg, interval time.Duration) func() {
+	done := make(chan struct{})
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-done:
+				return
+			case <-ticker.C:
+				_ = msg.InProgress()
+			}
+		}
+	}()
+	return func() { close(done) }
+}
+
+func publishJobResult(nc *nats.Conn, wc *workerConfig, report completionReport) {
+	data, err := json.Marshal(report)
+	if err == nil {
+		err = nc.Publish(wc.resultSubject, data)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "警告: 发布任务结果失败:", err)
+	}
+}
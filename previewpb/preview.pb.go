@@ -0,0 +1,999 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        v5.29.3
+// source: previewpb/preview.proto
+
+package previewpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type GeneratePreviewRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// 输入视频路径或 s3://、gs://、az:// 地址，路径相对于服务进程的工作目录。
+	Input string `protobuf:"bytes,1,opt,name=input,proto3" json:"input,omitempty"`
+	// 输出路径或对象存储地址，格式按扩展名或 options 中的 format 决定。
+	Output string `protobuf:"bytes,2,opt,name=output,proto3" json:"output,omitempty"`
+	// 其余参数以命令行参数名为键 (不含前导 -)，例如 {"rows": "4", "preset": "torrent"}。
+	Options       map[string]string `protobuf:"bytes,3,rep,name=options,proto3" json:"options,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GeneratePreviewRequest) Reset() {
+	*x = GeneratePreviewRequest{}
+	mi := &file_previewpb_preview_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GeneratePreviewRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GeneratePreviewRequest) ProtoMessage() {}
+
+func (x *GeneratePreviewRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_previewpb_preview_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GeneratePreviewRequest.ProtoReflect.Descriptor instead.
+func (*GeneratePreviewRequest) Descriptor() ([]byte, []int) {
+	return file_previewpb_preview_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *GeneratePreviewRequest) GetInput() string {
+	if x != nil {
+		return x.Input
+	}
+	return ""
+}
+
+func (x *GeneratePreviewRequest) GetOutput() string {
+	if x != nil {
+		return x.Output
+	}
+	return ""
+}
+
+func (x *GeneratePreviewRequest) GetOptions() map[string]string {
+	if x != nil {
+		return x.Options
+	}
+	return nil
+}
+
+type GeneratePreviewResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Input         string                 `protobuf:"bytes,1,opt,name=input,proto3" json:"input,omitempty"`
+	Output        string                 `protobuf:"bytes,2,opt,name=output,proto3" json:"output,omitempty"`
+	Animation     string                 `protobuf:"bytes,3,opt,name=animation,proto3" json:"animation,omitempty"`
+	FramesDir     string                 `protobuf:"bytes,4,opt,name=frames_dir,json=framesDir,proto3" json:"frames_dir,omitempty"`
+	Duration      float64                `protobuf:"fixed64,5,opt,name=duration,proto3" json:"duration,omitempty"`
+	Timestamps    []float64              `protobuf:"fixed64,6,rep,packed,name=timestamps,proto3" json:"timestamps,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GeneratePreviewResponse) Reset() {
+	*x = GeneratePreviewResponse{}
+	mi := &file_previewpb_preview_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GeneratePreviewResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GeneratePreviewResponse) ProtoMessage() {}
+
+func (x *GeneratePreviewResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_previewpb_preview_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GeneratePreviewResponse.ProtoReflect.Descriptor instead.
+func (*GeneratePreviewResponse) Descriptor() ([]byte, []int) {
+	return file_previewpb_preview_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GeneratePreviewResponse) GetInput() string {
+	if x != nil {
+		return x.Input
+	}
+	return ""
+}
+
+func (x *GeneratePreviewResponse) GetOutput() string {
+	if x != nil {
+		return x.Output
+	}
+	return ""
+}
+
+func (x *GeneratePreviewResponse) GetAnimation() string {
+	if x != nil {
+		return x.Animation
+	}
+	return ""
+}
+
+func (x *GeneratePreviewResponse) GetFramesDir() string {
+	if x != nil {
+		return x.FramesDir
+	}
+	return ""
+}
+
+func (x *GeneratePreviewResponse) GetDuration() float64 {
+	if x != nil {
+		return x.Duration
+	}
+	return 0
+}
+
+func (x *GeneratePreviewResponse) GetTimestamps() []float64 {
+	if x != nil {
+		return x.Timestamps
+	}
+	return nil
+}
+
+type Progress struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	FramesDone    int32                  `protobuf:"varint,1,opt,name=frames_done,json=framesDone,proto3" json:"frames_done,omitempty"`
+	FramesTotal   int32                  `protobuf:"varint,2,opt,name=frames_total,json=framesTotal,proto3" json:"frames_total,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Progress) Reset() {
+	*x = Progress{}
+	mi := &file_previewpb_preview_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Progress) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Progress) ProtoMessage() {}
+
+func (x *Progress) ProtoReflect() protoreflect.Message {
+	mi := &file_previewpb_preview_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Progress.ProtoReflect.Descriptor instead.
+func (*Progress) Descriptor() ([]byte, []int) {
+	return file_previewpb_preview_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *Progress) GetFramesDone() int32 {
+	if x != nil {
+		return x.FramesDone
+	}
+	return 0
+}
+
+func (x *Progress) GetFramesTotal() int32 {
+	if x != nil {
+		return x.FramesTotal
+	}
+	return 0
+}
+
+type GeneratePreviewEvent struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Event:
+	//
+	//	*GeneratePreviewEvent_Progress
+	//	*GeneratePreviewEvent_Result
+	Event         isGeneratePreviewEvent_Event `protobuf_oneof:"event"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GeneratePreviewEvent) Reset() {
+	*x = GeneratePreviewEvent{}
+	mi := &file_previewpb_preview_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GeneratePreviewEvent) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GeneratePreviewEvent) ProtoMessage() {}
+
+func (x *GeneratePreviewEvent) ProtoReflect() protoreflect.Message {
+	mi := &file_previewpb_preview_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GeneratePreviewEvent.ProtoReflect.Descriptor instead.
+func (*GeneratePreviewEvent) Descriptor() ([]byte, []int) {
+	return file_previewpb_preview_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *GeneratePreviewEvent) GetEvent() isGeneratePreviewEvent_Event {
+	if x != nil {
+		return x.Event
+	}
+	return nil
+}
+
+func (x *GeneratePreviewEvent) GetProgress() *Progress {
+	if x != nil {
+		if x, ok := x.Event.(*GeneratePreviewEvent_Progress); ok {
+			return x.Progress
+		}
+	}
+	return nil
+}
+
+func (x *GeneratePreviewEvent) GetResult() *GeneratePreviewResponse {
+	if x != nil {
+		if x, ok := x.Event.(*GeneratePreviewEvent_Result); ok {
+			return x.Result
+		}
+	}
+	return nil
+}
+
+type isGeneratePreviewEvent_Event interface {
+	isGeneratePreviewEvent_Event()
+}
+
+type GeneratePreviewEvent_Progress struct {
+	Progress *Progress `protobuf:"bytes,1,opt,name=progress,proto3,oneof"`
+}
+
+type GeneratePreviewEvent_Result struct {
+	Result *GeneratePreviewResponse `protobuf:"bytes,2,opt,name=result,proto3,oneof"`
+}
+
+func (*GeneratePreviewEvent_Progress) isGeneratePreviewEvent_Event() {}
+
+func (*GeneratePreviewEvent_Result) isGeneratePreviewEvent_Event() {}
+
+type ProbeVideoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Input         string                 `protobuf:"bytes,1,opt,name=input,proto3" json:"input,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ProbeVideoRequest) Reset() {
+	*x = ProbeVideoRequest{}
+	mi := &file_previewpb_preview_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ProbeVideoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ProbeVideoRequest) ProtoMessage() {}
+
+func (x *ProbeVideoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_previewpb_preview_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ProbeVideoRequest.ProtoReflect.Descriptor instead.
+func (*ProbeVideoRequest) Descriptor() ([]byte, []int) {
+	return file_previewpb_preview_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ProbeVideoRequest) GetInput() string {
+	if x != nil {
+		return x.Input
+	}
+	return ""
+}
+
+type VideoMetadata struct {
+	state          protoimpl.MessageState `protogen:"open.v1"`
+	Format         string                 `protobuf:"bytes,1,opt,name=format,proto3" json:"format,omitempty"`
+	FormatLongName string                 `protobuf:"bytes,2,opt,name=format_long_name,json=formatLongName,proto3" json:"format_long_name,omitempty"`
+	Duration       float64                `protobuf:"fixed64,3,opt,name=duration,proto3" json:"duration,omitempty"`
+	Size           int64                  `protobuf:"varint,4,opt,name=size,proto3" json:"size,omitempty"`
+	BitRate        int64                  `protobuf:"varint,5,opt,name=bit_rate,json=bitRate,proto3" json:"bit_rate,omitempty"`
+	Width          int32                  `protobuf:"varint,6,opt,name=width,proto3" json:"width,omitempty"`
+	Height         int32                  `protobuf:"varint,7,opt,name=height,proto3" json:"height,omitempty"`
+	DisplayWidth   int32                  `protobuf:"varint,8,opt,name=display_width,json=displayWidth,proto3" json:"display_width,omitempty"`
+	DisplayHeight  int32                  `protobuf:"varint,9,opt,name=display_height,json=displayHeight,proto3" json:"display_height,omitempty"`
+	Rotation       int32                  `protobuf:"varint,10,opt,name=rotation,proto3" json:"rotation,omitempty"`
+	VideoCodec     string                 `protobuf:"bytes,11,opt,name=video_codec,json=videoCodec,proto3" json:"video_codec,omitempty"`
+	PixelFormat    string                 `protobuf:"bytes,12,opt,name=pixel_format,json=pixelFormat,proto3" json:"pixel_format,omitempty"`
+	ColorSpace     string                 `protobuf:"bytes,13,opt,name=color_space,json=colorSpace,proto3" json:"color_space,omitempty"`
+	ColorTransfer  string                 `protobuf:"bytes,14,opt,name=color_transfer,json=colorTransfer,proto3" json:"color_transfer,omitempty"`
+	ColorPrimaries string                 `protobuf:"bytes,15,opt,name=color_primaries,json=colorPrimaries,proto3" json:"color_primaries,omitempty"`
+	ColorRange     string                 `protobuf:"bytes,16,opt,name=color_range,json=colorRange,proto3" json:"color_range,omitempty"`
+	Fps            float64                `protobuf:"fixed64,17,opt,name=fps,proto3" json:"fps,omitempty"`
+	VideoBitRate   int64                  `protobuf:"varint,18,opt,name=video_bit_rate,json=videoBitRate,proto3" json:"video_bit_rate,omitempty"`
+	AudioCodec     string                 `protobuf:"bytes,19,opt,name=audio_codec,json=audioCodec,proto3" json:"audio_codec,omitempty"`
+	AudioChannels  int32                  `protobuf:"varint,20,opt,name=audio_channels,json=audioChannels,proto3" json:"audio_channels,omitempty"`
+	SampleRate     int32                  `protobuf:"varint,21,opt,name=sample_rate,json=sampleRate,proto3" json:"sample_rate,omitempty"`
+	Tags           map[string]string      `protobuf:"bytes,22,rep,name=tags,proto3" json:"tags,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	Streams        []*Stream              `protobuf:"bytes,23,rep,name=streams,proto3" json:"streams,omitempty"`
+	Chapters       []*Chapter             `protobuf:"bytes,24,rep,name=chapters,proto3" json:"chapters,omitempty"`
+	unknownFields  protoimpl.UnknownFields
+	sizeCache      protoimpl.SizeCache
+}
+
+func (x *VideoMetadata) Reset() {
+	*x = VideoMetadata{}
+	mi := &file_previewpb_preview_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *VideoMetadata) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*VideoMetadata) ProtoMessage() {}
+
+func (x *VideoMetadata) ProtoReflect() protoreflect.Message {
+	mi := &file_previewpb_preview_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use VideoMetadata.ProtoReflect.Descriptor instead.
+func (*VideoMetadata) Descriptor() ([]byte, []int) {
+	return file_previewpb_preview_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *VideoMetadata) GetFormat() string {
+	if x != nil {
+		return x.Format
+	}
+	return ""
+}
+
+func (x *VideoMetadata) GetFormatLongName() string {
+	if x != nil {
+		return x.FormatLongName
+	}
+	return ""
+}
+
+func (x *VideoMetadata) GetDuration() float64 {
+	if x != nil {
+		return x.Duration
+	}
+	return 0
+}
+
+func (x *VideoMetadata) GetSize() int64 {
+	if x != nil {
+		return x.Size
+	}
+	return 0
+}
+
+func (x *VideoMetadata) GetBitRate() int64 {
+	if x != nil {
+		return x.BitRate
+	}
+	return 0
+}
+
+func (x *VideoMetadata) GetWidth() int32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *VideoMetadata) GetHeight() int32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *VideoMetadata) GetDisplayWidth() int32 {
+	if x != nil {
+		return x.DisplayWidth
+	}
+	return 0
+}
+
+func (x *VideoMetadata) GetDisplayHeight() int32 {
+	if x != nil {
+		return x.DisplayHeight
+	}
+	return 0
+}
+
+func (x *VideoMetadata) GetRotation() int32 {
+	if x != nil {
+		return x.Rotation
+	}
+	return 0
+}
+
+func (x *VideoMetadata) GetVideoCodec() string {
+	if x != nil {
+		return x.VideoCodec
+	}
+	return ""
+}
+
+func (x *VideoMetadata) GetPixelFormat() string {
+	if x != nil {
+		return x.PixelFormat
+	}
+	return ""
+}
+
+func (x *VideoMetadata) GetColorSpace() string {
+	if x != nil {
+		return x.ColorSpace
+	}
+	return ""
+}
+
+func (x *VideoMetadata) GetColorTransfer() string {
+	if x != nil {
+		return x.ColorTransfer
+	}
+	return ""
+}
+
+func (x *VideoMetadata) GetColorPrimaries() string {
+	if x != nil {
+		return x.ColorPrimaries
+	}
+	return ""
+}
+
+func (x *VideoMetadata) GetColorRange() string {
+	if x != nil {
+		return x.ColorRange
+	}
+	return ""
+}
+
+func (x *VideoMetadata) GetFps() float64 {
+	if x != nil {
+		return x.Fps
+	}
+	return 0
+}
+
+func (x *VideoMetadata) GetVideoBitRate() int64 {
+	if x != nil {
+		return x.VideoBitRate
+	}
+	return 0
+}
+
+func (x *VideoMetadata) GetAudioCodec() string {
+	if x != nil {
+		return x.AudioCodec
+	}
+	return ""
+}
+
+func (x *VideoMetadata) GetAudioChannels() int32 {
+	if x != nil {
+		return x.AudioChannels
+	}
+	return 0
+}
+
+func (x *VideoMetadata) GetSampleRate() int32 {
+	if x != nil {
+		return x.SampleRate
+	}
+	return 0
+}
+
+func (x *VideoMetadata) GetTags() map[string]string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *VideoMetadata) GetStreams() []*Stream {
+	if x != nil {
+		return x.Streams
+	}
+	return nil
+}
+
+func (x *VideoMetadata) GetChapters() []*Chapter {
+	if x != nil {
+		return x.Chapters
+	}
+	return nil
+}
+
+type Stream struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Index         int32                  `protobuf:"varint,1,opt,name=index,proto3" json:"index,omitempty"`
+	Type          string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Codec         string                 `protobuf:"bytes,3,opt,name=codec,proto3" json:"codec,omitempty"`
+	CodecLongName string                 `protobuf:"bytes,4,opt,name=codec_long_name,json=codecLongName,proto3" json:"codec_long_name,omitempty"`
+	Profile       string                 `protobuf:"bytes,5,opt,name=profile,proto3" json:"profile,omitempty"`
+	Width         int32                  `protobuf:"varint,6,opt,name=width,proto3" json:"width,omitempty"`
+	Height        int32                  `protobuf:"varint,7,opt,name=height,proto3" json:"height,omitempty"`
+	PixelFormat   string                 `protobuf:"bytes,8,opt,name=pixel_format,json=pixelFormat,proto3" json:"pixel_format,omitempty"`
+	Fps           float64                `protobuf:"fixed64,9,opt,name=fps,proto3" json:"fps,omitempty"`
+	BitRate       int64                  `protobuf:"varint,10,opt,name=bit_rate,json=bitRate,proto3" json:"bit_rate,omitempty"`
+	Duration      float64                `protobuf:"fixed64,11,opt,name=duration,proto3" json:"duration,omitempty"`
+	Channels      int32                  `protobuf:"varint,12,opt,name=channels,proto3" json:"channels,omitempty"`
+	ChannelLayout string                 `protobuf:"bytes,13,opt,name=channel_layout,json=channelLayout,proto3" json:"channel_layout,omitempty"`
+	SampleRate    int32                  `protobuf:"varint,14,opt,name=sample_rate,json=sampleRate,proto3" json:"sample_rate,omitempty"`
+	Rotation      int32                  `protobuf:"varint,15,opt,name=rotation,proto3" json:"rotation,omitempty"`
+	Language      string                 `protobuf:"bytes,16,opt,name=language,proto3" json:"language,omitempty"`
+	Title         string                 `protobuf:"bytes,17,opt,name=title,proto3" json:"title,omitempty"`
+	Tags          map[string]string      `protobuf:"bytes,18,rep,name=tags,proto3" json:"tags,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Stream) Reset() {
+	*x = Stream{}
+	mi := &file_previewpb_preview_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Stream) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Stream) ProtoMessage() {}
+
+func (x *Stream) ProtoReflect() protoreflect.Message {
+	mi := &file_previewpb_preview_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Stream.ProtoReflect.Descriptor instead.
+func (*Stream) Descriptor() ([]byte, []int) {
+	return file_previewpb_preview_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *Stream) GetIndex() int32 {
+	if x != nil {
+		return x.Index
+	}
+	return 0
+}
+
+func (x *Stream) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Stream) GetCodec() string {
+	if x != nil {
+		return x.Codec
+	}
+	return ""
+}
+
+func (x *Stream) GetCodecLongName() string {
+	if x != nil {
+		return x.CodecLongName
+	}
+	return ""
+}
+
+func (x *Stream) GetProfile() string {
+	if x != nil {
+		return x.Profile
+	}
+	return ""
+}
+
+func (x *Stream) GetWidth() int32 {
+	if x != nil {
+		return x.Width
+	}
+	return 0
+}
+
+func (x *Stream) GetHeight() int32 {
+	if x != nil {
+		return x.Height
+	}
+	return 0
+}
+
+func (x *Stream) GetPixelFormat() string {
+	if x != nil {
+		return x.PixelFormat
+	}
+	return ""
+}
+
+func (x *Stream) GetFps() float64 {
+	if x != nil {
+		return x.Fps
+	}
+	return 0
+}
+
+func (x *Stream) GetBitRate() int64 {
+	if x != nil {
+		return x.BitRate
+	}
+	return 0
+}
+
+func (x *Stream) GetDuration() float64 {
+	if x != nil {
+		return x.Duration
+	}
+	return 0
+}
+
+func (x *Stream) GetChannels() int32 {
+	if x != nil {
+		return x.Channels
+	}
+	return 0
+}
+
+func (x *Stream) GetChannelLayout() string {
+	if x != nil {
+		return x.ChannelLayout
+	}
+	return ""
+}
+
+func (x *Stream) GetSampleRate() int32 {
+	if x != nil {
+		return x.SampleRate
+	}
+	return 0
+}
+
+func (x *Stream) GetRotation() int32 {
+	if x != nil {
+		return x.Rotation
+	}
+	return 0
+}
+
+func (x *Stream) GetLanguage() string {
+	if x != nil {
+		return x.Language
+	}
+	return ""
+}
+
+func (x *Stream) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Stream) GetTags() map[string]string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+type Chapter struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Start         float64                `protobuf:"fixed64,2,opt,name=start,proto3" json:"start,omitempty"`
+	End           float64                `protobuf:"fixed64,3,opt,name=end,proto3" json:"end,omitempty"`
+	Title         string                 `protobuf:"bytes,4,opt,name=title,proto3" json:"title,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Chapter) Reset() {
+	*x = Chapter{}
+	mi := &file_previewpb_preview_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Chapter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Chapter) ProtoMessage() {}
+
+func (x *Chapter) ProtoReflect() protoreflect.Message {
+	mi := &file_previewpb_preview_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Chapter.ProtoReflect.Descriptor instead.
+func (*Chapter) Descriptor() ([]byte, []int) {
+	return file_previewpb_preview_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Chapter) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Chapter) GetStart() float64 {
+	if x != nil {
+		return x.Start
+	}
+	return 0
+}
+
+func (x *Chapter) GetEnd() float64 {
+	if x != nil {
+		return x.End
+	}
+	return 0
+}
+
+func (x *Chapter) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+var File_previewpb_preview_proto protoreflect.FileDescriptor
+
+const file_previewpb_preview_proto_rawDesc = "" +
+	"\n" +
+	"\x17previewpb/preview.proto\x12\x0fvideopreview.v1\"\xd2\x01\n" +
+	"\x16GeneratePreviewRequest\x12\x14\n" +
+	"\x05input\x18\x01 \x01(\tR\x05input\x12\x16\n" +
+	"\x06output\x18\x02 \x01(\tR\x06output\x12N\n" +
+	"\aoptions\x18\x03 \x03(\v24.videopreview.v1.GeneratePreviewRequest.OptionsEntryR\aoptions\x1a:\n" +
+	"\fOptionsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xc0\x01\n" +
+	"\x17GeneratePreviewResponse\x12\x14\n" +
+	"\x05input\x18\x01 \x01(\tR\x05input\x12\x16\n" +
+	"\x06output\x18\x02 \x01(\tR\x06output\x12\x1c\n" +
+	"\tanimation\x18\x03 \x01(\tR\tanimation\x12\x1d\n" +
+	"\n" +
+	"frames_dir\x18\x04 \x01(\tR\tframesDir\x12\x1a\n" +
+	"\bduration\x18\x05 \x01(\x01R\bduration\x12\x1e\n" +
+	"\n" +
+	"timestamps\x18\x06 \x03(\x01R\n" +
+	"timestamps\"N\n" +
+	"\bProgress\x12\x1f\n" +
+	"\vframes_done\x18\x01 \x01(\x05R\n" +
+	"framesDone\x12!\n" +
+	"\fframes_total\x18\x02 \x01(\x05R\vframesTotal\"\x9c\x01\n" +
+	"\x14GeneratePreviewEvent\x127\n" +
+	"\bprogress\x18\x01 \x01(\v2\x19.videopreview.v1.ProgressH\x00R\bprogress\x12B\n" +
+	"\x06result\x18\x02 \x01(\v2(.videopreview.v1.GeneratePreviewResponseH\x00R\x06resultB\a\n" +
+	"\x05event\")\n" +
+	"\x11ProbeVideoRequest\x12\x14\n" +
+	"\x05input\x18\x01 \x01(\tR\x05input\"\x89\a\n" +
+	"\rVideoMetadata\x12\x16\n" +
+	"\x06format\x18\x01 \x01(\tR\x06format\x12(\n" +
+	"\x10format_long_name\x18\x02 \x01(\tR\x0eformatLongName\x12\x1a\n" +
+	"\bduration\x18\x03 \x01(\x01R\bduration\x12\x12\n" +
+	"\x04size\x18\x04 \x01(\x03R\x04size\x12\x19\n" +
+	"\bbit_rate\x18\x05 \x01(\x03R\abitRate\x12\x14\n" +
+	"\x05width\x18\x06 \x01(\x05R\x05width\x12\x16\n" +
+	"\x06height\x18\a \x01(\x05R\x06height\x12#\n" +
+	"\rdisplay_width\x18\b \x01(\x05R\fdisplayWidth\x12%\n" +
+	"\x0edisplay_height\x18\t \x01(\x05R\rdisplayHeight\x12\x1a\n" +
+	"\brotation\x18\n" +
+	" \x01(\x05R\brotation\x12\x1f\n" +
+	"\vvideo_codec\x18\v \x01(\tR\n" +
+	"videoCodec\x12!\n" +
+	"\fpixel_format\x18\f \x01(\tR\vpixelFormat\x12\x1f\n" +
+	"\vcolor_space\x18\r \x01(\tR\n" +
+	"colorSpace\x12%\n" +
+	"\x0ecolor_transfer\x18\x0e \x01(\tR\rcolorTransfer\x12'\n" +
+	"\x0fcolor_primaries\x18\x0f \x01(\tR\x0ecolorPrimaries\x12\x1f\n" +
+	"\vcolor_range\x18\x10 \x01(\tR\n" +
+	"colorRange\x12\x10\n" +
+	"\x03fps\x18\x11 \x01(\x01R\x03fps\x12$\n" +
+	"\x0evideo_bit_rate\x18\x12 \x01(\x03R\fvideoBitRate\x12\x1f\n" +
+	"\vaudio_codec\x18\x13 \x01(\tR\n" +
+	"audioCodec\x12%\n" +
+	"\x0eaudio_channels\x18\x14 \x01(\x05R\raudioChannels\x12\x1f\n" +
+	"\vsample_rate\x18\x15 \x01(\x05R\n" +
+	"sampleRate\x12<\n" +
+	"\x04tags\x18\x16 \x03(\v2(.videopreview.v1.VideoMetadata.TagsEntryR\x04tags\x121\n" +
+	"\astreams\x18\x17 \x03(\v2\x17.videopreview.v1.StreamR\astreams\x124\n" +
+	"\bchapters\x18\x18 \x03(\v2\x18.videopreview.v1.ChapterR\bchapters\x1a7\n" +
+	"\tTagsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"\xc6\x04\n" +
+	"\x06Stream\x12\x14\n" +
+	"\x05index\x18\x01 \x01(\x05R\x05index\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12\x14\n" +
+	"\x05codec\x18\x03 \x01(\tR\x05codec\x12&\n" +
+	"\x0fcodec_long_name\x18\x04 \x01(\tR\rcodecLongName\x12\x18\n" +
+	"\aprofile\x18\x05 \x01(\tR\aprofile\x12\x14\n" +
+	"\x05width\x18\x06 \x01(\x05R\x05width\x12\x16\n" +
+	"\x06height\x18\a \x01(\x05R\x06height\x12!\n" +
+	"\fpixel_format\x18\b \x01(\tR\vpixelFormat\x12\x10\n" +
+	"\x03fps\x18\t \x01(\x01R\x03fps\x12\x19\n" +
+	"\bbit_rate\x18\n" +
+	" \x01(\x03R\abitRate\x12\x1a\n" +
+	"\bduration\x18\v \x01(\x01R\bduration\x12\x1a\n" +
+	"\bchannels\x18\f \x01(\x05R\bchannels\x12%\n" +
+	"\x0echannel_layout\x18\r \x01(\tR\rchannelLayout\x12\x1f\n" +
+	"\vsample_rate\x18\x0e \x01(\x05R\n" +
+	"sampleRate\x12\x1a\n" +
+	"\brotation\x18\x0f \x01(\x05R\brotation\x12\x1a\n" +
+	"\blanguage\x18\x10 \x01(\tR\blanguage\x12\x14\n" +
+	"\x05title\x18\x11 \x01(\tR\x05title\x125\n" +
+	"\x04tags\x18\x12 \x03(\v2!.videopreview.v1.Stream.TagsEntryR\x04tags\x1a7\n" +
+	"\tTagsEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\tR\x05value:\x028\x01\"W\n" +
+	"\aChapter\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x14\n" +
+	"\x05start\x18\x02 \x01(\x01R\x05start\x12\x10\n" +
+	"\x03end\x18\x03 \x01(\x01R\x03end\x12\x14\n" +
+	"\x05title\x18\x04 \x01(\tR\x05title2\xb3\x02\n" +
+	"\x0ePreviewService\x12d\n" +
+	"\x0fGeneratePreview\x12'.videopreview.v1.GeneratePreviewRequest\x1a(.videopreview.v1.GeneratePreviewResponse\x12i\n" +
+	"\x15GeneratePreviewStream\x12'.videopreview.v1.GeneratePreviewRequest\x1a%.videopreview.v1.GeneratePreviewEvent0\x01\x12P\n" +
+	"\n" +
+	"ProbeVideo\x12\".videopreview.v1.ProbeVideoRequest\x1a\x1e.videopreview.v1.VideoMetadataB\x1fZ\x1dvideo-preview-image/previewpbb\x06proto3"
+
+var (
+	file_previewpb_preview_proto_rawDescOnce sync.Once
+	file_previewpb_preview_proto_rawDescData []byte
+)
+
+func file_previewpb_preview_proto_rawDescGZIP() []byte {
+	file_previewpb_preview_proto_rawDescOnce.Do(func() {
+		file_previewpb_preview_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_previewpb_preview_proto_rawDesc), len(file_previewpb_preview_proto_rawDesc)))
+	})
+	return file_previewpb_preview_proto_rawDescData
+}
+
+var file_previewpb_preview_proto_msgTypes = make([]protoimpl.MessageInfo, 11)
+var file_previewpb_preview_proto_goTypes = []any{
+	(*GeneratePreviewRequest)(nil),  // 0: videopreview.v1.GeneratePreviewRequest
+	(*GeneratePreviewResponse)(nil), // 1: videopreview.v1.GeneratePreviewResponse
+	(*Progress)(nil),                // 2: videopreview.v1.Progress
+	(*GeneratePreviewEvent)(nil),    // 3: videopreview.v1.GeneratePreviewEvent
+	(*ProbeVideoRequest)(nil),       // 4: videopreview.v1.ProbeVideoRequest
+	(*VideoMetadata)(nil),           // 5: videopreview.v1.VideoMetadata
+	(*Stream)(nil),                  // 6: videopreview.v1.Stream
+	(*Chapter)(nil),                 // 7: videopreview.v1.Chapter
+	nil,                             // 8: videopreview.v1.GeneratePreviewRequest.OptionsEntry
+	nil,                             // 9: videopreview.v1.VideoMetadata.TagsEntry
+	nil,                             // 10: videopreview.v1.Stream.TagsEntry
+}
+var file_previewpb_preview_proto_depIdxs = []int32{
+	8,  // 0: videopreview.v1.GeneratePreviewRequest.options:type_name -> videopreview.v1.GeneratePreviewRequest.OptionsEntry
+	2,  // 1: videopreview.v1.GeneratePreviewEvent.progress:type_name -> videopreview.v1.Progress
+	1,  // 2: videopreview.v1.GeneratePreviewEvent.result:type_name -> videopreview.v1.GeneratePreviewResponse
+	9,  // 3: videopreview.v1.VideoMetadata.tags:type_name -> videopreview.v1.VideoMetadata.TagsEntry
+	6,  // 4: videopreview.v1.VideoMetadata.streams:type_name -> videopreview.v1.Stream
+	7,  // 5: videopreview.v1.VideoMetadata.chapters:type_name -> videopreview.v1.Chapter
+	10, // 6: videopreview.v1.Stream.tags:type_name -> videopreview.v1.Stream.TagsEntry
+	0,  // 7: videopreview.v1.PreviewService.GeneratePreview:input_type -> videopreview.v1.GeneratePreviewRequest
+	0,  // 8: videopreview.v1.PreviewService.GeneratePreviewStream:input_type -> videopreview.v1.GeneratePreviewRequest
+	4,  // 9: videopreview.v1.PreviewService.ProbeVideo:input_type -> videopreview.v1.ProbeVideoRequest
+	1,  // 10: videopreview.v1.PreviewService.GeneratePreview:output_type -> videopreview.v1.GeneratePreviewResponse
+	3,  // 11: videopreview.v1.PreviewService.GeneratePreviewStream:output_type -> videopreview.v1.GeneratePreviewEvent
+	5,  // 12: videopreview.v1.PreviewService.ProbeVideo:output_type -> videopreview.v1.VideoMetadata
+	10, // [10:13] is the sub-list for method output_type
+	7,  // [7:10] is the sub-list for method input_type
+	7,  // [7:7] is the sub-list for extension type_name
+	7,  // [7:7] is the sub-list for extension extendee
+	0,  // [0:7] is the sub-list for field type_name
+}
+
+func init() { file_previewpb_preview_proto_init() }
+func file_previewpb_preview_proto_init() {
+	if File_previewpb_preview_proto != nil {
+		return
+	}
+	file_previewpb_preview_proto_msgTypes[3].OneofWrappers = []any{
+		(*GeneratePreviewEvent_Progress)(nil),
+		(*GeneratePreviewEvent_Result)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_previewpb_preview_proto_rawDesc), len(file_previewpb_preview_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   11,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_previewpb_preview_proto_goTypes,
+		DependencyIndexes: file_previewpb_preview_proto_depIdxs,
+		MessageInfos:      file_previewpb_preview_proto_msgTypes,
+	}.Build()
+	File_previewpb_preview_proto = out.File
+	file_previewpb_preview_proto_goTypes = nil
+	file_previewpb_preview_proto_depIdxs = nil
+}
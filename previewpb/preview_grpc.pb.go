@@ -0,0 +1,211 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.6.1
+// - protoc             v5.29.3
+// source: previewpb/preview.proto
+
+package previewpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	PreviewService_GeneratePreview_FullMethodName       = "/videopreview.v1.PreviewService/GeneratePreview"
+	PreviewService_GeneratePreviewStream_FullMethodName = "/videopreview.v1.PreviewService/GeneratePreviewStream"
+	PreviewService_ProbeVideo_FullMethodName            = "/videopreview.v1.PreviewService/ProbeVideo"
+)
+
+// PreviewServiceClient is the client API for PreviewService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// PreviewService 通过 gRPC 暴露预览图生成与视频信息读取，供任务编排系统调用。
+type PreviewServiceClient interface {
+	// GeneratePreview 生成预览图，完成后一次性返回结果。
+	GeneratePreview(ctx context.Context, in *GeneratePreviewRequest, opts ...grpc.CallOption) (*GeneratePreviewResponse, error)
+	// GeneratePreviewStream 与 GeneratePreview 相同，但在每张截图完成后推送进度，最后一条消息为结果。
+	GeneratePreviewStream(ctx context.Context, in *GeneratePreviewRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GeneratePreviewEvent], error)
+	// ProbeVideo 返回与 probe 子命令相同的视频信息。
+	ProbeVideo(ctx context.Context, in *ProbeVideoRequest, opts ...grpc.CallOption) (*VideoMetadata, error)
+}
+
+type previewServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPreviewServiceClient(cc grpc.ClientConnInterface) PreviewServiceClient {
+	return &previewServiceClient{cc}
+}
+
+func (c *previewServiceClient) GeneratePreview(ctx context.Context, in *GeneratePreviewRequest, opts ...grpc.CallOption) (*GeneratePreviewResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GeneratePreviewResponse)
+	err := c.cc.Invoke(ctx, PreviewService_GeneratePreview_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *previewServiceClient) GeneratePreviewStream(ctx context.Context, in *GeneratePreviewRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[GeneratePreviewEvent], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &PreviewService_ServiceDesc.Streams[0], PreviewService_GeneratePreviewStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[GeneratePreviewRequest, GeneratePreviewEvent]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PreviewService_GeneratePreviewStreamClient = grpc.ServerStreamingClient[GeneratePreviewEvent]
+
+func (c *previewServiceClient) ProbeVideo(ctx context.Context, in *ProbeVideoRequest, opts ...grpc.CallOption) (*VideoMetadata, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(VideoMetadata)
+	err := c.cc.Invoke(ctx, PreviewService_ProbeVideo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// PreviewServiceServer is the server API for PreviewService service.
+// All implementations must embed UnimplementedPreviewServiceServer
+// for forward compatibility.
+//
+// PreviewService 通过 gRPC 暴露预览图生成与视频信息读取，供任务编排系统调用。
+type PreviewServiceServer interface {
+	// GeneratePreview 生成预览图，完成后一次性返回结果。
+	GeneratePreview(context.Context, *GeneratePreviewRequest) (*GeneratePreviewResponse, error)
+	// GeneratePreviewStream 与 GeneratePreview 相同，但在每张截图完成后推送进度，最后一条消息为结果。
+	GeneratePreviewStream(*GeneratePreviewRequest, grpc.ServerStreamingServer[GeneratePreviewEvent]) error
+	// ProbeVideo 返回与 probe 子命令相同的视频信息。
+	ProbeVideo(context.Context, *ProbeVideoRequest) (*VideoMetadata, error)
+	mustEmbedUnimplementedPreviewServiceServer()
+}
+
+// UnimplementedPreviewServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedPreviewServiceServer struct{}
+
+func (UnimplementedPreviewServiceServer) GeneratePreview(context.Context, *GeneratePreviewRequest) (*GeneratePreviewResponse, error) {
+	return nil, status.Error(codes.Unimplemented, "method GeneratePreview not implemented")
+}
+func (UnimplementedPreviewServiceServer) GeneratePreviewStream(*GeneratePreviewRequest, grpc.ServerStreamingServer[GeneratePreviewEvent]) error {
+	return status.Error(codes.Unimplemented, "method GeneratePreviewStream not implemented")
+}
+func (UnimplementedPreviewServiceServer) ProbeVideo(context.Context, *ProbeVideoRequest) (*VideoMetadata, error) {
+	return nil, status.Error(codes.Unimplemented, "method ProbeVideo not implemented")
+}
+func (UnimplementedPreviewServiceServer) mustEmbedUnimplementedPreviewServiceServer() {}
+func (UnimplementedPreviewServiceServer) testEmbeddedByValue()                        {}
+
+// UnsafePreviewServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PreviewServiceServer will
+// result in compilation errors.
+type UnsafePreviewServiceServer interface {
+	mustEmbedUnimplementedPreviewServiceServer()
+}
+
+func RegisterPreviewServiceServer(s grpc.ServiceRegistrar, srv PreviewServiceServer) {
+	// If the following call panics, it indicates UnimplementedPreviewServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&PreviewService_ServiceDesc, srv)
+}
+
+func _PreviewService_GeneratePreview_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GeneratePreviewRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PreviewServiceServer).GeneratePreview(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PreviewService_GeneratePreview_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PreviewServiceServer).GeneratePreview(ctx, req.(*GeneratePreviewRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _PreviewService_GeneratePreviewStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(GeneratePreviewRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PreviewServiceServer).GeneratePreviewStream(m, &grpc.GenericServerStream[GeneratePreviewRequest, GeneratePreviewEvent]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PreviewService_GeneratePreviewStreamServer = grpc.ServerStreamingServer[GeneratePreviewEvent]
+
+func _PreviewService_ProbeVideo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ProbeVideoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PreviewServiceServer).ProbeVideo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: PreviewService_ProbeVideo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PreviewServiceServer).ProbeVideo(ctx, req.(*ProbeVideoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// PreviewService_ServiceDesc is the grpc.ServiceDesc for PreviewService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PreviewService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "videopreview.v1.PreviewService",
+	HandlerType: (*PreviewServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GeneratePreview",
+			Handler:    _PreviewService_GeneratePreview_Handler,
+		},
+		{
+			MethodName: "ProbeVideo",
+			Handler:    _PreviewService_ProbeVideo_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "GeneratePreviewStream",
+			Handler:       _PreviewService_GeneratePreviewStream_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "previewpb/preview.proto",
+}
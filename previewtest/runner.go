@@ -0,0 +1,136 @@
+// Package previewtest 提供测试用的假命令执行器：记录每次调用 ffmpeg、ffprobe 等外部程序的参数，
+// 并以测试二进制自身作为假程序输出预设结果，在没有安装 ffmpeg 的环境中也能测试完整流程。
+//
+// 使用时在测试包的 TestMain 中调用 Main，再把 NewRunner 返回的 Runner 赋给 preview.Generator 的 Runner 字段。
+package previewtest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// 假程序从这些环境变量读取要输出的内容与退出码。
+const (
+	envStdout = "PREVIEWTEST_STDOUT"
+	envStderr = "PREVIEWTEST_STDERR"
+	envExit   = "PREVIEWTEST_EXIT"
+)
+
+// Call 为一次外部程序调用，Name 为调用方传入的程序路径。
+type Call struct {
+	Name string
+	Args []string
+}
+
+// Result 为假程序的行为：先把 Stdout、Stderr 分别写到标准输出与标准错误，再以 ExitCode 退出。
+type Result struct {
+	Stdout   []byte
+	Stderr   string
+	ExitCode int
+}
+
+// Runner 记录每次调用并按 handle 的返回值启动假程序，可以在多个 goroutine 中同时使用。
+type Runner struct {
+	dir    string
+	handle func(Call) Result
+
+	mu    sync.Mutex
+	calls []Call
+}
+
+// NewRunner 返回按 handle 决定输出的 Runner；handle 为 nil 时假程序不输出任何内容并以 0 退出。
+// 假程序的输出写在 t 的临时目录中，测试结束后自动删除。
+func NewRunner(t testing.TB, handle func(Call) Result) *Runner {
+	if handle == nil {
+		handle = func(Call) Result { return Result{} }
+	}
+	return &Runner{dir: t.TempDir(), handle: handle}
+}
+
+// Command 记录调用后返回启动测试二进制的命令，该进程在 Main 中输出 handle 返回的结果；
+// ctx 结束时与 exec.CommandContext 一样终止假程序。
+func (r *Runner) Command(ctx context.Context, name string, args ...string) *exec.Cmd {
+	call := Call{Name: name, Args: slices.Clone(args)}
+	r.mu.Lock()
+	r.calls = append(r.calls, call)
+	n := len(r.calls)
+	r.mu.Unlock()
+
+	result := r.handle(call)
+	stdout := filepath.Join(r.dir, fmt.Sprintf("stdout-%d", n))
+	stderr := filepath.Join(r.dir, fmt.Sprintf("stderr-%d", n))
+	// 写入失败时假程序读不到文件，以退出码 127 报告，与找不到程序时的 shell 行为一致。
+	_ = os.WriteFile(stdout, result.Stdout, 0o644)
+	_ = os.WriteFile(stderr, []byte(result.Stderr), 0o644)
+
+	cmd := exec.CommandContext(ctx, os.Args[0], "-test.run=^$")
+	cmd.Env = append(os.Environ(),
+		envStdout+"="+stdout,
+		envStderr+"="+stderr,
+		envExit+"="+strconv.Itoa(result.ExitCode),
+	)
+	return cmd
+}
+
+// Calls 返回到目前为止的全部调用，按调用顺序排列。
+func (r *Runner) Calls() []Call {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.calls)
+}
+
+// Main 在 TestMain 中代替 os.Exit(m.Run()) 调用：测试二进制被 Runner 作为假程序启动时输出预设结果后退出，
+// 否则正常运行测试。
+func Main(m *testing.M) {
+	if code, ok := os.LookupEnv(envExit); ok {
+		os.Exit(fakeProcess(code))
+	}
+	os.Exit(m.Run())
+}
+
+func fakeProcess(code string) int {
+	for _, output := range []struct {
+		env string
+		w   *os.File
+	}{{envStdout, os.Stdout}, {envStderr, os.Stderr}} {
+		data, err := os.ReadFile(os.Getenv(output.env))
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			return 127
+		}
+		if _, err := output.w.Write(data); err != nil {
+			return 1
+		}
+	}
+	exit, err := strconv.Atoi(code)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "无效的退出码: %s\n", code)
+		return 127
+	}
+	return exit
+}
+
+// PNG 返回 width×height、以 c 填充的 PNG 图片，可作为假 ffmpeg 截图的输出。
+func PNG(width, height int, c color.Color) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := range height {
+		for x := range width {
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	// 写入内存不会失败。
+	_ = png.Encode(&buf, img)
+	return buf.Bytes()
+}